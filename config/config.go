@@ -0,0 +1,269 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/paddyquinn/messari/database"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// configSetting is the name of the setting holding the path to the config file. It can only be set by a flag or an
+	// environment variable.
+	configSetting = "config"
+
+	// envPrefix is prepended to the upper snake case name of a setting to get the environment variable that sets it,
+	// e.g. MESSARI_LOG_LEVEL sets log-level.
+	envPrefix = "MESSARI_"
+)
+
+// Config holds the settings the server is run with.
+type Config struct {
+	// DBPath is the path to the SQLite database file, which is created if it does not exist.
+	DBPath string
+
+	// Address is the TCP address the server listens on, e.g. ":8080".
+	Address string
+
+	// LogLevel is the least severe level that is logged.
+	LogLevel log.Level
+
+	// ReadTimeout, WriteTimeout and IdleTimeout are the longest the server spends reading a request, writing a
+	// response and waiting for the next request on a kept-alive connection. A timeout of 0 never times out. Exports
+	// are not bound by the write timeout, and imports are bound by neither the read nor the write timeout.
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+
+	// ShutdownTimeout is the longest the server waits for in-flight requests to finish when it is stopped.
+	ShutdownTimeout time.Duration
+
+	// Auth requires clients to authenticate with an API key. PublicRead lets clients without an API key use the
+	// endpoints that only read crypto assets while Auth is on.
+	Auth       bool
+	PublicRead bool
+
+	// IdempotencyTTL is how long the response to a request made with an Idempotency-Key header is kept for replaying
+	// to retries of the request.
+	IdempotencyTTL time.Duration
+}
+
+// setting is a single configurable value. The usage is shown by the -help flag and set parses a value into a config.
+type setting struct {
+	usage string
+	set   func(cfg *Config, value string) error
+}
+
+// settings maps the name of every setting to how it is set. The name is used as is for the flag and the config file
+// key.
+var settings = map[string]setting{
+	"db": {
+		usage: fmt.Sprintf("path to the SQLite database file, or %s to keep the database in memory", database.InMemoryPath),
+		set: func(cfg *Config, value string) error {
+			cfg.DBPath = value
+			return nil
+		},
+	},
+	"address": {
+		usage: "TCP address to listen on",
+		set: func(cfg *Config, value string) error {
+			cfg.Address = value
+			return nil
+		},
+	},
+	"log-level": {
+		usage: "least severe level to log: debug, info, warning, error, fatal or panic",
+		set: func(cfg *Config, value string) (err error) {
+			cfg.LogLevel, err = log.ParseLevel(value)
+			return err
+		},
+	},
+	"read-timeout": {
+		usage: "longest to spend reading a request other than an import, e.g. 5s, or 0 to never time out",
+		set: func(cfg *Config, value string) (err error) {
+			cfg.ReadTimeout, err = time.ParseDuration(value)
+			return err
+		},
+	},
+	"write-timeout": {
+		usage: "longest to spend writing a response other than an export or import, e.g. 30s, or 0 to never time out",
+		set: func(cfg *Config, value string) (err error) {
+			cfg.WriteTimeout, err = time.ParseDuration(value)
+			return err
+		},
+	},
+	"idle-timeout": {
+		usage: "longest to keep an idle connection open, e.g. 1m, or 0 to never time out",
+		set: func(cfg *Config, value string) (err error) {
+			cfg.IdleTimeout, err = time.ParseDuration(value)
+			return err
+		},
+	},
+	"auth": {
+		usage: "require an API key with the read, write or admin scope to use the API: true or false",
+		set: func(cfg *Config, value string) (err error) {
+			cfg.Auth, err = strconv.ParseBool(value)
+			return err
+		},
+	},
+	"public-read": {
+		usage: "allow reading crypto assets without an API key when auth is on: true or false",
+		set: func(cfg *Config, value string) (err error) {
+			cfg.PublicRead, err = strconv.ParseBool(value)
+			return err
+		},
+	},
+	"idempotency-ttl": {
+		usage: "how long to keep the response to a request with an Idempotency-Key header, e.g. 24h",
+		set: func(cfg *Config, value string) (err error) {
+			cfg.IdempotencyTTL, err = time.ParseDuration(value)
+			return err
+		},
+	},
+	"shutdown-timeout": {
+		usage: "longest to wait for in-flight requests to finish when stopping, e.g. 15s",
+		set: func(cfg *Config, value string) (err error) {
+			cfg.ShutdownTimeout, err = time.ParseDuration(value)
+			return err
+		},
+	},
+}
+
+// Default creates a new config with the settings the server has always run with, except that writes now require an API
+// key.
+func Default() *Config {
+	return &Config{
+		DBPath:   "database/data/sqlite",
+		Address:  ":8080",
+		LogLevel: log.InfoLevel,
+
+		ReadTimeout:     5 * time.Second,
+		WriteTimeout:    30 * time.Second,
+		IdleTimeout:     time.Minute,
+		ShutdownTimeout: 15 * time.Second,
+
+		Auth:       true,
+		PublicRead: true,
+
+		IdempotencyTTL: 24 * time.Hour,
+	}
+}
+
+// Load creates a new config from the defaults, an optional YAML or TOML config file, MESSARI_* environment variables
+// and the command line arguments, which do not include the program name. Each source overrides the ones before it, so
+// a flag always wins. The config file is given by the -config flag or the MESSARI_CONFIG environment variable and its
+// format is chosen by its extension. flag.ErrHelp is returned if the -help flag was passed.
+func Load(args []string) (*Config, error) {
+	// Parse the flags first to find out which config file to read, but apply them last.
+	flags := flag.NewFlagSet("messari", flag.ContinueOnError)
+	for _, name := range settingNames() {
+		flags.String(name, "", settings[name].usage)
+	}
+	configPath := flags.String(configSetting, "", "path to a YAML (.yaml or .yml) or TOML (.toml) config file")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if flags.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument: %s", flags.Arg(0))
+	}
+
+	cfg := Default()
+
+	// Apply the config file.
+	if *configPath == "" {
+		*configPath = os.Getenv(envVar(configSetting))
+	}
+	if *configPath != "" {
+		if err := cfg.loadFile(*configPath); err != nil {
+			return nil, err
+		}
+	}
+
+	// Apply the environment variables.
+	for _, name := range settingNames() {
+		if value, ok := os.LookupEnv(envVar(name)); ok {
+			if err := cfg.set(name, value); err != nil {
+				return nil, fmt.Errorf("invalid %s: %s", envVar(name), err.Error())
+			}
+		}
+	}
+
+	// Apply the flags that were passed.
+	var err error
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name != configSetting && err == nil {
+			if setErr := cfg.set(f.Name, f.Value.String()); setErr != nil {
+				err = fmt.Errorf("invalid -%s: %s", f.Name, setErr.Error())
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// loadFile applies the settings in a YAML or TOML config file. An error is returned if the file has an unknown
+// extension, cannot be parsed or contains an unknown setting.
+func (cfg *Config) loadFile(path string) error {
+	buffer, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	values := make(map[string]interface{})
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(buffer, &values)
+	case ".toml":
+		_, err = toml.Decode(string(buffer), &values)
+	default:
+		return fmt.Errorf("unknown config file format: %s", path)
+	}
+	if err != nil {
+		return fmt.Errorf("could not parse %s: %s", path, err.Error())
+	}
+
+	for name, value := range values {
+		if err = cfg.set(name, fmt.Sprint(value)); err != nil {
+			return fmt.Errorf("invalid %s in %s: %s", name, path, err.Error())
+		}
+	}
+	return nil
+}
+
+// set sets the named setting to the value.
+func (cfg *Config) set(name, value string) error {
+	s, ok := settings[name]
+	if !ok {
+		return errors.New("unknown setting")
+	}
+	return s.set(cfg, value)
+}
+
+// envVar returns the environment variable that sets the named setting.
+func envVar(name string) string {
+	return envPrefix + strings.ToUpper(strings.Replace(name, "-", "_", -1))
+}
+
+// settingNames returns the name of every setting in alphabetical order.
+func settingNames() []string {
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func TestLoad(t *testing.T) {
+	// Create a temporary directory for the config files.
+	dir, err := ioutil.TempDir("", "messari-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Run tests.
+	testLoadDefault(t)
+	testLoadPrecedence(t, dir)
+	testLoadTOML(t, dir)
+	testLoadUnknownSetting(t, dir)
+	testLoadInvalidLogLevel(t)
+	testLoadInvalidTimeout(t)
+	testLoadInvalidAuth(t)
+}
+
+func testLoadDefault(t *testing.T) {
+	cfg, err := Load(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	assertConfig(t, Default(), cfg)
+}
+
+func testLoadPrecedence(t *testing.T, dir string) {
+	// The config file sets everything, the environment overrides the address and log level and the flags override the
+	// log level again.
+	path := writeConfigFile(t, dir, "config.yaml", "db: /tmp/registry.db\naddress: :9000\nlog-level: error\n")
+	setEnv(t, "MESSARI_CONFIG", path)
+	setEnv(t, "MESSARI_ADDRESS", ":9001")
+	setEnv(t, "MESSARI_LOG_LEVEL", "warning")
+	defer os.Unsetenv("MESSARI_CONFIG")
+	defer os.Unsetenv("MESSARI_ADDRESS")
+	defer os.Unsetenv("MESSARI_LOG_LEVEL")
+
+	cfg, err := Load([]string{"-log-level", "debug"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	expected := Default()
+	expected.DBPath = "/tmp/registry.db"
+	expected.Address = ":9001"
+	expected.LogLevel = log.DebugLevel
+	assertConfig(t, expected, cfg)
+}
+
+func testLoadTOML(t *testing.T, dir string) {
+	path := writeConfigFile(t, dir, "config.toml", "db = \":memory:\"\nshutdown-timeout = \"1m30s\"\n"+
+		"public-read = false\nidempotency-ttl = \"1h\"\n")
+
+	cfg, err := Load([]string{"-config", path})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	expected := Default()
+	expected.DBPath = ":memory:"
+	expected.ShutdownTimeout = 90 * time.Second
+	expected.PublicRead = false
+	expected.IdempotencyTTL = time.Hour
+	assertConfig(t, expected, cfg)
+}
+
+func testLoadUnknownSetting(t *testing.T, dir string) {
+	path := writeConfigFile(t, dir, "unknown.yml", "port: 8080\n")
+
+	if _, err := Load([]string{"-config", path}); err == nil {
+		t.Fatal("expected an error loading a config file with an unknown setting")
+	}
+}
+
+func testLoadInvalidLogLevel(t *testing.T) {
+	if _, err := Load([]string{"-log-level", "loud"}); err == nil {
+		t.Fatal("expected an error loading an invalid log level")
+	}
+}
+
+func testLoadInvalidTimeout(t *testing.T) {
+	if _, err := Load([]string{"-read-timeout", "5"}); err == nil {
+		t.Fatal("expected an error loading a timeout without a unit")
+	}
+}
+
+func testLoadInvalidAuth(t *testing.T) {
+	if _, err := Load([]string{"-auth", "maybe"}); err == nil {
+		t.Fatal("expected an error loading an auth setting that is not a boolean")
+	}
+}
+
+func assertConfig(t *testing.T, expected, actual *Config) {
+	if *expected != *actual {
+		t.Fatalf("expected config %+v, got %+v", *expected, *actual)
+	}
+}
+
+func setEnv(t *testing.T, key, value string) {
+	if err := os.Setenv(key, value); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeConfigFile(t *testing.T, dir, name, contents string) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,115 @@
+package database
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"time"
+
+	"github.com/paddyquinn/messari/database/models"
+)
+
+// apiKeyBytes is the number of random bytes in an API key, which is hex encoded when it is issued.
+const apiKeyBytes = 32
+
+// APIKeys returns every API key that has been issued, including revoked keys, oldest first. The keys themselves are
+// not known.
+func (s *SQLite) APIKeys() ([]*models.APIKey, error) {
+	rows, err := s.connection.Query("SELECT id, name, scope, createdAt, revokedAt FROM api_key ORDER BY id;")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	apiKeys := []*models.APIKey{}
+	for rows.Next() {
+		apiKey := &models.APIKey{}
+		if err = rows.Scan(&apiKey.ID, &apiKey.Name, &apiKey.Scope, &apiKey.CreatedAt, &apiKey.RevokedAt); err != nil {
+			return nil, err
+		}
+		apiKeys = append(apiKeys, apiKey)
+	}
+
+	return apiKeys, rows.Err()
+}
+
+// Authenticate looks up the API key a client passed. An InvalidAPIKeyError is returned if the key was never issued or
+// has been revoked.
+func (s *SQLite) Authenticate(key string) (*models.APIKey, error) {
+	apiKey := &models.APIKey{}
+	err := s.connection.QueryRow("SELECT id, name, scope, createdAt FROM api_key WHERE hash = ? AND revokedAt IS NULL;",
+		hashAPIKey(key)).Scan(&apiKey.ID, &apiKey.Name, &apiKey.Scope, &apiKey.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, NewInvalidAPIKeyError()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return apiKey, nil
+}
+
+// CreateAPIKey issues a new API key with the given name and scope. Only a hash of the key is stored, so the returned
+// API key is the only place the key itself can be read from. A NullConstraintError is returned if the name is empty and
+// an InvalidScopeError is returned if the scope does not exist.
+func (s *SQLite) CreateAPIKey(name, scope string) (*models.APIKey, error) {
+	if name == emptyString {
+		return nil, NewNullConstraintError("name")
+	}
+	if !models.IsScope(scope) {
+		return nil, NewInvalidScopeError(scope)
+	}
+
+	buffer := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(buffer); err != nil {
+		return nil, err
+	}
+
+	apiKey := &models.APIKey{
+		Name:      name,
+		Scope:     scope,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		Key:       hex.EncodeToString(buffer),
+	}
+	result, err := s.connection.Exec("INSERT INTO api_key(name, hash, scope, createdAt) VALUES(?, ?, ?, ?);",
+		apiKey.Name, hashAPIKey(apiKey.Key), apiKey.Scope, apiKey.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	apiKey.ID = int(id)
+
+	return apiKey, nil
+}
+
+// RevokeAPIKey revokes the API key with the given id so that it can no longer be used. An UnknownAPIKeyError is
+// returned if there is no unrevoked API key with the given id.
+func (s *SQLite) RevokeAPIKey(id int) error {
+	result, err := s.connection.Exec("UPDATE api_key SET revokedAt = ? WHERE id = ? AND revokedAt IS NULL;",
+		time.Now().UTC().Format(time.RFC3339), id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected != 1 {
+		return NewUnknownAPIKeyError(id)
+	}
+
+	return nil
+}
+
+// hashAPIKey returns the hex encoded SHA-256 hash of an API key, which is what is stored in the api_key table. A fast
+// hash is enough because the keys are long and random rather than chosen by people.
+func hashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/paddyquinn/messari/database/models"
+)
+
+// The audit_log table is append-only: triggers stop its rows from being updated or deleted.
+
+// AuditQuery filters the audit log. An entry must match every filter that is set. Since is an RFC 3339 UTC timestamp,
+// e.g. "2018-06-01T00:00:00Z", and only entries made at or after it match.
+type AuditQuery struct {
+	CryptoAssetID *int
+	Actor         string
+	Since         string
+}
+
+// Audit appends an entry to the audit log, setting its id and the time it was made.
+func (s *SQLite) Audit(entry *models.AuditEntry) error {
+	entry.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+
+	var payload *string
+	if len(entry.Payload) > 0 {
+		payloadString := string(entry.Payload)
+		payload = &payloadString
+	}
+
+	result, err := s.connection.Exec("INSERT INTO audit_log(createdAt, requestId, actor, clientIp, method, path, action, "+
+		"cryptoAssetId, status, payload) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?);", entry.CreatedAt, entry.RequestID,
+		entry.Actor, entry.ClientIP, entry.Method, entry.Path, entry.Action, entry.CryptoAssetID, entry.Status, payload)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	entry.ID = int(id)
+	return nil
+}
+
+// AuditLog returns the entries of the audit log that match the query, oldest first.
+func (s *SQLite) AuditLog(query *AuditQuery) ([]*models.AuditEntry, error) {
+	conditions := []string{"1 = 1"}
+	args := []interface{}{}
+	if query.CryptoAssetID != nil {
+		conditions = append(conditions, "cryptoAssetId = ?")
+		args = append(args, *query.CryptoAssetID)
+	}
+	if query.Actor != emptyString {
+		conditions = append(conditions, "actor = ?")
+		args = append(args, query.Actor)
+	}
+	if query.Since != emptyString {
+		conditions = append(conditions, "createdAt >= ?")
+		args = append(args, query.Since)
+	}
+
+	rows, err := s.connection.Query("SELECT id, createdAt, requestId, actor, clientIp, method, path, action, "+
+		"cryptoAssetId, status, payload FROM audit_log WHERE "+strings.Join(conditions, " AND ")+" ORDER BY id;", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*models.AuditEntry{}
+	for rows.Next() {
+		var (
+			entry         = &models.AuditEntry{}
+			cryptoAssetID *int
+			payload       *string
+		)
+		err = rows.Scan(&entry.ID, &entry.CreatedAt, &entry.RequestID, &entry.Actor, &entry.ClientIP, &entry.Method,
+			&entry.Path, &entry.Action, &cryptoAssetID, &entry.Status, &payload)
+		if err != nil {
+			return nil, err
+		}
+
+		if cryptoAssetID != nil {
+			id := strconv.Itoa(*cryptoAssetID)
+			entry.CryptoAssetID = &id
+		}
+		if payload != nil {
+			entry.Payload = []byte(*payload)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
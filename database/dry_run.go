@@ -0,0 +1,43 @@
+package database
+
+import (
+	"database/sql"
+
+	"github.com/paddyquinn/messari/database/models"
+)
+
+// DryRunInsert inserts the crypto asset exactly as Insert would and returns it as it would be stored, along with the
+// version it would have, without keeping it. The insert is always rolled back, and the same errors are returned as by
+// Insert, so a dry run fails whenever the real insert would, e.g. because the symbol is taken.
+func (s *SQLite) DryRunInsert(cryptoAsset *models.CryptoAsset, actor string) (*models.CryptoAsset, error) {
+	return s.dryRun(func(transaction *sql.Tx) (int, error) {
+		return insertCryptoAsset(transaction, cryptoAsset, actor, models.CreateAction)
+	})
+}
+
+// DryRunUpdate updates the crypto asset with the given id exactly as Update would and returns it as it would be after
+// the update, along with the version the update would give it, without keeping the update. The update is always rolled
+// back, and the same errors are returned as by Update, so a dry run fails whenever the real update would, e.g. because
+// the crypto asset is deleted, there is nothing to update or the symbol is taken.
+func (s *SQLite) DryRunUpdate(id int, cryptoAsset *models.CryptoAsset, actor string) (*models.CryptoAsset, error) {
+	return s.dryRun(func(transaction *sql.Tx) (int, error) {
+		return id, updateCryptoAsset(transaction, id, cryptoAsset, actor, models.UpdateAction)
+	})
+}
+
+// dryRun makes a write in a SQL transaction that is always rolled back and returns the crypto asset with the id the
+// write returns as it was before the rollback.
+func (s *SQLite) dryRun(write func(transaction *sql.Tx) (int, error)) (*models.CryptoAsset, error) {
+	transaction, err := s.connection.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer transaction.Rollback()
+
+	id, err := write(transaction)
+	if err != nil {
+		return nil, err
+	}
+
+	return getCryptoAsset(transaction, id)
+}
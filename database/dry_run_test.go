@@ -0,0 +1,90 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/paddyquinn/messari/database/models"
+)
+
+func TestSQLite_DryRunInsert(t *testing.T) {
+	db := newTestSQLite(t)
+	defer db.Close()
+
+	insertTestCryptoAsset(t, db, "ethereum", "eth", []string{"Vitalik Buterin"})
+
+	// A dry run returns the crypto asset as it would be stored, at its first version, but does not keep it.
+	name, symbol, description, website := "bitcoin", "btc", "The original cryptocurrency", "https://bitcoin.org"
+	var icoAmount float64
+	blockReward := 12.5
+	fundingStatus, foundedDate, coinType := "no-ico", "2009-01-03", "currency"
+	cryptoAsset := &models.CryptoAsset{Name: &name, Symbol: &symbol, Description: &description,
+		Team: []string{"Satoshi Nakamoto"}, ICOAmount: &icoAmount, BlockReward: &blockReward,
+		FundingStatus: &fundingStatus, FoundedDate: &foundedDate, CoinType: &coinType, Website: &website}
+	insertedCryptoAsset, err := db.DryRunInsert(cryptoAsset, "alice")
+	if err != nil {
+		t.Fatalf("unexpected error in a dry run of an insert: %s", err.Error())
+	}
+	if *insertedCryptoAsset.Symbol != "btc" || *insertedCryptoAsset.Version != 1 ||
+		len(insertedCryptoAsset.Team) != 1 || insertedCryptoAsset.Team[0] != "Satoshi Nakamoto" {
+		t.Fatalf("unexpected crypto asset: %+v", insertedCryptoAsset)
+	}
+	if _, err = db.Get(2); err == nil {
+		t.Fatal("expected the dry run of an insert to be rolled back")
+	}
+
+	// A taken symbol fails the dry run just as it fails the insert.
+	symbol = "eth"
+	if _, err = db.DryRunInsert(cryptoAsset, "alice"); err == nil {
+		t.Fatal("expected a unique constraint error")
+	} else if _, ok := err.(*UniqueConstraintError); !ok {
+		t.Fatalf("expected a unique constraint error, got %v", err)
+	}
+}
+
+func TestSQLite_DryRunUpdate(t *testing.T) {
+	db := newTestSQLite(t)
+	defer db.Close()
+
+	insertTestCryptoAsset(t, db, "ethereum", "eth", []string{"Vitalik Buterin"})
+	insertTestCryptoAsset(t, db, "bitcoin", "btc", []string{"Satoshi Nakamoto"})
+
+	// A dry run returns the crypto asset as it would be after the update, at the version the update would give it, but
+	// does not keep the update.
+	name := "ether"
+	updatedCryptoAsset, err := db.DryRunUpdate(1, &models.CryptoAsset{Name: &name}, "alice")
+	if err != nil {
+		t.Fatalf("unexpected error in a dry run of an update: %s", err.Error())
+	}
+	if *updatedCryptoAsset.Name != "ether" || *updatedCryptoAsset.Version != 2 {
+		t.Fatalf("unexpected crypto asset: %+v", updatedCryptoAsset)
+	}
+	cryptoAsset, err := db.Get(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *cryptoAsset.Name != "ethereum" || *cryptoAsset.Version != 1 {
+		t.Fatalf("expected the dry run of an update to be rolled back, got %+v", cryptoAsset)
+	}
+
+	// A dry run fails whenever the update would: with nothing to update, with a taken symbol or on a deleted crypto
+	// asset.
+	if _, err = db.DryRunUpdate(1, &models.CryptoAsset{}, "alice"); err == nil {
+		t.Fatal("expected an empty update error")
+	} else if _, ok := err.(*EmptyUpdateError); !ok {
+		t.Fatalf("expected an empty update error, got %v", err)
+	}
+	symbol := "btc"
+	if _, err = db.DryRunUpdate(1, &models.CryptoAsset{Symbol: &symbol}, "alice"); err == nil {
+		t.Fatal("expected a unique constraint error")
+	} else if _, ok := err.(*UniqueConstraintError); !ok {
+		t.Fatalf("expected a unique constraint error, got %v", err)
+	}
+	if err = db.Delete(2, nil, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = db.DryRunUpdate(2, &models.CryptoAsset{Name: &name}, "alice"); err == nil {
+		t.Fatal("expected an unknown id error")
+	} else if _, ok := err.(*UnknownIDError); !ok {
+		t.Fatalf("expected an unknown id error, got %v", err)
+	}
+}
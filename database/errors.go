@@ -2,6 +2,25 @@ package database
 
 import "fmt"
 
+// DuplicateVocabularyValueError represents an error when a value is added to a controlled vocabulary, or a value is
+// renamed, that is already a value or alias of the vocabulary.
+type DuplicateVocabularyValueError struct {
+	vocabulary string
+	value      string
+}
+
+// NewDuplicateVocabularyValueError creates a new duplicate vocabulary value error with the name of the vocabulary and
+// the duplicate value.
+func NewDuplicateVocabularyValueError(vocabulary, value string) *DuplicateVocabularyValueError {
+	return &DuplicateVocabularyValueError{vocabulary: vocabulary, value: value}
+}
+
+// Error makes DuplicateVocabularyValueError adhere to the error interface. The vocabulary and the duplicate value are
+// returned in the string.
+func (d *DuplicateVocabularyValueError) Error() string {
+	return fmt.Sprintf("%s %s already exists", d.vocabulary, d.value)
+}
+
 // EmptyUpdateError represents an error where an update is attempted on the database with no new data.
 type EmptyUpdateError struct{}
 
@@ -15,6 +34,96 @@ func (e *EmptyUpdateError) Error() string {
 	return "nothing to update"
 }
 
+// InvalidAPIKeyError represents an error when a client authenticates with an API key that was never issued or has been
+// revoked.
+type InvalidAPIKeyError struct{}
+
+// NewInvalidAPIKeyError creates a new invalid API key error.
+func NewInvalidAPIKeyError() *InvalidAPIKeyError {
+	return &InvalidAPIKeyError{}
+}
+
+// Error makes InvalidAPIKeyError adhere to the error interface.
+func (i *InvalidAPIKeyError) Error() string {
+	return "invalid API key"
+}
+
+// InvalidCursorError represents an error when a search is attempted with a cursor that was not created by a previous
+// search with the same sort.
+type InvalidCursorError struct{}
+
+// NewInvalidCursorError creates a new invalid cursor error.
+func NewInvalidCursorError() *InvalidCursorError {
+	return &InvalidCursorError{}
+}
+
+// Error makes InvalidCursorError adhere to the error interface.
+func (i *InvalidCursorError) Error() string {
+	return "invalid cursor"
+}
+
+// InvalidLimitError represents an error when a search is attempted with a page size that is negative or too large.
+type InvalidLimitError struct {
+	limit int
+}
+
+// NewInvalidLimitError creates a new invalid limit error with the offending limit.
+func NewInvalidLimitError(limit int) *InvalidLimitError {
+	return &InvalidLimitError{limit: limit}
+}
+
+// Error makes InvalidLimitError adhere to the error interface. The offending limit is returned in the string.
+func (i *InvalidLimitError) Error() string {
+	return fmt.Sprintf("limit %d must be between 0 and %d", i.limit, MaxLimit)
+}
+
+// InvalidScopeError represents an error when an API key is issued with a scope that does not exist.
+type InvalidScopeError struct {
+	scope string
+}
+
+// NewInvalidScopeError creates a new invalid scope error with the unknown scope.
+func NewInvalidScopeError(scope string) *InvalidScopeError {
+	return &InvalidScopeError{scope: scope}
+}
+
+// Error makes InvalidScopeError adhere to the error interface. The unknown scope is returned in the string.
+func (i *InvalidScopeError) Error() string {
+	return fmt.Sprintf("invalid scope %s: must be read, propose, write, approve or admin", i.scope)
+}
+
+// MigrationError represents an error when a migration fails to apply to the database schema.
+type MigrationError struct {
+	version     int
+	description string
+	err         error
+}
+
+// NewMigrationError creates a new migration error with the version and description of the failed migration and the
+// error it failed with.
+func NewMigrationError(version int, description string, err error) *MigrationError {
+	return &MigrationError{version: version, description: description, err: err}
+}
+
+// Error makes MigrationError adhere to the error interface. The failed migration and its error are returned in the
+// string.
+func (m *MigrationError) Error() string {
+	return fmt.Sprintf("migration %d (%s) failed: %s", m.version, m.description, m.err.Error())
+}
+
+// MissingFTS5Error represents an error when the SQLite driver was built without FTS5, which the full-text index needs.
+type MissingFTS5Error struct{}
+
+// NewMissingFTS5Error creates a new missing FTS5 error.
+func NewMissingFTS5Error() *MissingFTS5Error {
+	return &MissingFTS5Error{}
+}
+
+// Error makes MissingFTS5Error adhere to the error interface. The build tag that adds FTS5 is returned in the string.
+func (m *MissingFTS5Error) Error() string {
+	return "SQLite was built without FTS5: build with -tags fts5"
+}
+
 // NullConstraintError represents an error when an insert or update to the database is attempted with a null field that
 // has a non-null constraint.
 type NullConstraintError struct {
@@ -31,6 +140,48 @@ func (n *NullConstraintError) Error() string {
 	return fmt.Sprintf("%s cannot be null", n.field)
 }
 
+// Field returns the null column.
+func (n *NullConstraintError) Field() string {
+	return n.field
+}
+
+// ProposalReviewedError represents an error when a proposal that has already been approved or rejected is reviewed
+// again.
+type ProposalReviewedError struct {
+	id     int
+	status string
+}
+
+// NewProposalReviewedError creates a new proposal reviewed error with the id of the proposal and its status.
+func NewProposalReviewedError(id int, status string) *ProposalReviewedError {
+	return &ProposalReviewedError{id: id, status: status}
+}
+
+// Error makes ProposalReviewedError adhere to the error interface. The id and status of the proposal are returned in
+// the string.
+func (p *ProposalReviewedError) Error() string {
+	return fmt.Sprintf("proposal %d has already been %s", p.id, p.status)
+}
+
+// SchemaVersionError represents an error when the database schema is newer than the latest migration this binary
+// knows about.
+type SchemaVersionError struct {
+	version int
+	latest  int
+}
+
+// NewSchemaVersionError creates a new schema version error with the version of the database schema and the latest
+// version this binary knows about.
+func NewSchemaVersionError(version, latest int) *SchemaVersionError {
+	return &SchemaVersionError{version: version, latest: latest}
+}
+
+// Error makes SchemaVersionError adhere to the error interface. Both versions are returned in the string.
+func (s *SchemaVersionError) Error() string {
+	return fmt.Sprintf("database schema version %d is newer than the latest version %d supported by this binary",
+		s.version, s.latest)
+}
+
 // UniqueConstraintError represents an error when an insert or update to the database is attempted that breaks the
 // symbol field's unique constraint.
 type UniqueConstraintError struct {
@@ -47,6 +198,52 @@ func (u *UniqueConstraintError) Error() string {
 	return fmt.Sprintf("symbol %s already exists", u.symbol)
 }
 
+// UnknownAPIKeyError represents an error when an API key with an id that can not be found in the database is revoked.
+type UnknownAPIKeyError struct {
+	id int
+}
+
+// NewUnknownAPIKeyError creates a new unknown API key error with the unknown id.
+func NewUnknownAPIKeyError(id int) *UnknownAPIKeyError {
+	return &UnknownAPIKeyError{id: id}
+}
+
+// Error makes UnknownAPIKeyError adhere to the error interface. The unknown id is returned in the string.
+func (u *UnknownAPIKeyError) Error() string {
+	return fmt.Sprintf("API key with id %d not found", u.id)
+}
+
+// UnknownFieldError represents an error when a search is attempted that asks for a field crypto assets do not have.
+type UnknownFieldError struct {
+	field string
+}
+
+// NewUnknownFieldError creates a new unknown field error with the offending field.
+func NewUnknownFieldError(field string) *UnknownFieldError {
+	return &UnknownFieldError{field: field}
+}
+
+// Error makes UnknownFieldError adhere to the error interface. The offending field is returned in the string.
+func (u *UnknownFieldError) Error() string {
+	return fmt.Sprintf("unknown field %s", u.field)
+}
+
+// UnknownSortFieldError represents an error when a search is attempted with a sort on a field that search results
+// cannot be sorted by.
+type UnknownSortFieldError struct {
+	field string
+}
+
+// NewUnknownSortFieldError creates a new unknown sort field error with the offending field.
+func NewUnknownSortFieldError(field string) *UnknownSortFieldError {
+	return &UnknownSortFieldError{field: field}
+}
+
+// Error makes UnknownSortFieldError adhere to the error interface. The offending field is returned in the string.
+func (u *UnknownSortFieldError) Error() string {
+	return fmt.Sprintf("cannot sort by %s", u.field)
+}
+
 // UnknownIDError represents an error when an update is attempted on a crypto asset with an id that can not be found in
 // the database or when the foreign key constraint is broken when trying to insert into the team_member table.
 type UnknownIDError struct {
@@ -62,3 +259,141 @@ func NewUnknownIDError(id int) *UnknownIDError {
 func (u *UnknownIDError) Error() string {
 	return fmt.Sprintf("crypto asset with id %d not found", u.id)
 }
+
+// UnknownMembershipError represents an error when the role or tenure of a person is changed on the team of a crypto
+// asset that they are not on.
+type UnknownMembershipError struct {
+	personID      int
+	cryptoAssetID int
+}
+
+// NewUnknownMembershipError creates a new unknown membership error with the id of the person and the id of the crypto
+// asset.
+func NewUnknownMembershipError(personID, cryptoAssetID int) *UnknownMembershipError {
+	return &UnknownMembershipError{personID: personID, cryptoAssetID: cryptoAssetID}
+}
+
+// Error makes UnknownMembershipError adhere to the error interface. Both ids are returned in the string.
+func (u *UnknownMembershipError) Error() string {
+	return fmt.Sprintf("person with id %d is not on the team of crypto asset with id %d", u.personID, u.cryptoAssetID)
+}
+
+// UnknownPersonError represents an error when a person with an id that can not be found in the database is looked up
+// or updated.
+type UnknownPersonError struct {
+	id int
+}
+
+// NewUnknownPersonError creates a new unknown person error with the unknown id.
+func NewUnknownPersonError(id int) *UnknownPersonError {
+	return &UnknownPersonError{id: id}
+}
+
+// Error makes UnknownPersonError adhere to the error interface. The unknown id is returned in the string.
+func (u *UnknownPersonError) Error() string {
+	return fmt.Sprintf("person with id %d not found", u.id)
+}
+
+// UnknownProposalError represents an error when a proposal with an id that can not be found in the database is looked
+// up or reviewed.
+type UnknownProposalError struct {
+	id int
+}
+
+// NewUnknownProposalError creates a new unknown proposal error with the unknown id.
+func NewUnknownProposalError(id int) *UnknownProposalError {
+	return &UnknownProposalError{id: id}
+}
+
+// Error makes UnknownProposalError adhere to the error interface. The unknown id is returned in the string.
+func (u *UnknownProposalError) Error() string {
+	return fmt.Sprintf("proposal with id %d not found", u.id)
+}
+
+// UnknownRevisionError represents an error when a crypto asset has no revision with the given number.
+type UnknownRevisionError struct {
+	id       int
+	revision int
+}
+
+// NewUnknownRevisionError creates a new unknown revision error with the id of the crypto asset and the unknown
+// revision number.
+func NewUnknownRevisionError(id, revision int) *UnknownRevisionError {
+	return &UnknownRevisionError{id: id, revision: revision}
+}
+
+// Error makes UnknownRevisionError adhere to the error interface. The crypto asset id and the unknown revision number
+// are returned in the string.
+func (u *UnknownRevisionError) Error() string {
+	return fmt.Sprintf("revision %d of crypto asset with id %d not found", u.revision, u.id)
+}
+
+// UnknownVocabularyError represents an error when a controlled vocabulary that does not exist is used.
+type UnknownVocabularyError struct {
+	vocabulary string
+}
+
+// NewUnknownVocabularyError creates a new unknown vocabulary error with the unknown vocabulary.
+func NewUnknownVocabularyError(vocabulary string) *UnknownVocabularyError {
+	return &UnknownVocabularyError{vocabulary: vocabulary}
+}
+
+// Error makes UnknownVocabularyError adhere to the error interface. The unknown vocabulary is returned in the string.
+func (u *UnknownVocabularyError) Error() string {
+	return fmt.Sprintf("unknown vocabulary %s", u.vocabulary)
+}
+
+// UnknownVocabularyValueError represents an error when a value that a controlled vocabulary does not have is renamed or
+// retired.
+type UnknownVocabularyValueError struct {
+	vocabulary string
+	value      string
+}
+
+// NewUnknownVocabularyValueError creates a new unknown vocabulary value error with the name of the vocabulary and the
+// unknown value.
+func NewUnknownVocabularyValueError(vocabulary, value string) *UnknownVocabularyValueError {
+	return &UnknownVocabularyValueError{vocabulary: vocabulary, value: value}
+}
+
+// Error makes UnknownVocabularyValueError adhere to the error interface. The vocabulary and the unknown value are
+// returned in the string.
+func (u *UnknownVocabularyValueError) Error() string {
+	return fmt.Sprintf("%s %s not found", u.vocabulary, u.value)
+}
+
+// UnsupportedQueryError represents an error when a search is attempted that combines options that cannot be used
+// together.
+type UnsupportedQueryError struct {
+	reason string
+}
+
+// NewUnsupportedQueryError creates a new unsupported query error with the reason the query is unsupported.
+func NewUnsupportedQueryError(reason string) *UnsupportedQueryError {
+	return &UnsupportedQueryError{reason: reason}
+}
+
+// Error makes UnsupportedQueryError adhere to the error interface. The reason is returned in the string.
+func (u *UnsupportedQueryError) Error() string {
+	return u.reason
+}
+
+// VersionMismatchError represents an error when a write expects a crypto asset to be at a version it is no longer at,
+// which means someone else has changed it since the writer read it.
+type VersionMismatchError struct {
+	id       int
+	expected int
+	actual   int
+}
+
+// NewVersionMismatchError creates a new version mismatch error with the id of the crypto asset, the version the write
+// expected and the version the crypto asset is actually at.
+func NewVersionMismatchError(id, expected, actual int) *VersionMismatchError {
+	return &VersionMismatchError{id: id, expected: expected, actual: actual}
+}
+
+// Error makes VersionMismatchError adhere to the error interface. The crypto asset id and both versions are returned in
+// the string.
+func (v *VersionMismatchError) Error() string {
+	return fmt.Sprintf("crypto asset with id %d is at version %d, not %d", v.id, v.actual, v.expected)
+}
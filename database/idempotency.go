@@ -0,0 +1,80 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/paddyquinn/messari/database/models"
+)
+
+// ReserveIdempotencyKey stores an idempotency key, which has not yet got a response, for the request it is being used
+// for. Expired keys are deleted first. If the actor has already used the key and it has not expired, nothing is
+// stored and the key as it was stored before is returned instead. Otherwise, nil is returned.
+func (s *SQLite) ReserveIdempotencyKey(key *models.IdempotencyKey) (*models.IdempotencyKey, error) {
+	_, err := s.connection.Exec("DELETE FROM idempotency_key WHERE expiresAt <= ?;",
+		time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return nil, err
+	}
+
+	// Only one of any number of concurrent requests with the same key can insert it.
+	result, err := s.connection.Exec("INSERT OR IGNORE INTO idempotency_key(idempotencyKey, actor, requestHash, status, "+
+		"createdAt, expiresAt) VALUES(?, ?, ?, 0, ?, ?);", key.Key, key.Actor, key.RequestHash, key.CreatedAt,
+		key.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 1 {
+		return nil, nil
+	}
+
+	// The key has already been used, so return it as it was stored.
+	var (
+		storedKey  = &models.IdempotencyKey{}
+		headerJSON *string
+	)
+	err = s.connection.QueryRow("SELECT idempotencyKey, actor, requestHash, status, header, body, createdAt, expiresAt "+
+		"FROM idempotency_key WHERE idempotencyKey = ? AND actor = ?;", key.Key, key.Actor).Scan(&storedKey.Key,
+		&storedKey.Actor, &storedKey.RequestHash, &storedKey.Status, &headerJSON, &storedKey.Body, &storedKey.CreatedAt,
+		&storedKey.ExpiresAt)
+	if err == sql.ErrNoRows {
+		// The key expired between inserting and selecting it, so try again.
+		return s.ReserveIdempotencyKey(key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if headerJSON != nil {
+		if err = json.Unmarshal([]byte(*headerJSON), &storedKey.Header); err != nil {
+			return nil, err
+		}
+	}
+
+	return storedKey, nil
+}
+
+// ReleaseIdempotencyKey deletes an idempotency key the actor has reserved so that the request it was used for can be
+// retried, for example because the request failed with an internal error.
+func (s *SQLite) ReleaseIdempotencyKey(key, actor string) error {
+	_, err := s.connection.Exec("DELETE FROM idempotency_key WHERE idempotencyKey = ? AND actor = ? AND status = 0;",
+		key, actor)
+	return err
+}
+
+// SaveIdempotentResponse stores the response to the request an idempotency key was reserved for, so that it can be
+// replayed to retries of the request until the key expires.
+func (s *SQLite) SaveIdempotentResponse(key *models.IdempotencyKey) error {
+	headerJSON, err := json.Marshal(key.Header)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.connection.Exec("UPDATE idempotency_key SET status = ?, header = ?, body = ? WHERE idempotencyKey = ? "+
+		"AND actor = ?;", key.Status, string(headerJSON), key.Body, key.Key, key.Actor)
+	return err
+}
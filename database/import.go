@@ -0,0 +1,155 @@
+package database
+
+import (
+	"database/sql"
+	"strconv"
+
+	"github.com/paddyquinn/messari/database/models"
+	"github.com/paddyquinn/messari/util"
+)
+
+// Import registers the crypto asset of every row, normalizing and validating it as if it were registered on its own,
+// and records the actor as having imported it in its history. A row that already failed, because it could not be
+// parsed, is only validated. If upsert is true, a row with the symbol of a live crypto asset replaces that crypto asset
+// instead. The status of each row is set to what became of it, and a row that could not be imported fails with the
+// reasons why. If atomic is true and any row fails, nothing is imported and every other row is skipped. Otherwise the
+// rows that did not fail are imported regardless. An error is only returned if the import could not be made at all.
+func (s *SQLite) Import(rows []*models.ImportRow, upsert, atomic bool, actor string) error {
+	// Begin a SQL transaction so that an all-or-nothing import can be undone and so that every row is imported against
+	// the same vocabularies.
+	transaction, err := s.connection.Begin()
+	if err != nil {
+		return err
+	}
+
+	vocabularies, err := selectVocabularies(transaction, true)
+	if err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	failed := false
+	for _, row := range rows {
+		if err = importRow(transaction, row, vocabularies, upsert, actor); err != nil {
+			transaction.Rollback()
+			return err
+		}
+		failed = failed || row.Status == models.FailedStatus
+	}
+
+	// Nothing is imported by an all-or-nothing import with a failed row.
+	if atomic && failed {
+		transaction.Rollback()
+		for _, row := range rows {
+			if row.Status != models.FailedStatus {
+				row.Status, row.ID = models.SkippedStatus, nil
+			}
+		}
+		return nil
+	}
+
+	// Commit the transaction and return.
+	return transaction.Commit()
+}
+
+// importRow normalizes and imports the crypto asset of a row as described by Import as part of a SQL transaction. A row
+// that already failed is only validated, so that every reason it failed is reported. Each row is imported within a
+// savepoint so that a row that fails part way through leaves nothing behind.
+func importRow(transaction *sql.Tx, row *models.ImportRow, vocabularies models.Vocabularies, upsert bool,
+	actor string) error {
+	// Ids are assigned by the database, so any id in the row is ignored.
+	cryptoAsset := row.CryptoAsset
+	cryptoAsset.ID = nil
+
+	// A row that replaces a crypto asset is a write over it, so the values it leaves as they are are not held to format
+	// rules added since they were stored.
+	var stored *models.CryptoAsset
+	if upsert && cryptoAsset.Symbol != nil {
+		var err error
+		if stored, err = selectLiveCryptoAsset(transaction, *util.Normalize(*cryptoAsset.Symbol)); err != nil {
+			return err
+		}
+	}
+	_, err := cryptoAsset.NormalizeChanges(vocabularies, true, stored)
+	if cryptoAsset.Symbol != nil && *cryptoAsset.Symbol != emptyString {
+		row.Symbol = cryptoAsset.Symbol
+	}
+	if err != nil {
+		validationErr, ok := err.(*models.ValidationError)
+		if !ok {
+			return err
+		}
+		row.Fail(validationErr.Violations()...)
+	}
+	if row.Status == models.FailedStatus {
+		return nil
+	}
+
+	if _, err = transaction.Exec("SAVEPOINT import_row;"); err != nil {
+		return err
+	}
+
+	id, status, err := upsertCryptoAsset(transaction, cryptoAsset, stored, actor)
+	if err != nil {
+		if _, rollbackErr := transaction.Exec("ROLLBACK TO import_row;"); rollbackErr != nil {
+			return rollbackErr
+		}
+
+		// Only errors in the row itself fail the row. Any other error fails the import.
+		switch e := err.(type) {
+		case *NullConstraintError:
+			row.Fail(&models.Violation{Field: e.Field(), Code: models.RequiredViolation, Detail: e.Error()})
+		case *UniqueConstraintError:
+			row.Fail(&models.Violation{Field: "symbol", Code: models.UniqueViolation, Detail: e.Error()})
+		default:
+			return err
+		}
+	} else {
+		idString := strconv.Itoa(id)
+		row.Status, row.ID = status, &idString
+	}
+
+	_, err = transaction.Exec("RELEASE import_row;")
+	return err
+}
+
+// selectLiveCryptoAsset retrieves the live crypto asset with the given symbol as part of a SQL transaction. It returns
+// nil if there is none.
+func selectLiveCryptoAsset(transaction *sql.Tx, symbol string) (*models.CryptoAsset, error) {
+	var id int
+	err := transaction.QueryRow("SELECT id FROM crypto_asset WHERE symbol = ? AND deletedAt IS NULL;", symbol).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return getCryptoAsset(transaction, id)
+}
+
+// upsertCryptoAsset inserts the crypto asset as part of a SQL transaction unless the live crypto asset with its symbol
+// is given, in which case that crypto asset is replaced. The id of the crypto asset is returned along with whether it
+// was created, updated or left unchanged.
+func upsertCryptoAsset(transaction *sql.Tx, cryptoAsset, stored *models.CryptoAsset, actor string) (int, string,
+	error) {
+	if stored != nil {
+		id, _ := strconv.Atoi(*stored.ID)
+		if err := updateCryptoAsset(transaction, id, cryptoAsset, actor, models.ImportAction); err != nil {
+			return -1, emptyString, err
+		}
+
+		// A revision, and so a new version, is only recorded if the crypto asset changed.
+		var version int
+		err := transaction.QueryRow("SELECT version FROM crypto_asset WHERE id = ?;", id).Scan(&version)
+		if err != nil {
+			return -1, emptyString, err
+		}
+		if version == *stored.Version {
+			return id, models.UnchangedStatus, nil
+		}
+		return id, models.UpdatedStatus, nil
+	}
+
+	id, err := insertCryptoAsset(transaction, cryptoAsset, actor, models.ImportAction)
+	return id, models.CreatedStatus, err
+}
@@ -0,0 +1,113 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/paddyquinn/messari/database/models"
+)
+
+func TestSQLite_Import(t *testing.T) {
+	db := newTestSQLite(t)
+	defer db.Close()
+
+	// An atomic import with a failed row imports nothing and skips every other row.
+	rows := newTestImportRows("btc", "")
+	if err := db.Import(rows, false, true, "alice"); err != nil {
+		t.Fatalf("unexpected error importing: %s", err.Error())
+	}
+	assertImportStatuses(t, rows, models.SkippedStatus, models.FailedStatus)
+	if rows[0].ID != nil {
+		t.Fatal("expected a skipped row to have no id")
+	}
+	if _, err := db.Get(1); err == nil {
+		t.Fatal("expected an atomic import with a failed row to import nothing")
+	}
+
+	// A best effort import imports every row that does not fail, normalizing it on the way in.
+	rows = newTestImportRows("btc", "eth", "")
+	rows[1].CryptoAsset.CoinType = stringPointer("coin")
+	if err := db.Import(rows, false, false, "alice"); err != nil {
+		t.Fatalf("unexpected error importing: %s", err.Error())
+	}
+	assertImportStatuses(t, rows, models.CreatedStatus, models.CreatedStatus, models.FailedStatus)
+	cryptoAsset, err := db.Get(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *cryptoAsset.CoinType != "currency" {
+		t.Fatalf("expected the coin type of an imported crypto asset to be normalized, got %s", *cryptoAsset.CoinType)
+	}
+
+	// Importing a symbol that is already taken fails the row unless the import upserts, in which case the crypto asset
+	// is only updated if it changed.
+	rows = newTestImportRows("btc")
+	if err = db.Import(rows, false, false, "bob"); err != nil {
+		t.Fatalf("unexpected error importing: %s", err.Error())
+	}
+	assertImportStatuses(t, rows, models.FailedStatus)
+	if rows[0].Errors[0].Code != models.UniqueViolation {
+		t.Fatalf("expected a taken symbol to fail the row, got %+v", rows[0].Errors[0])
+	}
+
+	rows = newTestImportRows("btc", "eth")
+	rows[1].CryptoAsset.Website = stringPointer("https://ethereum.org")
+	if err = db.Import(rows, true, true, "bob"); err != nil {
+		t.Fatalf("unexpected error importing: %s", err.Error())
+	}
+	assertImportStatuses(t, rows, models.UnchangedStatus, models.UpdatedStatus)
+	if *rows[1].ID != "2" {
+		t.Fatalf("expected the crypto asset with the symbol to be updated, got %s", *rows[1].ID)
+	}
+
+	history, err := db.History(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[1].Action != models.ImportAction || history[1].Actor != "bob" {
+		t.Fatalf("expected the update to be recorded as an import by bob, got %d revisions", len(history))
+	}
+
+	// A row that replaces a crypto asset stored before websites had to be URLs may leave its website as it is while it
+	// changes the rest, but may not change it to another that is not a URL.
+	if _, err = db.connection.Exec("UPDATE crypto_asset SET website = 'example.com' WHERE id = 1;"); err != nil {
+		t.Fatal(err)
+	}
+	rows = newTestImportRows("btc", "eth")
+	rows[0].CryptoAsset.Website = stringPointer("example.com")
+	rows[0].CryptoAsset.Description = stringPointer("The original cryptocurrency")
+	rows[1].CryptoAsset.Website = stringPointer("ethereum.org")
+	if err = db.Import(rows, true, false, "bob"); err != nil {
+		t.Fatalf("unexpected error importing: %s", err.Error())
+	}
+	assertImportStatuses(t, rows, models.UpdatedStatus, models.FailedStatus)
+	if rows[1].Errors[0].Field != "website" || rows[1].Errors[0].Code != models.FormatViolation {
+		t.Fatalf("expected a changed website to fail the row, got %+v", rows[1].Errors[0])
+	}
+}
+
+// newTestImportRows creates an import row with a valid crypto asset for each of the given symbols.
+func newTestImportRows(symbols ...string) []*models.ImportRow {
+	rows := make([]*models.ImportRow, len(symbols))
+	for idx := range symbols {
+		name, description, website := symbols[idx]+" coin", "A test coin", "https://example.com"
+		var icoAmount, blockReward float64
+		fundingStatus, foundedDate, coinType := "no-ico", "2009-01-03", "token"
+		rows[idx] = &models.ImportRow{Row: idx + 1, CryptoAsset: &models.CryptoAsset{Name: &name, Symbol: &symbols[idx],
+			Description: &description, Team: []string{}, ICOAmount: &icoAmount, BlockReward: &blockReward,
+			FundingStatus: &fundingStatus, FoundedDate: &foundedDate, CoinType: &coinType, Website: &website}}
+	}
+	return rows
+}
+
+// assertImportStatuses fails the test unless the rows have the expected statuses.
+func assertImportStatuses(t *testing.T, rows []*models.ImportRow, expectedStatuses ...string) {
+	if len(rows) != len(expectedStatuses) {
+		t.Fatalf("expected %d rows, got %d", len(expectedStatuses), len(rows))
+	}
+	for idx, expectedStatus := range expectedStatuses {
+		if rows[idx].Status != expectedStatus {
+			t.Fatalf("expected row %d to be %s, got %s: %+v", rows[idx].Row, expectedStatus, rows[idx].Status,
+				rows[idx].Errors)
+		}
+	}
+}
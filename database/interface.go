@@ -4,8 +4,44 @@ import "github.com/paddyquinn/messari/database/models"
 
 // Interface represents an interface any database driver or mock need adhere to.
 type Interface interface {
-	Insert(cryptoAsset *models.CryptoAsset) (string, error)
-	Select(names, symbols, fundingStatuses, coinTypes []string, startDate, endDate string) ([]*models.CryptoAsset, error)
-	Update(id int, cryptoAsset *models.CryptoAsset) error
+	AddVocabularyValue(vocabulary string, value *models.VocabularyValue) error
+	APIKeys() ([]*models.APIKey, error)
+	ApproveProposal(id int, actor, comment string) error
+	Audit(entry *models.AuditEntry) error
+	AuditLog(query *AuditQuery) ([]*models.AuditEntry, error)
+	Authenticate(key string) (*models.APIKey, error)
+	Autocomplete(prefix string, limit int) ([]*models.Suggestion, error)
+	CommentOnProposal(id int, actor, comment string) error
+	CreateAPIKey(name, scope string) (*models.APIKey, error)
+	Delete(id int, version *int, actor string) error
+	DryRunInsert(cryptoAsset *models.CryptoAsset, actor string) (*models.CryptoAsset, error)
+	DryRunUpdate(id int, cryptoAsset *models.CryptoAsset, actor string) (*models.CryptoAsset, error)
+	Export(query *Query, each func(cryptoAsset *models.CryptoAsset) error) error
+	Get(id int) (*models.CryptoAsset, error)
+	History(id int) ([]*models.Revision, error)
+	Import(rows []*models.ImportRow, upsert, atomic bool, actor string) error
+	People(name string) ([]*models.Person, error)
+	Person(id int) (*models.Person, error)
+	PersonAssets(id int) ([]*models.Membership, error)
+	Insert(cryptoAsset *models.CryptoAsset, actor string) (string, error)
+	Proposal(id int) (*models.Proposal, error)
+	Proposals(status string) ([]*models.Proposal, error)
+	Propose(id int, cryptoAsset *models.CryptoAsset, actor string) (int, error)
+	RejectProposal(id int, actor, comment string) error
+	ReleaseIdempotencyKey(key, actor string) error
+	ReserveIdempotencyKey(key *models.IdempotencyKey) (*models.IdempotencyKey, error)
+	RenameVocabularyValue(vocabulary, value, newValue, actor string) error
+	Restore(id int, version *int, actor string) error
+	RevokeAPIKey(id int) error
+	RetireVocabularyValue(vocabulary, value string) error
+	Revert(id int, cryptoAsset *models.CryptoAsset, actor string) error
+	Revision(id, revision int) (*models.Revision, error)
+	SaveIdempotentResponse(key *models.IdempotencyKey) error
+	SchemaVersion() (int, error)
+	Select(query *Query) ([]*models.CryptoAsset, string, error)
+	Update(id int, cryptoAsset *models.CryptoAsset, actor string) error
+	UpdateMembership(personID, cryptoAssetID int, membership *models.Membership, actor string) error
+	UpdatePerson(id int, person *models.Person, actor string) error
+	Vocabularies(includeRetired bool) (models.Vocabularies, error)
 	Close()
 }
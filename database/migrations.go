@@ -0,0 +1,313 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// migration is a single change to the database schema. Its version is its position in the migrations list, starting at
+// 1, and its statements are executed in order inside a single SQL transaction. Foreign keys are not enforced while the
+// statements run so that a table can be rebuilt by copying it into a new table, dropping it and renaming the new table.
+// They are checked before the transaction is committed instead.
+type migration struct {
+	description string
+	statements  []string
+}
+
+// migrations lists every change ever made to the database schema in the order they are applied. Migrations that have
+// been released must never be edited or reordered; change the schema by appending a new migration instead. The early
+// migrations tolerate their changes already having been made because databases created before migrations were
+// introduced have no record of which of them were applied.
+var migrations = []migration{
+	{
+		description: "create the crypto_asset and team_member tables",
+		statements: []string{
+			"CREATE TABLE IF NOT EXISTS crypto_asset(id INTEGER PRIMARY KEY, name TEXT NOT NULL, " +
+				"symbol TEXT UNIQUE NOT NULL, description TEXT NOT NULL, icoAmount REAL NOT NULL, " +
+				"blockReward REAL NOT NULL, fundingStatus TEXT NOT NULL, foundedDate TEXT NOT NULL, " +
+				"coinType TEXT NOT NULL, website TEXT NOT NULL);",
+			"CREATE TABLE IF NOT EXISTS team_member(cryptoAssetId INTEGER NOT NULL, name TEXT NOT NULL, " +
+				"FOREIGN KEY(cryptoAssetId) REFERENCES crypto_asset(id));",
+		},
+	},
+	{
+		description: "index crypto asset names for autocomplete",
+		statements: []string{
+			"CREATE INDEX IF NOT EXISTS crypto_asset_name ON crypto_asset(name);",
+		},
+	},
+	{
+		description: "create the crypto_asset_search full-text index",
+		statements: []string{
+			"CREATE VIRTUAL TABLE IF NOT EXISTS crypto_asset_search USING fts5(name, symbol, description, team);",
+			"DELETE FROM crypto_asset_search;",
+			"INSERT INTO crypto_asset_search(rowid, name, symbol, description, team) SELECT id, name, symbol, " +
+				"description, (SELECT group_concat(name, ', ') FROM team_member WHERE cryptoAssetId = ca.id) " +
+				"FROM crypto_asset ca;",
+		},
+	},
+	{
+		description: "add soft delete tombstones and only require live crypto assets to have unique symbols",
+		statements: []string{
+			"CREATE TABLE crypto_asset_new(id INTEGER PRIMARY KEY, name TEXT NOT NULL, symbol TEXT NOT NULL, " +
+				"description TEXT NOT NULL, icoAmount REAL NOT NULL, blockReward REAL NOT NULL, " +
+				"fundingStatus TEXT NOT NULL, foundedDate TEXT NOT NULL, coinType TEXT NOT NULL, website TEXT NOT NULL, " +
+				"deletedAt TEXT, deletedBy TEXT);",
+			"INSERT INTO crypto_asset_new(id, name, symbol, description, icoAmount, blockReward, fundingStatus, " +
+				"foundedDate, coinType, website) SELECT id, name, symbol, description, icoAmount, blockReward, " +
+				"fundingStatus, foundedDate, coinType, website FROM crypto_asset;",
+			"DROP TABLE crypto_asset;",
+			"ALTER TABLE crypto_asset_new RENAME TO crypto_asset;",
+			"CREATE INDEX crypto_asset_name ON crypto_asset(name);",
+			"CREATE UNIQUE INDEX crypto_asset_symbol ON crypto_asset(symbol) WHERE deletedAt IS NULL;",
+		},
+	},
+	{
+		description: "record the revision history of every crypto asset, starting from its current state",
+		statements: []string{
+			"CREATE TABLE crypto_asset_revision(id INTEGER PRIMARY KEY, cryptoAssetId INTEGER NOT NULL, " +
+				"revision INTEGER NOT NULL, createdAt TEXT NOT NULL, actor TEXT NOT NULL, action TEXT NOT NULL, " +
+				"diff TEXT NOT NULL, name TEXT NOT NULL, symbol TEXT NOT NULL, description TEXT NOT NULL, " +
+				"icoAmount REAL NOT NULL, blockReward REAL NOT NULL, fundingStatus TEXT NOT NULL, " +
+				"foundedDate TEXT NOT NULL, coinType TEXT NOT NULL, website TEXT NOT NULL, deletedAt TEXT, " +
+				"deletedBy TEXT, UNIQUE(cryptoAssetId, revision), FOREIGN KEY(cryptoAssetId) REFERENCES crypto_asset(id));",
+			"CREATE INDEX crypto_asset_revision_createdAt ON crypto_asset_revision(cryptoAssetId, createdAt);",
+			"CREATE TABLE team_member_revision(revisionId INTEGER NOT NULL, name TEXT NOT NULL, " +
+				"FOREIGN KEY(revisionId) REFERENCES crypto_asset_revision(id));",
+			"CREATE INDEX team_member_revision_revisionId ON team_member_revision(revisionId);",
+			"INSERT INTO crypto_asset_revision(cryptoAssetId, revision, createdAt, actor, action, diff, name, symbol, " +
+				"description, icoAmount, blockReward, fundingStatus, foundedDate, coinType, website, deletedAt, " +
+				"deletedBy) SELECT id, 1, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'), 'migration', 'import', '{}', name, " +
+				"symbol, description, icoAmount, blockReward, fundingStatus, foundedDate, coinType, website, " +
+				"deletedAt, deletedBy FROM crypto_asset;",
+			"INSERT INTO team_member_revision(revisionId, name) SELECT r.id, team_member.name FROM team_member " +
+				"JOIN crypto_asset_revision r ON r.cryptoAssetId = team_member.cryptoAssetId ORDER BY team_member.rowid;",
+		},
+	},
+	{
+		description: "create the api_key table",
+		statements: []string{
+			"CREATE TABLE api_key(id INTEGER PRIMARY KEY, name TEXT NOT NULL, hash TEXT UNIQUE NOT NULL, " +
+				"scope TEXT NOT NULL, createdAt TEXT NOT NULL, revokedAt TEXT);",
+		},
+	},
+	{
+		description: "create the proposal and proposal_event tables",
+		statements: []string{
+			"CREATE TABLE proposal(id INTEGER PRIMARY KEY, cryptoAssetId INTEGER NOT NULL, status TEXT NOT NULL, " +
+				"changes TEXT NOT NULL, diff TEXT NOT NULL, proposedBy TEXT NOT NULL, proposedAt TEXT NOT NULL, " +
+				"reviewedBy TEXT, reviewedAt TEXT, FOREIGN KEY(cryptoAssetId) REFERENCES crypto_asset(id));",
+			"CREATE INDEX proposal_status ON proposal(status);",
+			"CREATE TABLE proposal_event(id INTEGER PRIMARY KEY, proposalId INTEGER NOT NULL, actor TEXT NOT NULL, " +
+				"event TEXT NOT NULL, comment TEXT, createdAt TEXT NOT NULL, " +
+				"FOREIGN KEY(proposalId) REFERENCES proposal(id));",
+			"CREATE INDEX proposal_event_proposalId ON proposal_event(proposalId);",
+		},
+	},
+	{
+		description: "create the append-only audit_log table",
+		statements: []string{
+			"CREATE TABLE audit_log(id INTEGER PRIMARY KEY, createdAt TEXT NOT NULL, requestId TEXT NOT NULL, " +
+				"actor TEXT NOT NULL, clientIp TEXT NOT NULL, method TEXT NOT NULL, path TEXT NOT NULL, " +
+				"action TEXT NOT NULL, cryptoAssetId INTEGER, status INTEGER NOT NULL, payload TEXT);",
+			"CREATE INDEX audit_log_cryptoAssetId ON audit_log(cryptoAssetId);",
+			"CREATE INDEX audit_log_actor ON audit_log(actor);",
+			"CREATE TRIGGER audit_log_no_update BEFORE UPDATE ON audit_log " +
+				"BEGIN SELECT RAISE(ABORT, 'audit_log is append-only'); END;",
+			"CREATE TRIGGER audit_log_no_delete BEFORE DELETE ON audit_log " +
+				"BEGIN SELECT RAISE(ABORT, 'audit_log is append-only'); END;",
+		},
+	},
+	{
+		description: "add a version to every crypto asset, starting from its latest revision",
+		statements: []string{
+			"ALTER TABLE crypto_asset ADD COLUMN version INTEGER NOT NULL DEFAULT 1;",
+			"UPDATE crypto_asset SET version = coalesce((SELECT max(revision) FROM crypto_asset_revision " +
+				"WHERE cryptoAssetId = crypto_asset.id), 1);",
+		},
+	},
+	{
+		description: "create the idempotency_key table",
+		statements: []string{
+			"CREATE TABLE idempotency_key(idempotencyKey TEXT NOT NULL, actor TEXT NOT NULL, requestHash TEXT NOT NULL, " +
+				"status INTEGER NOT NULL, header TEXT, body BLOB, createdAt TEXT NOT NULL, expiresAt TEXT NOT NULL, " +
+				"PRIMARY KEY(idempotencyKey, actor));",
+			"CREATE INDEX idempotency_key_expiresAt ON idempotency_key(expiresAt);",
+		},
+	},
+	{
+		description: "control the funding statuses and coin types of crypto assets with vocabularies of values and aliases",
+		statements: []string{
+			"CREATE TABLE funding_status(value TEXT PRIMARY KEY, retiredAt TEXT);",
+			"CREATE TABLE funding_status_alias(alias TEXT PRIMARY KEY, value TEXT NOT NULL, " +
+				"FOREIGN KEY(value) REFERENCES funding_status(value));",
+			"CREATE INDEX funding_status_alias_value ON funding_status_alias(value);",
+			"CREATE TABLE coin_type(value TEXT PRIMARY KEY, retiredAt TEXT);",
+			"CREATE TABLE coin_type_alias(alias TEXT PRIMARY KEY, value TEXT NOT NULL, " +
+				"FOREIGN KEY(value) REFERENCES coin_type(value));",
+			"CREATE INDEX coin_type_alias_value ON coin_type_alias(value);",
+			"INSERT INTO funding_status(value) VALUES('pre-ico'), ('ico'), ('post-ico'), ('no-ico');",
+			"INSERT INTO funding_status_alias(alias, value) VALUES('pre ico', 'pre-ico'), ('presale', 'pre-ico'), " +
+				"('post ico', 'post-ico'), ('no ico', 'no-ico'), ('none', 'no-ico');",
+			"INSERT INTO coin_type(value) VALUES('currency'), ('governance'), ('platform'), ('privacy'), ('stablecoin'), " +
+				"('storage'), ('token');",
+			"INSERT INTO coin_type_alias(alias, value) VALUES('coin', 'currency'), ('cryptocurrency', 'currency'), " +
+				"('stable coin', 'stablecoin');",
+
+			// Existing crypto assets with an alias are given the value it stands for, and any other value they have is
+			// kept as a value of its own so that it can be renamed or retired.
+			"UPDATE crypto_asset SET fundingStatus = (SELECT value FROM funding_status_alias " +
+				"WHERE alias = crypto_asset.fundingStatus) WHERE fundingStatus IN (SELECT alias FROM funding_status_alias);",
+			"UPDATE crypto_asset SET coinType = (SELECT value FROM coin_type_alias WHERE alias = crypto_asset.coinType) " +
+				"WHERE coinType IN (SELECT alias FROM coin_type_alias);",
+			"INSERT OR IGNORE INTO funding_status(value) SELECT DISTINCT fundingStatus FROM crypto_asset;",
+			"INSERT OR IGNORE INTO coin_type(value) SELECT DISTINCT coinType FROM crypto_asset;",
+
+			// Foreign keys can only be added to the crypto_asset table by rebuilding it.
+			"CREATE TABLE crypto_asset_new(id INTEGER PRIMARY KEY, name TEXT NOT NULL, symbol TEXT NOT NULL, " +
+				"description TEXT NOT NULL, icoAmount REAL NOT NULL, blockReward REAL NOT NULL, " +
+				"fundingStatus TEXT NOT NULL, foundedDate TEXT NOT NULL, coinType TEXT NOT NULL, website TEXT NOT NULL, " +
+				"deletedAt TEXT, deletedBy TEXT, version INTEGER NOT NULL DEFAULT 1, " +
+				"FOREIGN KEY(fundingStatus) REFERENCES funding_status(value), " +
+				"FOREIGN KEY(coinType) REFERENCES coin_type(value));",
+			"INSERT INTO crypto_asset_new(id, name, symbol, description, icoAmount, blockReward, fundingStatus, " +
+				"foundedDate, coinType, website, deletedAt, deletedBy, version) SELECT id, name, symbol, description, " +
+				"icoAmount, blockReward, fundingStatus, foundedDate, coinType, website, deletedAt, deletedBy, version " +
+				"FROM crypto_asset;",
+			"DROP TABLE crypto_asset;",
+			"ALTER TABLE crypto_asset_new RENAME TO crypto_asset;",
+			"CREATE INDEX crypto_asset_name ON crypto_asset(name);",
+			"CREATE UNIQUE INDEX crypto_asset_symbol ON crypto_asset(symbol) WHERE deletedAt IS NULL;",
+			"CREATE INDEX crypto_asset_fundingStatus ON crypto_asset(fundingStatus);",
+			"CREATE INDEX crypto_asset_coinType ON crypto_asset(coinType);",
+		},
+	},
+	{
+		description: "create the person tables and link team members to people",
+		statements: []string{
+			"CREATE TABLE person(id INTEGER PRIMARY KEY, name TEXT NOT NULL);",
+			"CREATE INDEX person_name ON person(name);",
+			"CREATE TABLE person_alias(personId INTEGER NOT NULL, alias TEXT NOT NULL, PRIMARY KEY(personId, alias), " +
+				"FOREIGN KEY(personId) REFERENCES person(id));",
+			"CREATE INDEX person_alias_alias ON person_alias(alias);",
+			"CREATE TABLE person_handle(personId INTEGER NOT NULL, network TEXT NOT NULL, handle TEXT NOT NULL, " +
+				"PRIMARY KEY(personId, network), FOREIGN KEY(personId) REFERENCES person(id));",
+
+			// Every distinct team member name becomes a person, numbered in the order they were first listed.
+			"INSERT INTO person(name) SELECT name FROM team_member GROUP BY name ORDER BY min(rowid);",
+
+			// The team_member table is rebuilt to link crypto assets to people. The rowids are kept so that every team
+			// stays in the order it was given in.
+			"CREATE TABLE team_member_new(cryptoAssetId INTEGER NOT NULL, personId INTEGER NOT NULL, role TEXT, " +
+				"startDate TEXT, endDate TEXT, FOREIGN KEY(cryptoAssetId) REFERENCES crypto_asset(id), " +
+				"FOREIGN KEY(personId) REFERENCES person(id));",
+			"INSERT INTO team_member_new(rowid, cryptoAssetId, personId) SELECT tm.rowid, tm.cryptoAssetId, p.id " +
+				"FROM team_member tm JOIN person p ON p.name = tm.name;",
+			"DROP TABLE team_member;",
+			"ALTER TABLE team_member_new RENAME TO team_member;",
+			"CREATE INDEX team_member_cryptoAssetId ON team_member(cryptoAssetId);",
+			"CREATE INDEX team_member_personId ON team_member(personId);",
+
+			// The team of a crypto asset is still read as a list of names, in order of position.
+			"CREATE VIEW team_member_name AS SELECT tm.rowid AS position, tm.cryptoAssetId, p.name FROM team_member tm " +
+				"JOIN person p ON p.id = tm.personId;",
+		},
+	},
+}
+
+// migrate brings the database schema up to date by applying every migration it has not yet had applied. The version of
+// each applied migration is recorded in the schema_migrations table. An error is returned without changing anything if
+// the database has had migrations applied that this binary does not know about, since it was last written to by a
+// newer binary. A MissingFTS5Error is returned up front if the SQLite driver was built without FTS5, rather than
+// leaving the migration that creates the full-text index to fail with "no such module: fts5".
+func migrate(conn *sql.DB) error {
+	var fts5 bool
+	if err := conn.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5');").Scan(&fts5); err != nil {
+		return err
+	}
+	if !fts5 {
+		return NewMissingFTS5Error()
+	}
+
+	_, err := conn.Exec("CREATE TABLE IF NOT EXISTS schema_migrations(version INTEGER PRIMARY KEY, " +
+		"description TEXT NOT NULL, appliedAt TEXT NOT NULL);")
+	if err != nil {
+		return err
+	}
+
+	version, err := schemaVersion(conn)
+	if err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return NewSchemaVersionError(version, len(migrations))
+	}
+
+	for idx := version; idx < len(migrations); idx++ {
+		if err = applyMigration(conn, idx+1, migrations[idx]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// schemaVersion returns the version of the last migration applied to the database, which is 0 if none have been.
+func schemaVersion(conn *sql.DB) (int, error) {
+	var version int
+	err := conn.QueryRow("SELECT coalesce(max(version), 0) FROM schema_migrations;").Scan(&version)
+	return version, err
+}
+
+// applyMigration executes the statements of a migration and records that it was applied in a single SQL transaction,
+// so that a migration that fails part of the way through leaves the schema untouched. The migration runs on a
+// connection of its own because foreign keys can only be switched off outside of a transaction.
+func applyMigration(conn *sql.DB, version int, m migration) error {
+	ctx := context.Background()
+	migrationConn, err := conn.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer migrationConn.Close()
+
+	_, err = migrationConn.ExecContext(ctx, "PRAGMA foreign_keys = OFF;")
+	if err != nil {
+		return err
+	}
+	defer migrationConn.ExecContext(ctx, "PRAGMA foreign_keys = ON;")
+
+	transaction, err := migrationConn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, statement := range m.statements {
+		if _, err = transaction.Exec(statement); err != nil {
+			transaction.Rollback()
+			return NewMigrationError(version, m.description, err)
+		}
+	}
+
+	// Make sure the migration did not break any foreign keys while they were not being enforced.
+	rows, err := transaction.Query("PRAGMA foreign_key_check;")
+	if err != nil {
+		transaction.Rollback()
+		return err
+	}
+	violated := rows.Next()
+	rows.Close()
+	if violated {
+		transaction.Rollback()
+		return NewMigrationError(version, m.description, errors.New("FOREIGN KEY constraint failed"))
+	}
+
+	_, err = transaction.Exec("INSERT INTO schema_migrations(version, description, appliedAt) VALUES (?, ?, ?);",
+		version, m.description, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	return transaction.Commit()
+}
@@ -0,0 +1,30 @@
+//go:build !fts5
+// +build !fts5
+
+package database
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func Test_migrate_withoutFTS5(t *testing.T) {
+	conn, err := sql.Open("sqlite3", "file::memory:?_foreign_keys=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetMaxOpenConns(1)
+
+	// A driver built without the fts5 build tag is refused before any migration is applied.
+	if _, ok := migrate(conn).(*MissingFTS5Error); !ok {
+		t.Fatal("expected a missing FTS5 error migrating with a driver built without FTS5")
+	}
+	var tables int
+	if err = conn.QueryRow("SELECT count(*) FROM sqlite_master;").Scan(&tables); err != nil {
+		t.Fatal(err)
+	}
+	if tables != 0 {
+		t.Fatalf("expected an untouched database, got %d tables", tables)
+	}
+}
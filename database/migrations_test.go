@@ -0,0 +1,97 @@
+package database
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+func Test_migrate(t *testing.T) {
+	// Open an empty in-memory database. A single connection is used so that every statement sees the same database.
+	conn, err := sql.Open("sqlite3", "file::memory:?_foreign_keys=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetMaxOpenConns(1)
+
+	// Migrating an empty database applies every migration, and migrating it again does nothing.
+	for i := 0; i < 2; i++ {
+		if err = migrate(conn); err != nil {
+			t.Fatalf("unexpected error migrating the database: %s", err.Error())
+		}
+
+		version, err := schemaVersion(conn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version != len(migrations) {
+			t.Fatalf("expected schema version %d, got %d", len(migrations), version)
+		}
+	}
+
+	// A database migrated by a newer binary is refused.
+	_, err = conn.Exec("INSERT INTO schema_migrations(version, description, appliedAt) VALUES (?, 'from the future', '');",
+		len(migrations)+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := migrate(conn).(*SchemaVersionError); !ok {
+		t.Fatal("expected a schema version error migrating a database newer than the binary")
+	}
+}
+
+func Test_migrate_people(t *testing.T) {
+	conn, err := sql.Open("sqlite3", "file::memory:?_foreign_keys=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetMaxOpenConns(1)
+
+	// Apply every migration before the people were introduced and give two crypto assets teams sharing a member.
+	_, err = conn.Exec("CREATE TABLE schema_migrations(version INTEGER PRIMARY KEY, description TEXT NOT NULL, " +
+		"appliedAt TEXT NOT NULL);")
+	if err != nil {
+		t.Fatal(err)
+	}
+	peopleVersion := 12
+	for idx := 0; idx < peopleVersion-1; idx++ {
+		if err = applyMigration(conn, idx+1, migrations[idx]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err = conn.Exec("INSERT INTO crypto_asset(id, name, symbol, description, icoAmount, blockReward, fundingStatus, " +
+		"foundedDate, coinType, website) VALUES " +
+		"(1, 'ethereum', 'eth', '', 0, 0, 'no-ico', '2015-07-30', 'platform', ''), " +
+		"(2, 'polkadot', 'dot', '', 0, 0, 'no-ico', '2020-05-26', 'platform', '');")
+	if err == nil {
+		_, err = conn.Exec("INSERT INTO team_member(cryptoAssetId, name) VALUES (2, 'Gavin Wood'), " +
+			"(1, 'Vitalik Buterin'), (2, 'Robert Habermeier'), (1, 'Gavin Wood');")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every distinct name becomes a person, and every team keeps its order.
+	if err = migrate(conn); err != nil {
+		t.Fatalf("unexpected error migrating the database: %s", err.Error())
+	}
+	var people int
+	if err = conn.QueryRow("SELECT count(*) FROM person;").Scan(&people); err != nil {
+		t.Fatal(err)
+	}
+	if people != 3 {
+		t.Fatalf("expected 3 people, got %d", people)
+	}
+	expectedTeams := map[int][]string{1: {"Vitalik Buterin", "Gavin Wood"}, 2: {"Gavin Wood", "Robert Habermeier"}}
+	for id, expectedTeam := range expectedTeams {
+		cryptoAsset, err := getCryptoAsset(conn, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(expectedTeam, cryptoAsset.Team) {
+			t.Fatalf("expected crypto asset %d to have the team %v, got %v", id, expectedTeam, cryptoAsset.Team)
+		}
+	}
+}
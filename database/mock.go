@@ -10,30 +10,336 @@ type Mock struct {
 	mock.Mock
 }
 
+// AddVocabularyValue mocks adding a value to a controlled vocabulary in the database.
+func (m *Mock) AddVocabularyValue(vocabulary string, value *models.VocabularyValue) error {
+	args := m.Called(vocabulary, value)
+	return args.Error(0)
+}
+
+// APIKeys mocks a lookup of every API key from the database.
+func (m *Mock) APIKeys() ([]*models.APIKey, error) {
+	args := m.Called()
+	apiKeys, ok := args.Get(0).([]*models.APIKey)
+	if !ok {
+		return nil, args.Error(1)
+	}
+
+	return apiKeys, args.Error(1)
+}
+
+// ApproveProposal mocks approving a proposal in the database.
+func (m *Mock) ApproveProposal(id int, actor, comment string) error {
+	args := m.Called(id, actor, comment)
+	return args.Error(0)
+}
+
+// Audit mocks appending an entry to the audit log in the database.
+func (m *Mock) Audit(entry *models.AuditEntry) error {
+	args := m.Called(entry)
+	return args.Error(0)
+}
+
+// AuditLog mocks a lookup of audit log entries from the database.
+func (m *Mock) AuditLog(query *AuditQuery) ([]*models.AuditEntry, error) {
+	args := m.Called(query)
+	entries, ok := args.Get(0).([]*models.AuditEntry)
+	if !ok {
+		return nil, args.Error(1)
+	}
+
+	return entries, args.Error(1)
+}
+
+// Authenticate mocks a lookup of an API key by the key itself from the database.
+func (m *Mock) Authenticate(key string) (*models.APIKey, error) {
+	args := m.Called(key)
+	apiKey, ok := args.Get(0).(*models.APIKey)
+	if !ok {
+		return nil, args.Error(1)
+	}
+
+	return apiKey, args.Error(1)
+}
+
+// Autocomplete mocks a lookup of the crypto assets whose name or symbol start with or nearly start with the prefix.
+func (m *Mock) Autocomplete(prefix string, limit int) ([]*models.Suggestion, error) {
+	args := m.Called(prefix, limit)
+	suggestions, ok := args.Get(0).([]*models.Suggestion)
+	if !ok {
+		return nil, args.Error(1)
+	}
+
+	return suggestions, args.Error(1)
+}
+
+// CommentOnProposal mocks commenting on a proposal in the database.
+func (m *Mock) CommentOnProposal(id int, actor, comment string) error {
+	args := m.Called(id, actor, comment)
+	return args.Error(0)
+}
+
+// CreateAPIKey mocks issuing an API key in the database.
+func (m *Mock) CreateAPIKey(name, scope string) (*models.APIKey, error) {
+	args := m.Called(name, scope)
+	apiKey, ok := args.Get(0).(*models.APIKey)
+	if !ok {
+		return nil, args.Error(1)
+	}
+
+	return apiKey, args.Error(1)
+}
+
+// Delete mocks the soft deletion of a crypto asset from the database.
+func (m *Mock) Delete(id int, version *int, actor string) error {
+	args := m.Called(id, version, actor)
+	return args.Error(0)
+}
+
+// DryRunInsert mocks a dry run of a crypto asset insert into the database.
+func (m *Mock) DryRunInsert(cryptoAsset *models.CryptoAsset, actor string) (*models.CryptoAsset, error) {
+	args := m.Called(cryptoAsset, actor)
+	insertedCryptoAsset, ok := args.Get(0).(*models.CryptoAsset)
+	if !ok {
+		return nil, args.Error(1)
+	}
+
+	return insertedCryptoAsset, args.Error(1)
+}
+
+// DryRunUpdate mocks a dry run of a crypto asset update in the database.
+func (m *Mock) DryRunUpdate(id int, cryptoAsset *models.CryptoAsset, actor string) (*models.CryptoAsset, error) {
+	args := m.Called(id, cryptoAsset, actor)
+	updatedCryptoAsset, ok := args.Get(0).(*models.CryptoAsset)
+	if !ok {
+		return nil, args.Error(1)
+	}
+
+	return updatedCryptoAsset, args.Error(1)
+}
+
+// Export mocks an export of crypto assets from the database by calling each with every crypto asset the mock returns.
+func (m *Mock) Export(query *Query, each func(cryptoAsset *models.CryptoAsset) error) error {
+	args := m.Called(query)
+	cryptoAssets, _ := args.Get(0).([]*models.CryptoAsset)
+	for _, cryptoAsset := range cryptoAssets {
+		if err := each(cryptoAsset); err != nil {
+			return err
+		}
+	}
+
+	return args.Error(1)
+}
+
+// Get mocks a lookup of a single crypto asset by id from the database.
+func (m *Mock) Get(id int) (*models.CryptoAsset, error) {
+	args := m.Called(id)
+	cryptoAsset, ok := args.Get(0).(*models.CryptoAsset)
+	if !ok {
+		return nil, args.Error(1)
+	}
+
+	return cryptoAsset, args.Error(1)
+}
+
+// History mocks a lookup of the revisions of a crypto asset from the database.
+func (m *Mock) History(id int) ([]*models.Revision, error) {
+	args := m.Called(id)
+	revisions, ok := args.Get(0).([]*models.Revision)
+	if !ok {
+		return nil, args.Error(1)
+	}
+
+	return revisions, args.Error(1)
+}
+
+// Import mocks importing rows of crypto assets into the database.
+func (m *Mock) Import(rows []*models.ImportRow, upsert, atomic bool, actor string) error {
+	args := m.Called(rows, upsert, atomic, actor)
+	return args.Error(0)
+}
+
 // Insert mocks a crypto asset insert into the database.
-func (m *Mock) Insert(cryptoAsset *models.CryptoAsset) (string, error) {
-	args := m.Called(cryptoAsset)
+func (m *Mock) Insert(cryptoAsset *models.CryptoAsset, actor string) (string, error) {
+	args := m.Called(cryptoAsset, actor)
 	return args.String(0), args.Error(1)
 }
 
-// Select mocks a search for crypto assets from the database.
-func (m *Mock) Select(names, symbols, fundingStatuses, coinTypes []string, startDate,
-	endDate string) ([]*models.CryptoAsset, error) {
+// People mocks a lookup of people by name from the database.
+func (m *Mock) People(name string) ([]*models.Person, error) {
+	args := m.Called(name)
+	people, ok := args.Get(0).([]*models.Person)
+	if !ok {
+		return nil, args.Error(1)
+	}
 
-	args := m.Called(names, symbols, fundingStatuses, coinTypes, startDate, endDate)
-	cryptoAssets, ok := args.Get(0).([]*models.CryptoAsset)
+	return people, args.Error(1)
+}
+
+// Person mocks a lookup of a single person by id from the database.
+func (m *Mock) Person(id int) (*models.Person, error) {
+	args := m.Called(id)
+	person, ok := args.Get(0).(*models.Person)
+	if !ok {
+		return nil, args.Error(1)
+	}
+
+	return person, args.Error(1)
+}
+
+// PersonAssets mocks a lookup of the memberships of a person from the database.
+func (m *Mock) PersonAssets(id int) ([]*models.Membership, error) {
+	args := m.Called(id)
+	memberships, ok := args.Get(0).([]*models.Membership)
+	if !ok {
+		return nil, args.Error(1)
+	}
+
+	return memberships, args.Error(1)
+}
+
+// Proposal mocks a lookup of a single proposal by id from the database.
+func (m *Mock) Proposal(id int) (*models.Proposal, error) {
+	args := m.Called(id)
+	proposal, ok := args.Get(0).(*models.Proposal)
+	if !ok {
+		return nil, args.Error(1)
+	}
+
+	return proposal, args.Error(1)
+}
+
+// Proposals mocks a lookup of the proposals with a status from the database.
+func (m *Mock) Proposals(status string) ([]*models.Proposal, error) {
+	args := m.Called(status)
+	proposals, ok := args.Get(0).([]*models.Proposal)
+	if !ok {
+		return nil, args.Error(1)
+	}
+
+	return proposals, args.Error(1)
+}
+
+// Propose mocks storing a proposed update to a crypto asset in the database.
+func (m *Mock) Propose(id int, cryptoAsset *models.CryptoAsset, actor string) (int, error) {
+	args := m.Called(id, cryptoAsset, actor)
+	return args.Int(0), args.Error(1)
+}
+
+// RejectProposal mocks rejecting a proposal in the database.
+func (m *Mock) RejectProposal(id int, actor, comment string) error {
+	args := m.Called(id, actor, comment)
+	return args.Error(0)
+}
+
+// ReleaseIdempotencyKey mocks deleting a reserved idempotency key from the database.
+func (m *Mock) ReleaseIdempotencyKey(key, actor string) error {
+	args := m.Called(key, actor)
+	return args.Error(0)
+}
+
+// ReserveIdempotencyKey mocks reserving an idempotency key in the database.
+func (m *Mock) ReserveIdempotencyKey(key *models.IdempotencyKey) (*models.IdempotencyKey, error) {
+	args := m.Called(key)
+	storedKey, ok := args.Get(0).(*models.IdempotencyKey)
+	if !ok {
+		return nil, args.Error(1)
+	}
+
+	return storedKey, args.Error(1)
+}
+
+// RenameVocabularyValue mocks renaming a value of a controlled vocabulary in the database.
+func (m *Mock) RenameVocabularyValue(vocabulary, value, newValue, actor string) error {
+	args := m.Called(vocabulary, value, newValue, actor)
+	return args.Error(0)
+}
+
+// Restore mocks the restoration of a deleted crypto asset in the database.
+func (m *Mock) Restore(id int, version *int, actor string) error {
+	args := m.Called(id, version, actor)
+	return args.Error(0)
+}
+
+// RevokeAPIKey mocks revoking an API key in the database.
+func (m *Mock) RevokeAPIKey(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+// RetireVocabularyValue mocks retiring a value of a controlled vocabulary in the database.
+func (m *Mock) RetireVocabularyValue(vocabulary, value string) error {
+	args := m.Called(vocabulary, value)
+	return args.Error(0)
+}
+
+// Revert mocks reverting a crypto asset to a snapshot in the database.
+func (m *Mock) Revert(id int, cryptoAsset *models.CryptoAsset, actor string) error {
+	args := m.Called(id, cryptoAsset, actor)
+	return args.Error(0)
+}
+
+// Revision mocks a lookup of a single revision of a crypto asset from the database.
+func (m *Mock) Revision(id, revision int) (*models.Revision, error) {
+	args := m.Called(id, revision)
+	rev, ok := args.Get(0).(*models.Revision)
 	if !ok {
 		return nil, args.Error(1)
 	}
 
-	return cryptoAssets, args.Error(1)
+	return rev, args.Error(1)
+}
+
+// SaveIdempotentResponse mocks storing the response to a request made with an idempotency key in the database.
+func (m *Mock) SaveIdempotentResponse(key *models.IdempotencyKey) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
+
+// SchemaVersion mocks a lookup of the version of the database schema.
+func (m *Mock) SchemaVersion() (int, error) {
+	args := m.Called()
+	return args.Int(0), args.Error(1)
+}
+
+// Select mocks a search for crypto assets from the database.
+func (m *Mock) Select(query *Query) ([]*models.CryptoAsset, string, error) {
+	args := m.Called(query)
+	cryptoAssets, ok := args.Get(0).([]*models.CryptoAsset)
+	if !ok {
+		return nil, args.String(1), args.Error(2)
+	}
+
+	return cryptoAssets, args.String(1), args.Error(2)
 }
 
 // Update mocks an update to a crypto asset in the database.
-func (m *Mock) Update(id int, cryptoAsset *models.CryptoAsset) error {
-	args := m.Called(id, cryptoAsset)
+func (m *Mock) Update(id int, cryptoAsset *models.CryptoAsset, actor string) error {
+	args := m.Called(id, cryptoAsset, actor)
+	return args.Error(0)
+}
+
+// UpdateMembership mocks changing the role and tenure of a person on a team in the database.
+func (m *Mock) UpdateMembership(personID, cryptoAssetID int, membership *models.Membership, actor string) error {
+	args := m.Called(personID, cryptoAssetID, membership, actor)
 	return args.Error(0)
 }
 
+// UpdatePerson mocks a person update in the database.
+func (m *Mock) UpdatePerson(id int, person *models.Person, actor string) error {
+	args := m.Called(id, person, actor)
+	return args.Error(0)
+}
+
+// Vocabularies mocks a lookup of every controlled vocabulary from the database.
+func (m *Mock) Vocabularies(includeRetired bool) (models.Vocabularies, error) {
+	args := m.Called(includeRetired)
+	vocabularies, ok := args.Get(0).(models.Vocabularies)
+	if !ok {
+		return nil, args.Error(1)
+	}
+
+	return vocabularies, args.Error(1)
+}
+
 // Close does nothing since there is no actual database to close.
 func (m *Mock) Close() {}
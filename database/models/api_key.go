@@ -0,0 +1,56 @@
+package models
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// API key scopes. Each scope includes the scopes before it, so a write key can also read and propose changes, an
+// approve key can also write and an admin key can do anything.
+const (
+	ReadScope    = "read"
+	ProposeScope = "propose"
+	WriteScope   = "write"
+	ApproveScope = "approve"
+	AdminScope   = "admin"
+)
+
+// scopeRanks orders the scopes from least to most permissive.
+var scopeRanks = map[string]int{ReadScope: 1, ProposeScope: 2, WriteScope: 3, ApproveScope: 4, AdminScope: 5}
+
+// APIKey is a key that clients authenticate with. Only a hash of the key is stored, so the key itself is only known
+// when it is issued. A revoked key can no longer be used.
+type APIKey struct {
+	ID        int     `json:"id"`
+	Name      string  `json:"name"`
+	Scope     string  `json:"scope"`
+	CreatedAt string  `json:"createdAt"`
+	RevokedAt *string `json:"revokedAt,omitempty"`
+	Key       string  `json:"key,omitempty"`
+}
+
+// NewAPIKey creates a new API key struct from the name and scope in a JSON request body.
+func NewAPIKey(requestBody io.ReadCloser) (*APIKey, error) {
+	apiKey := &APIKey{}
+	decoder := json.NewDecoder(requestBody)
+	err := decoder.Decode(apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey.Name = strings.TrimSpace(apiKey.Name)
+	apiKey.Scope = strings.ToLower(strings.TrimSpace(apiKey.Scope))
+	return apiKey, nil
+}
+
+// HasScope determines whether the API key is allowed to do what the given scope allows.
+func (apiKey *APIKey) HasScope(scope string) bool {
+	return apiKey.RevokedAt == nil && IsScope(scope) && scopeRanks[apiKey.Scope] >= scopeRanks[scope]
+}
+
+// IsScope determines whether the given string is a known scope.
+func IsScope(scope string) bool {
+	_, ok := scopeRanks[scope]
+	return ok
+}
@@ -0,0 +1,34 @@
+package models
+
+import "encoding/json"
+
+// Audit actions. Writes to a crypto asset are audited with the same action as the revision they make, if any.
+const (
+	AddValueAction     = "addValue"
+	ApproveAction      = "approve"
+	CommentAction      = "comment"
+	CreateKeyAction    = "createKey"
+	DenyAction         = "deny"
+	ProposeAction      = "propose"
+	RejectAction       = "reject"
+	RetireValueAction  = "retireValue"
+	RevokeKeyAction    = "revokeKey"
+	UpdatePersonAction = "updatePerson"
+)
+
+// AuditEntry is an immutable record of a write made through the API, successful or not, or of a request that was
+// turned away because it was not authorized. The payload is the request body after it was normalized, if the request
+// got that far, and never includes an API key.
+type AuditEntry struct {
+	ID            int             `json:"id"`
+	CreatedAt     string          `json:"createdAt"`
+	RequestID     string          `json:"requestId"`
+	Actor         string          `json:"actor"`
+	ClientIP      string          `json:"clientIp"`
+	Method        string          `json:"method"`
+	Path          string          `json:"path"`
+	Action        string          `json:"action"`
+	CryptoAssetID *string         `json:"cryptoAssetId,omitempty"`
+	Status        int             `json:"status"`
+	Payload       json.RawMessage `json:"payload,omitempty"`
+}
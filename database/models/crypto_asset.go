@@ -2,29 +2,35 @@ package models
 
 import (
 	"encoding/json"
-	"errors"
-	"fmt"
 	"io"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/paddyquinn/messari/util"
 )
 
-// CryptoAsset is a a representation of user input of a crypto asset
+// CryptoAsset is a a representation of user input of a crypto asset. The relevance and snippet are only set on the
+// results of a full-text search, and when and by whom a crypto asset was deleted are only set on deleted crypto assets.
+// All four are ignored on input. The YAML keys are the same as the JSON keys. The version is never part of the JSON or
+// YAML. It is set when a single crypto asset is read from the database and, on an update, is the version the crypto
+// asset must still be at for the update to be made.
 type CryptoAsset struct {
-	ID            *string  `json:"id"`
-	Name          *string  `json:"name"`
-	Symbol        *string  `json:"symbol"`
-	Description   *string  `json:"description"`
-	Team          []string `json:"team"`
-	ICOAmount     *float64 `json:"icoAmount"`
-	BlockReward   *float64 `json:"blockReward"`
-	FundingStatus *string  `json:"fundingStatus"`
-	FoundedDate   *string  `json:"foundedDate"`
-	CoinType      *string  `json:"coinType"`
-	Website       *string  `json:"website"`
+	ID            *string  `json:"id" yaml:"id"`
+	Name          *string  `json:"name" yaml:"name"`
+	Symbol        *string  `json:"symbol" yaml:"symbol"`
+	Description   *string  `json:"description" yaml:"description"`
+	Team          []string `json:"team" yaml:"team"`
+	ICOAmount     *float64 `json:"icoAmount" yaml:"icoAmount"`
+	BlockReward   *float64 `json:"blockReward" yaml:"blockReward"`
+	FundingStatus *string  `json:"fundingStatus" yaml:"fundingStatus"`
+	FoundedDate   *string  `json:"foundedDate" yaml:"foundedDate"`
+	CoinType      *string  `json:"coinType" yaml:"coinType"`
+	Website       *string  `json:"website" yaml:"website"`
+	Relevance     *float64 `json:"relevance,omitempty" yaml:"relevance,omitempty"`
+	Snippet       *string  `json:"snippet,omitempty" yaml:"snippet,omitempty"`
+	DeletedAt     *string  `json:"deletedAt,omitempty" yaml:"deletedAt,omitempty"`
+	DeletedBy     *string  `json:"deletedBy,omitempty" yaml:"deletedBy,omitempty"`
+	Version       *int     `json:"-" yaml:"-"`
 }
 
 // NewCryptoAsset creates a new crypto asset from a request body (typically passed in via POST JSON).
@@ -39,6 +45,44 @@ func NewCryptoAsset(requestBody io.ReadCloser) (*CryptoAsset, error) {
 	return cryptoAsset, nil
 }
 
+// Apply returns a copy of the crypto asset with every field that is set in the update replaced by the update's value.
+// The team is replaced as a whole.
+func (asset *CryptoAsset) Apply(update *CryptoAsset) *CryptoAsset {
+	updated := *asset
+	if update.Name != nil {
+		updated.Name = update.Name
+	}
+	if update.Symbol != nil {
+		updated.Symbol = update.Symbol
+	}
+	if update.Description != nil {
+		updated.Description = update.Description
+	}
+	if update.Team != nil {
+		updated.Team = update.Team
+	}
+	if update.ICOAmount != nil {
+		updated.ICOAmount = update.ICOAmount
+	}
+	if update.BlockReward != nil {
+		updated.BlockReward = update.BlockReward
+	}
+	if update.FundingStatus != nil {
+		updated.FundingStatus = update.FundingStatus
+	}
+	if update.FoundedDate != nil {
+		updated.FoundedDate = update.FoundedDate
+	}
+	if update.CoinType != nil {
+		updated.CoinType = update.CoinType
+	}
+	if update.Website != nil {
+		updated.Website = update.Website
+	}
+
+	return &updated
+}
+
 // Format formats the fields of a crypto asset to make them more human readable.
 func (asset *CryptoAsset) Format() {
 	if asset.Name != nil {
@@ -61,21 +105,21 @@ func (asset *CryptoAsset) Format() {
 }
 
 // Normalize normalizes all of the data within a crypto asset by trimming whitespace and lowercasing everything so that
-// data that enters our database is consistent. This function returns an error if the crypto asset contains a
-// non-numeric id string, the ICO amount or block reward are below 0, or the founded date is not ISO-8601 compliant.
-func (asset *CryptoAsset) Normalize() (int, error) {
-	var (
-		id  int
-		err error
-	)
-
-	if asset.ID != nil {
-		id, err = strconv.Atoi(*asset.ID)
-		if err != nil {
-			return -1, fmt.Errorf("invalid id: %s", *asset.ID)
-		}
-	}
+// data that enters our database is consistent, then validates it. An alias of a funding status or coin type is replaced
+// by the value of the vocabulary it stands for. If complete is true every field but the id is required, as it is when a
+// crypto asset is created or replaced. This function returns a ValidationError listing every violation if any field is
+// invalid, e.g. a non-numeric id string, an ICO amount or block reward below 0, a funding status or coin type that is
+// not a live value of its vocabulary, or a founded date that is not ISO-8601 compliant.
+func (asset *CryptoAsset) Normalize(vocabularies Vocabularies, complete bool) (int, error) {
+	return asset.NormalizeChanges(vocabularies, complete, nil)
+}
 
+// NormalizeChanges normalizes and validates a crypto asset that is written over the given stored crypto asset, as
+// Normalize does, except that a symbol, founded date or website that is already stored is not held to the format rules
+// added since, so that a crypto asset stored before them can still be updated, replaced or reverted. The stored crypto
+// asset may be nil, in which case every value is held to every rule.
+func (asset *CryptoAsset) NormalizeChanges(vocabularies Vocabularies, complete bool, stored *CryptoAsset) (int,
+	error) {
 	if asset.Name != nil {
 		asset.Name = util.Normalize(*asset.Name)
 	}
@@ -100,41 +144,76 @@ func (asset *CryptoAsset) Normalize() (int, error) {
 		asset.Team = normalizedTeam
 	}
 
-	if asset.ICOAmount != nil && *asset.ICOAmount < 0 {
-		return -1, errors.New("ICO amount cannot be negative")
-	}
-
-	if asset.BlockReward != nil && *asset.BlockReward < 0 {
-		return -1, errors.New("block reward cannot be negative")
-	}
-
 	if asset.FundingStatus != nil {
-		asset.FundingStatus = util.Normalize(*asset.FundingStatus)
+		fundingStatus := vocabularies.Canonicalize(FundingStatusVocabulary, *asset.FundingStatus)
+		asset.FundingStatus = &fundingStatus
 	}
 
 	if asset.FoundedDate != nil {
 		foundedDate := strings.TrimSpace(*asset.FoundedDate)
-
-		// Ensure the date is ISO-8601 formatted and is not in the future.
-		date, err := time.Parse("2006-01-02", foundedDate)
-		if err != nil || date.After(time.Now()) {
-			return -1, errors.New("date must be an ISO-8601 date in the past")
-		}
-
 		asset.FoundedDate = &foundedDate
 	}
 
 	if asset.CoinType != nil {
-		asset.CoinType = util.Normalize(*asset.CoinType)
+		coinType := vocabularies.Canonicalize(CoinTypeVocabulary, *asset.CoinType)
+		asset.CoinType = &coinType
 	}
 
 	if asset.Website != nil {
 		asset.Website = util.Normalize(*asset.Website)
 	}
 
+	if err := asset.validate(vocabularies, complete, stored); err != nil {
+		return -1, err
+	}
+
+	var id int
+	if asset.ID != nil {
+		id, _ = strconv.Atoi(*asset.ID)
+	}
 	return id, nil
 }
 
+// Project returns the crypto asset as a map from JSON key to value that only contains the given fields and the id.
+// Unknown fields are ignored.
+func (asset *CryptoAsset) Project(fields []string) map[string]interface{} {
+	projection := map[string]interface{}{"id": asset.ID}
+	for _, field := range fields {
+		switch field {
+		case "name":
+			projection[field] = asset.Name
+		case "symbol":
+			projection[field] = asset.Symbol
+		case "description":
+			projection[field] = asset.Description
+		case "team":
+			projection[field] = asset.Team
+		case "icoAmount":
+			projection[field] = asset.ICOAmount
+		case "blockReward":
+			projection[field] = asset.BlockReward
+		case "fundingStatus":
+			projection[field] = asset.FundingStatus
+		case "foundedDate":
+			projection[field] = asset.FoundedDate
+		case "coinType":
+			projection[field] = asset.CoinType
+		case "website":
+			projection[field] = asset.Website
+		case "relevance":
+			projection[field] = asset.Relevance
+		case "snippet":
+			projection[field] = asset.Snippet
+		case "deletedAt":
+			projection[field] = asset.DeletedAt
+		case "deletedBy":
+			projection[field] = asset.DeletedBy
+		}
+	}
+
+	return projection
+}
+
 // capitalize capitalizes the first letter of a string.
 func capitalize(str string) *string {
 	capitalizedStr := strings.Title(strings.ToLower(str))
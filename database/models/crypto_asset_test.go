@@ -21,6 +21,22 @@ func TestCryptoAsset_Format(t *testing.T) {
 	assertEquals(t, "coinType", "Currency", *cryptoAsset.CoinType)
 }
 
+func TestCryptoAsset_Apply(t *testing.T) {
+	name := "bitcoin"
+	blockReward := 12.5
+	cryptoAsset := &CryptoAsset{Name: &name, BlockReward: &blockReward, Team: []string{"Satoshi Nakomoto"}}
+
+	newBlockReward := 6.25
+	updated := cryptoAsset.Apply(&CryptoAsset{BlockReward: &newBlockReward, Team: []string{}})
+	assertEquals(t, "name", "bitcoin", *updated.Name)
+	assertEquals(t, "blockReward", 6.25, *updated.BlockReward)
+	assertEquals(t, "team length", 0, len(updated.Team))
+
+	// The crypto asset itself is left alone.
+	assertEquals(t, "original blockReward", 12.5, *cryptoAsset.BlockReward)
+	assertEquals(t, "original team length", 1, len(cryptoAsset.Team))
+}
+
 func TestCryptoAsset_Normalize(t *testing.T) {
 	testNonNumericID(t)
 	testNegativeICOAmount(t)
@@ -33,41 +49,46 @@ func TestCryptoAsset_Normalize(t *testing.T) {
 func testNonNumericID(t *testing.T) {
 	idString := "a"
 	cryptoAsset := &CryptoAsset{ID: &idString}
-	id, err := cryptoAsset.Normalize()
+	id, err := cryptoAsset.Normalize(newTestVocabularies(), false)
 	assertEquals(t, "id", -1, id)
 	assertEquals(t, "error", "invalid id: a", err.Error())
+	assertViolation(t, "id", err)
 }
 
 func testNegativeICOAmount(t *testing.T) {
 	icoAmount := -1.5
 	cryptoAsset := &CryptoAsset{ICOAmount: &icoAmount}
-	id, err := cryptoAsset.Normalize()
+	id, err := cryptoAsset.Normalize(newTestVocabularies(), false)
 	assertEquals(t, "id", -1, id)
 	assertEquals(t, "error", "ICO amount cannot be negative", err.Error())
+	assertViolation(t, "icoAmount", err)
 }
 
 func testNegativeBlockReward(t *testing.T) {
 	blockReward := -1.5
 	cryptoAsset := &CryptoAsset{BlockReward: &blockReward}
-	id, err := cryptoAsset.Normalize()
+	id, err := cryptoAsset.Normalize(newTestVocabularies(), false)
 	assertEquals(t, "id", -1, id)
 	assertEquals(t, "error", "block reward cannot be negative", err.Error())
+	assertViolation(t, "blockReward", err)
 }
 
 func testNonISO8601Date(t *testing.T) {
 	date := "12/25/2017"
 	cryptoAsset := &CryptoAsset{FoundedDate: &date}
-	id, err := cryptoAsset.Normalize()
+	id, err := cryptoAsset.Normalize(newTestVocabularies(), false)
 	assertEquals(t, "id", -1, id)
 	assertEquals(t, "error", "date must be an ISO-8601 date in the past", err.Error())
+	assertViolation(t, "foundedDate", err)
 }
 
 func testFutureDate(t *testing.T) {
 	date := "9999-12-31"
 	cryptoAsset := &CryptoAsset{FoundedDate: &date}
-	id, err := cryptoAsset.Normalize()
+	id, err := cryptoAsset.Normalize(newTestVocabularies(), false)
 	assertEquals(t, "id", -1, id)
 	assertEquals(t, "error", "date must be an ISO-8601 date in the past", err.Error())
+	assertViolation(t, "foundedDate", err)
 }
 
 func testSuccess(t *testing.T) {
@@ -89,7 +110,7 @@ func testSuccess(t *testing.T) {
 		Website:       &website,
 	}
 
-	id, err := cryptoAsset.Normalize()
+	id, err := cryptoAsset.Normalize(newTestVocabularies(), false)
 	assertEquals(t, "id", 0, id)
 	assertEquals(t, "error", err, nil)
 
@@ -122,3 +143,10 @@ func assertTeamEquals(t *testing.T, expectedTeam, actualTeam []string) {
 
 	}
 }
+
+func assertViolation(t *testing.T, expectedField string, err error) {
+	violations := err.(*ValidationError).Violations()
+	if len(violations) != 1 || violations[0].Field != expectedField {
+		t.Fatalf("violation comparison failed\n\nexpected field: %s\nactual: %v", expectedField, err)
+	}
+}
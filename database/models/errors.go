@@ -0,0 +1,28 @@
+package models
+
+import "strings"
+
+// ValidationError represents an error when one or more fields of a crypto asset have values they cannot have.
+type ValidationError struct {
+	violations []*Violation
+}
+
+// NewValidationError creates a new validation error with every violation that was found.
+func NewValidationError(violations []*Violation) *ValidationError {
+	return &ValidationError{violations: violations}
+}
+
+// Error makes ValidationError adhere to the error interface. The details of the violations are returned in the string,
+// separated by semicolons.
+func (v *ValidationError) Error() string {
+	details := make([]string, len(v.violations))
+	for idx, violation := range v.violations {
+		details[idx] = violation.Detail
+	}
+	return strings.Join(details, "; ")
+}
+
+// Violations returns every violation that was found.
+func (v *ValidationError) Violations() []*Violation {
+	return v.violations
+}
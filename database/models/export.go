@@ -0,0 +1,179 @@
+package models
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// TSVFormat is the tab separated values format, which can be both exported and imported. The other formats are listed
+// with the import formats.
+const TSVFormat = "tsv"
+
+// exportHeaderComment starts the first line of an exported CSV or TSV file, which holds the schema version.
+const exportHeaderComment = "#"
+
+// ExportHeader is the first line of exported newline delimited JSON, and the envelope of an exported JSON array. It
+// holds the version of the database schema the crypto assets were exported from.
+type ExportHeader struct {
+	SchemaVersion int `json:"schemaVersion"`
+}
+
+// exportEnvelope is an exported JSON array of crypto assets.
+type exportEnvelope struct {
+	ExportHeader
+	CryptoAssets []json.RawMessage `json:"cryptoAssets"`
+}
+
+// Exporter writes crypto assets to a file one at a time, so that an export never has to hold every crypto asset in
+// memory.
+type Exporter interface {
+	// Write writes a crypto asset to the file.
+	Write(cryptoAsset *CryptoAsset) error
+
+	// Close finishes the file. Nothing is written after it is closed.
+	Close() error
+}
+
+// IsExportFormat determines whether the given string is a format that can be exported.
+func IsExportFormat(format string) bool {
+	return format == TSVFormat || IsImportFormat(format)
+}
+
+// NewExporter creates an exporter writing the given format and writes the header of the file, which holds the schema
+// version. A CSV or TSV file starts with a "#" line holding the schema version, followed by a row naming the given
+// columns by their JSON keys. The team members in the team column are separated by semicolons. Newline delimited JSON
+// starts with an export header line, and a JSON array is wrapped in an export header holding it in "cryptoAssets".
+// Every JSON crypto asset is projected onto the given fields, unless there are none. An exported file can be imported.
+func NewExporter(writer io.Writer, format string, columns, fields []string, schemaVersion int) (Exporter, error) {
+	header := &ExportHeader{SchemaVersion: schemaVersion}
+	switch format {
+	case CSVFormat, TSVFormat:
+		if _, err := fmt.Fprintf(writer, "%s schemaVersion %d\n", exportHeaderComment, schemaVersion); err != nil {
+			return nil, err
+		}
+		return NewTabularExporter(writer, format == TSVFormat, columns)
+	case JSONFormat, NDJSONFormat:
+		exporter := &jsonExporter{writer: writer, fields: fields, array: format == JSONFormat}
+		buffer, err := json.Marshal(header)
+		if err != nil {
+			return nil, err
+		}
+		if exporter.array {
+			// Leave the envelope open so that the crypto assets can be written into it.
+			buffer = append(buffer[:len(buffer)-1], ",\"cryptoAssets\":["...)
+		} else {
+			buffer = append(buffer, '\n')
+		}
+		_, err = writer.Write(buffer)
+		return exporter, err
+	default:
+		return nil, fmt.Errorf("unknown export format %s", format)
+	}
+}
+
+// NewTabularExporter creates an exporter writing a CSV file, or a TSV file if tabSeparated is true, and writes the row
+// naming the given columns by their JSON keys. Unlike NewExporter, the file does not start with the schema version.
+func NewTabularExporter(writer io.Writer, tabSeparated bool, columns []string) (Exporter, error) {
+	exporter := &tabularExporter{writer: csv.NewWriter(writer), columns: columns}
+	if tabSeparated {
+		exporter.writer.Comma = '\t'
+	}
+	return exporter, exporter.writer.Write(columns)
+}
+
+// tabularExporter writes crypto assets as the rows of a CSV or TSV file.
+type tabularExporter struct {
+	writer  *csv.Writer
+	columns []string
+}
+
+// Write makes tabularExporter adhere to the Exporter interface. A null field is an empty cell.
+func (e *tabularExporter) Write(cryptoAsset *CryptoAsset) error {
+	projection := cryptoAsset.Project(e.columns)
+	record := make([]string, len(e.columns))
+	for idx, column := range e.columns {
+		switch value := projection[column].(type) {
+		case *string:
+			if value != nil {
+				record[idx] = *value
+			}
+		case *float64:
+			if value != nil {
+				record[idx] = strconv.FormatFloat(*value, 'f', -1, 64)
+			}
+		case []string:
+			record[idx] = strings.Join(value, TeamSeparator)
+		}
+	}
+	return e.writer.Write(record)
+}
+
+// Close makes tabularExporter adhere to the Exporter interface.
+func (e *tabularExporter) Close() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+// jsonExporter writes crypto assets as the elements of a JSON array or as newline delimited JSON.
+type jsonExporter struct {
+	writer  io.Writer
+	fields  []string
+	array   bool
+	written bool
+}
+
+// Write makes jsonExporter adhere to the Exporter interface.
+func (e *jsonExporter) Write(cryptoAsset *CryptoAsset) error {
+	var value interface{} = cryptoAsset
+	if len(e.fields) > 0 {
+		value = cryptoAsset.Project(e.fields)
+	}
+	buffer, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	// Elements of an array are separated by commas, while each line of newline delimited JSON ends with a newline.
+	if !e.array {
+		buffer = append(buffer, '\n')
+	} else if e.written {
+		buffer = append([]byte{','}, buffer...)
+	}
+	e.written = true
+	_, err = e.writer.Write(buffer)
+	return err
+}
+
+// Close makes jsonExporter adhere to the Exporter interface. It closes the envelope of a JSON array.
+func (e *jsonExporter) Close() error {
+	if !e.array {
+		return nil
+	}
+	_, err := io.WriteString(e.writer, "]}\n")
+	return err
+}
+
+// ExportColumns returns the columns of an exported CSV or TSV file, which are the id followed by the given fields, or
+// by every field of a crypto asset if there are none. When and by whom a crypto asset was deleted are only exported if
+// deleted crypto assets are.
+func ExportColumns(fields []string, includeDeleted bool) []string {
+	columns := []string{"id"}
+	if len(fields) == 0 {
+		columns = append(columns, requiredFields...)
+		if includeDeleted {
+			columns = append(columns, "deletedAt", "deletedBy")
+		}
+		return columns
+	}
+
+	for _, field := range fields {
+		if field != "id" {
+			columns = append(columns, field)
+		}
+	}
+	return columns
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestNewExporter(t *testing.T) {
+	id, name, symbol, icoAmount := "1", "Bitcoin", "BTC", 0.5
+	cryptoAssets := []*CryptoAsset{
+		{ID: &id, Name: &name, Symbol: &symbol, Team: []string{"Satoshi Nakamoto", "Hal Finney"}, ICOAmount: &icoAmount},
+		{ID: &id, Name: &name, Team: []string{}},
+	}
+
+	// A CSV file starts with the schema version and the columns, and a null field is an empty cell.
+	columns := []string{"id", "name", "symbol", "team", "icoAmount"}
+	assertExport(t, CSVFormat, columns, nil, cryptoAssets, "# schemaVersion 7\nid,name,symbol,team,icoAmount\n"+
+		"1,Bitcoin,BTC,Satoshi Nakamoto;Hal Finney,0.5\n1,Bitcoin,,,\n")
+	assertExport(t, TSVFormat, columns[:2], nil, cryptoAssets[:1], "# schemaVersion 7\nid\tname\n1\tBitcoin\n")
+
+	// JSON is wrapped in an envelope holding the schema version, while newline delimited JSON starts with it.
+	fields := []string{"symbol"}
+	assertExport(t, JSONFormat, columns, fields, cryptoAssets, "{\"schemaVersion\":7,\"cryptoAssets\":["+
+		"{\"id\":\"1\",\"symbol\":\"BTC\"},{\"id\":\"1\",\"symbol\":null}]}\n")
+	assertExport(t, JSONFormat, columns, fields, nil, "{\"schemaVersion\":7,\"cryptoAssets\":[]}\n")
+	assertExport(t, NDJSONFormat, columns, fields, cryptoAssets[:1], "{\"schemaVersion\":7}\n"+
+		"{\"id\":\"1\",\"symbol\":\"BTC\"}\n")
+}
+
+func TestExportImport(t *testing.T) {
+	// Every exported file can be imported, team and all.
+	id, name, symbol := "1", "Bitcoin", "BTC"
+	cryptoAsset := &CryptoAsset{ID: &id, Name: &name, Symbol: &symbol, Team: []string{"Satoshi Nakamoto", "Hal Finney"}}
+	for _, format := range []string{CSVFormat, TSVFormat, JSONFormat, NDJSONFormat} {
+		buffer := &bytes.Buffer{}
+		exporter, err := NewExporter(buffer, format, []string{"id", "name", "symbol", "team"}, nil, 7)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = exporter.Write(cryptoAsset); err != nil {
+			t.Fatal(err)
+		}
+		if err = exporter.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		rows, err := ParseImport(buffer, format)
+		if err != nil {
+			t.Fatalf("unexpected error importing an exported %s file: %s", format, err.Error())
+		}
+		assertEquals(t, format+" rows", 1, len(rows))
+		assertEquals(t, format+" status", "", rows[0].Status)
+		assertEquals(t, format+" symbol", symbol, *rows[0].CryptoAsset.Symbol)
+		assertTeamEquals(t, cryptoAsset.Team, rows[0].CryptoAsset.Team)
+	}
+}
+
+func TestExportColumns(t *testing.T) {
+	// Every field is exported unless fields are given, but the id always comes first.
+	expectedColumns := []string{"id", "name", "symbol", "description", "team", "icoAmount", "blockReward",
+		"fundingStatus", "foundedDate", "coinType", "website", "deletedAt", "deletedBy"}
+	if columns := ExportColumns(nil, true); !reflect.DeepEqual(expectedColumns, columns) {
+		t.Fatalf("unexpected columns: %v", columns)
+	}
+	if columns := ExportColumns([]string{"team", "id", "name"}, false); !reflect.DeepEqual([]string{"id", "team",
+		"name"}, columns) {
+		t.Fatalf("unexpected columns: %v", columns)
+	}
+}
+
+// assertExport fails the test unless exporting the crypto assets in the given format writes the expected file.
+func assertExport(t *testing.T, format string, columns, fields []string, cryptoAssets []*CryptoAsset,
+	expected string) {
+
+	buffer := &bytes.Buffer{}
+	exporter, err := NewExporter(buffer, format, columns, fields, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cryptoAsset := range cryptoAssets {
+		if err = exporter.Write(cryptoAsset); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = exporter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	assertEquals(t, format+" export", expected, buffer.String())
+}
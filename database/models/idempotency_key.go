@@ -0,0 +1,20 @@
+package models
+
+// IdempotencyKey is a key a client passes in the Idempotency-Key header so that retrying a request does not repeat it.
+// A key belongs to the actor that used it and is kept, along with a hash of the request it was used for and the
+// response to that request, until it expires. The status is 0 while the request is still being handled.
+type IdempotencyKey struct {
+	Key         string
+	Actor       string
+	RequestHash string
+	Status      int
+	Header      map[string]string
+	Body        []byte
+	CreatedAt   string
+	ExpiresAt   string
+}
+
+// IsComplete determines whether the response to the request the key was used for has been stored.
+func (key *IdempotencyKey) IsComplete() bool {
+	return key.Status != 0
+}
@@ -0,0 +1,293 @@
+package models
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Import formats.
+const (
+	CSVFormat    = "csv"
+	JSONFormat   = "json"
+	NDJSONFormat = "ndjson"
+)
+
+// Import modes. An atomic import imports nothing if any row fails, while a best effort import imports every row that
+// does not.
+const (
+	AtomicImport     = "atomic"
+	BestEffortImport = "bestEffort"
+)
+
+// Import row statuses. A row of an all-or-nothing import that failed is skipped if it is valid, because nothing was
+// imported.
+const (
+	CreatedStatus   = "created"
+	UpdatedStatus   = "updated"
+	UnchangedStatus = "unchanged"
+	FailedStatus    = "failed"
+	SkippedStatus   = "skipped"
+)
+
+// TeamSeparator separates the team members in the team column of a CSV file.
+const TeamSeparator = ";"
+
+// ImportRow is a row of an imported file and what became of it. Rows are numbered from 1 in the order they appear in
+// the file, not counting the header of a CSV file. The errors list every reason a failed row could not be imported.
+type ImportRow struct {
+	Row         int          `json:"row"`
+	Status      string       `json:"status"`
+	ID          *string      `json:"id,omitempty"`
+	Symbol      *string      `json:"symbol,omitempty"`
+	Errors      []*Violation `json:"errors,omitempty"`
+	CryptoAsset *CryptoAsset `json:"-"`
+}
+
+// Fail marks the row as failed for the given reasons. A field that is already blamed for the row failing is not blamed
+// again.
+func (row *ImportRow) Fail(violations ...*Violation) {
+	row.Status = FailedStatus
+	row.ID = nil
+	for _, violation := range violations {
+		if violation.Field == "" || !row.blames(violation.Field) {
+			row.Errors = append(row.Errors, violation)
+		}
+	}
+}
+
+// blames determines whether the row already failed because of the given field.
+func (row *ImportRow) blames(field string) bool {
+	for _, violation := range row.Errors {
+		if violation.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// ImportReport counts the rows of an import by status and lists every row.
+type ImportReport struct {
+	Created   int          `json:"created"`
+	Updated   int          `json:"updated"`
+	Unchanged int          `json:"unchanged"`
+	Failed    int          `json:"failed"`
+	Skipped   int          `json:"skipped"`
+	Rows      []*ImportRow `json:"rows"`
+}
+
+// NewImportReport creates a new import report from the rows of an import.
+func NewImportReport(rows []*ImportRow) *ImportReport {
+	report := &ImportReport{Rows: rows}
+	for _, row := range rows {
+		switch row.Status {
+		case CreatedStatus:
+			report.Created++
+		case UpdatedStatus:
+			report.Updated++
+		case UnchangedStatus:
+			report.Unchanged++
+		case FailedStatus:
+			report.Failed++
+		case SkippedStatus:
+			report.Skipped++
+		}
+	}
+	return report
+}
+
+// IsImportFormat determines whether the given string is a format that can be imported.
+func IsImportFormat(format string) bool {
+	return format == CSVFormat || format == TSVFormat || format == JSONFormat || format == NDJSONFormat
+}
+
+// ParseImport parses the crypto assets in a CSV or TSV file, a JSON array or newline delimited JSON. The header of a
+// CSV or TSV file names the field in each column by its JSON key, and the team members in the team column are
+// separated by semicolons. An empty CSV or TSV cell is null, except in the team column where it is an empty team. A row
+// that cannot be parsed is returned as failed, with the reason, so that the rest of the file can still be imported. An
+// error is only returned if the file as a whole cannot be parsed. Exported files can be imported, so the header of an
+// export is skipped.
+func ParseImport(reader io.Reader, format string) ([]*ImportRow, error) {
+	switch format {
+	case CSVFormat, TSVFormat:
+		return parseCSVImport(reader, format == TSVFormat)
+	case JSONFormat:
+		// An exported JSON array is wrapped in an export envelope.
+		var rawRows json.RawMessage
+		if err := json.NewDecoder(reader).Decode(&rawRows); err != nil {
+			return nil, err
+		}
+		envelope := &exportEnvelope{}
+		if bytes.HasPrefix(rawRows, []byte("{")) {
+			if err := json.Unmarshal(rawRows, envelope); err != nil {
+				return nil, err
+			}
+		} else if err := json.Unmarshal(rawRows, &envelope.CryptoAssets); err != nil {
+			return nil, err
+		}
+		return parseJSONRows(envelope.CryptoAssets), nil
+	case NDJSONFormat:
+		var rawRows []json.RawMessage
+		scanner := bufio.NewScanner(reader)
+		scanner.Buffer(nil, 1<<20)
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) > 0 && !(len(rawRows) == 0 && isExportHeader(line)) {
+				rawRows = append(rawRows, append(json.RawMessage(nil), line...))
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return parseJSONRows(rawRows), nil
+	default:
+		return nil, fmt.Errorf("unknown import format %s", format)
+	}
+}
+
+// parseJSONRows parses each JSON object into a crypto asset.
+func parseJSONRows(rawRows []json.RawMessage) []*ImportRow {
+	rows := make([]*ImportRow, len(rawRows))
+	for idx, rawRow := range rawRows {
+		rows[idx] = &ImportRow{Row: idx + 1, CryptoAsset: &CryptoAsset{}}
+		if err := json.Unmarshal(rawRow, rows[idx].CryptoAsset); err != nil {
+			var field string
+			if typeErr, ok := err.(*json.UnmarshalTypeError); ok {
+				field = typeErr.Field
+			}
+			rows[idx].Fail(&Violation{Field: field, Code: FormatViolation, Detail: err.Error()})
+		}
+	}
+	return rows
+}
+
+// isExportHeader determines whether a line of newline delimited JSON is the export header at the top of an export.
+func isExportHeader(line []byte) bool {
+	var header map[string]json.RawMessage
+	if err := json.Unmarshal(line, &header); err != nil {
+		return false
+	}
+	_, ok := header["schemaVersion"]
+	return ok && len(header) == 1
+}
+
+// parseCSVImport parses every row of a CSV or TSV file after the header into a crypto asset. The columns of a TSV file
+// are separated by tabs. The schema version line at the top of an exported file is skipped, and so are the columns
+// saying when and by whom an exported crypto asset was deleted, which cannot be imported.
+func parseCSVImport(reader io.Reader, tabSeparated bool) ([]*ImportRow, error) {
+	bufferedReader := bufio.NewReader(reader)
+	if start, err := bufferedReader.Peek(len(exportHeaderComment)); err == nil && string(start) == exportHeaderComment {
+		if _, err = bufferedReader.ReadString('\n'); err != nil && err != io.EOF {
+			return nil, err
+		}
+	}
+
+	csvReader := csv.NewReader(bufferedReader)
+	csvReader.FieldsPerRecord = -1
+	if tabSeparated {
+		// Leading white space cannot be trimmed when the columns are separated by white space, but each cell is trimmed
+		// anyway.
+		csvReader.Comma = '\t'
+	} else {
+		csvReader.TrimLeadingSpace = true
+	}
+
+	header, err := csvReader.Read()
+	if err == io.EOF {
+		return []*ImportRow{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for idx, column := range header {
+		header[idx] = strings.TrimSpace(column)
+		if header[idx] != "id" && header[idx] != "deletedAt" && header[idx] != "deletedBy" &&
+			!isRequiredField(header[idx]) {
+			return nil, fmt.Errorf("unknown column %s", header[idx])
+		}
+	}
+
+	rows := []*ImportRow{}
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		row := &ImportRow{Row: len(rows) + 1, CryptoAsset: &CryptoAsset{}}
+		rows = append(rows, row)
+		if len(record) != len(header) {
+			row.Fail(&Violation{Code: FormatViolation, Detail: fmt.Sprintf("row has %d columns, not %d", len(record),
+				len(header))})
+			continue
+		}
+		for idx, column := range header {
+			if err = setCSVField(row.CryptoAsset, column, record[idx]); err != nil {
+				row.Fail(&Violation{Field: column, Code: FormatViolation, Detail: err.Error()})
+			}
+		}
+	}
+}
+
+// setCSVField sets the field of the crypto asset with the given JSON key to the value of a CSV cell.
+func setCSVField(asset *CryptoAsset, field, value string) error {
+	value = strings.TrimSpace(value)
+	if field == "team" {
+		asset.Team = []string{}
+		if value != "" {
+			asset.Team = strings.Split(value, TeamSeparator)
+		}
+		return nil
+	}
+	if value == "" {
+		return nil
+	}
+
+	switch field {
+	case "id":
+		asset.ID = &value
+	case "name":
+		asset.Name = &value
+	case "symbol":
+		asset.Symbol = &value
+	case "description":
+		asset.Description = &value
+	case "icoAmount", "blockReward":
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("%s must be a number", field)
+		}
+		if field == "icoAmount" {
+			asset.ICOAmount = &number
+		} else {
+			asset.BlockReward = &number
+		}
+	case "fundingStatus":
+		asset.FundingStatus = &value
+	case "foundedDate":
+		asset.FoundedDate = &value
+	case "coinType":
+		asset.CoinType = &value
+	case "website":
+		asset.Website = &value
+	}
+	return nil
+}
+
+// isRequiredField determines whether the given JSON key is one of the fields required of every crypto asset.
+func isRequiredField(field string) bool {
+	for _, requiredField := range requiredFields {
+		if field == requiredField {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseImport(t *testing.T) {
+	// Teams are split on semicolons, and an empty team cell is an empty team rather than null.
+	rows, err := ParseImport(strings.NewReader("name,symbol,team,icoAmount\nbitcoin,btc,satoshi;hal,0\n"+
+		"ether,eth,,lots\nlitecoin,ltc\n"), CSVFormat)
+	if err != nil {
+		t.Fatalf("unexpected error parsing a CSV file: %s", err.Error())
+	}
+	assertEquals(t, "rows", 3, len(rows))
+	assertEquals(t, "status", "", rows[0].Status)
+	assertEquals(t, "symbol", "btc", *rows[0].CryptoAsset.Symbol)
+	assertTeamEquals(t, []string{"satoshi", "hal"}, rows[0].CryptoAsset.Team)
+	assertEquals(t, "icoAmount", float64(0), *rows[0].CryptoAsset.ICOAmount)
+
+	// A cell that cannot be parsed or a row with the wrong number of columns fails the row, but not the file.
+	assertEquals(t, "status", FailedStatus, rows[1].Status)
+	assertTeamEquals(t, []string{}, rows[1].CryptoAsset.Team)
+	assertEquals(t, "errors", 1, len(rows[1].Errors))
+	assertEquals(t, "error", Violation{Field: "icoAmount", Code: FormatViolation, Detail: "icoAmount must be a number"},
+		*rows[1].Errors[0])
+	assertEquals(t, "row", 3, rows[2].Row)
+	assertEquals(t, "status", FailedStatus, rows[2].Status)
+
+	// A column that is not a field fails the file.
+	if _, err = ParseImport(strings.NewReader("name,price\nbitcoin,10000\n"), CSVFormat); err == nil {
+		t.Fatal("expected an error parsing a CSV file with an unknown column")
+	}
+
+	// A JSON value of the wrong type fails the row it is in.
+	rows, err = ParseImport(strings.NewReader("[{\"symbol\":\"btc\"},{\"symbol\":1}]"), JSONFormat)
+	if err != nil {
+		t.Fatalf("unexpected error parsing a JSON array: %s", err.Error())
+	}
+	assertEquals(t, "rows", 2, len(rows))
+	assertEquals(t, "symbol", "btc", *rows[0].CryptoAsset.Symbol)
+	assertEquals(t, "status", FailedStatus, rows[1].Status)
+	assertEquals(t, "field", "symbol", rows[1].Errors[0].Field)
+
+	// Blank lines of newline delimited JSON are skipped.
+	rows, err = ParseImport(strings.NewReader("{\"symbol\":\"btc\"}\n\n  \n{\"symbol\":\"eth\"}\n"), NDJSONFormat)
+	if err != nil {
+		t.Fatalf("unexpected error parsing newline delimited JSON: %s", err.Error())
+	}
+	assertEquals(t, "rows", 2, len(rows))
+	assertEquals(t, "row", 2, rows[1].Row)
+	assertEquals(t, "symbol", "eth", *rows[1].CryptoAsset.Symbol)
+}
+
+func TestImportRow_Fail(t *testing.T) {
+	// A field is only blamed once for a row failing.
+	row := &ImportRow{Row: 1, Status: CreatedStatus, ID: new(string)}
+	row.Fail(&Violation{Field: "symbol", Code: FormatViolation, Detail: "symbol must be a string"})
+	row.Fail(&Violation{Field: "symbol", Code: RequiredViolation, Detail: "symbol is required"},
+		&Violation{Field: "name", Code: RequiredViolation, Detail: "name is required"})
+	assertEquals(t, "status", FailedStatus, row.Status)
+	if row.ID != nil {
+		t.Fatal("expected a failed row to have no id")
+	}
+	assertEquals(t, "errors", 2, len(row.Errors))
+	assertEquals(t, "code", FormatViolation, row.Errors[0].Code)
+	assertEquals(t, "field", "name", row.Errors[1].Field)
+}
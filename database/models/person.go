@@ -0,0 +1,128 @@
+package models
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/paddyquinn/messari/util"
+)
+
+// Person is someone who is, or was, on the team of a crypto asset. The team of a crypto asset lists the names of the
+// people on it, and a name given in a team is linked to the person with that name or, failing that, with that alias.
+// Social handles are keyed by the network they are on, e.g. "twitter". On an update, a null name, aliases or social
+// handles are left untouched, and otherwise the aliases and social handles are replaced as a whole.
+type Person struct {
+	ID            int               `json:"id"`
+	Name          *string           `json:"name"`
+	Aliases       []string          `json:"aliases"`
+	SocialHandles map[string]string `json:"socialHandles"`
+}
+
+// NewPerson creates a new person from a JSON request body. Like team member names, names and aliases are only trimmed.
+// Networks are normalized and handles are trimmed.
+func NewPerson(requestBody io.ReadCloser) (*Person, error) {
+	person := &Person{}
+	decoder := json.NewDecoder(requestBody)
+	err := decoder.Decode(person)
+	if err != nil {
+		return nil, err
+	}
+
+	if person.Name != nil {
+		name := strings.TrimSpace(*person.Name)
+		person.Name = &name
+	}
+	if person.Aliases != nil {
+		aliases := make([]string, len(person.Aliases))
+		for idx, alias := range person.Aliases {
+			aliases[idx] = strings.TrimSpace(alias)
+		}
+		person.Aliases = aliases
+	}
+	if person.SocialHandles != nil {
+		socialHandles := make(map[string]string, len(person.SocialHandles))
+		for network, handle := range person.SocialHandles {
+			socialHandles[*util.Normalize(network)] = strings.TrimSpace(handle)
+		}
+		person.SocialHandles = socialHandles
+	}
+	return person, nil
+}
+
+// Membership is the place of a person on the team of a crypto asset: the role they have, e.g. "co-founder" or
+// "advisor", and the ISO-8601 dates they started and stopped having it. The role and dates are null when unknown, and
+// an end date is only set once the person has left. The symbol and name are those of the crypto asset. They are
+// ignored on input.
+type Membership struct {
+	CryptoAssetID string  `json:"cryptoAssetId"`
+	Symbol        *string `json:"symbol"`
+	Name          *string `json:"name"`
+	Role          *string `json:"role"`
+	StartDate     *string `json:"startDate"`
+	EndDate       *string `json:"endDate"`
+}
+
+// NewMembership creates a new membership from the role, start date and end date in a JSON request body. Roles are
+// normalized and dates are only trimmed.
+func NewMembership(requestBody io.ReadCloser) (*Membership, error) {
+	membership := &Membership{}
+	decoder := json.NewDecoder(requestBody)
+	err := decoder.Decode(membership)
+	if err != nil {
+		return nil, err
+	}
+
+	membership.CryptoAssetID, membership.Symbol, membership.Name = "", nil, nil
+	if membership.Role != nil {
+		membership.Role = util.Normalize(*membership.Role)
+	}
+	if membership.StartDate != nil {
+		startDate := strings.TrimSpace(*membership.StartDate)
+		membership.StartDate = &startDate
+	}
+	if membership.EndDate != nil {
+		endDate := strings.TrimSpace(*membership.EndDate)
+		membership.EndDate = &endDate
+	}
+	return membership, nil
+}
+
+// Validate returns a ValidationError listing every violation if a date of the membership is not an ISO-8601 date or
+// the end date is before the start date.
+func (m *Membership) Validate() error {
+	v := &validator{}
+	fields, dates := []string{"startDate", "endDate"}, []*string{m.StartDate, m.EndDate}
+	for idx, date := range dates {
+		if date == nil {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", *date); err != nil {
+			v.add(fields[idx], FormatViolation, "date must be an ISO-8601 date")
+		}
+	}
+	if len(v.violations) == 0 && m.StartDate != nil && m.EndDate != nil && *m.EndDate < *m.StartDate {
+		v.add("endDate", RangeViolation, "end date cannot be before the start date")
+	}
+	return v.err()
+}
+
+// project returns the role and tenure of the membership, along with the name of the person it belongs to, as recorded
+// in the diff of a revision.
+func (m *Membership) project(person string) map[string]interface{} {
+	return map[string]interface{}{"person": person, "role": dereference(m.Role),
+		"startDate": dereference(m.StartDate), "endDate": dereference(m.EndDate)}
+}
+
+// Format formats the symbol and name of the crypto asset the same way as the crypto asset itself.
+func (m *Membership) Format() {
+	if m.Name != nil {
+		m.Name = capitalize(strings.TrimSpace(*m.Name))
+	}
+
+	if m.Symbol != nil {
+		symbol := strings.ToUpper(strings.TrimSpace(*m.Symbol))
+		m.Symbol = &symbol
+	}
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestMembership_Validate(t *testing.T) {
+	// The role is normalized and the dates are trimmed.
+	membership, err := NewMembership(ioutil.NopCloser(strings.NewReader(
+		"{\"cryptoAssetId\":\"2\",\"role\":\" Co-Founder \",\"startDate\":\" 2013-11-27\",\"endDate\":null}")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if membership.CryptoAssetID != "" || *membership.Role != "co-founder" || *membership.StartDate != "2013-11-27" ||
+		membership.EndDate != nil {
+		t.Fatalf("unexpected membership: %+v", membership)
+	}
+	if err = membership.Validate(); err != nil {
+		t.Fatalf("unexpected error validating a membership: %s", err.Error())
+	}
+
+	// Every date that is not an ISO-8601 date is a violation.
+	startDate, endDate := "27/11/2013", "soon"
+	assertViolations(t, []*Violation{
+		{"startDate", FormatViolation, "date must be an ISO-8601 date"},
+		{"endDate", FormatViolation, "date must be an ISO-8601 date"},
+	}, (&Membership{StartDate: &startDate, EndDate: &endDate}).Validate())
+
+	// A person cannot leave a team before they joined it.
+	startDate, endDate = "2013-11-27", "2013-01-01"
+	assertViolations(t, []*Violation{
+		{"endDate", RangeViolation, "end date cannot be before the start date"},
+	}, (&Membership{StartDate: &startDate, EndDate: &endDate}).Validate())
+}
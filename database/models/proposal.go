@@ -0,0 +1,70 @@
+package models
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// Proposal statuses. A proposal is pending until it is approved, which applies its changes, or rejected.
+const (
+	PendingStatus  = "pending"
+	ApprovedStatus = "approved"
+	RejectedStatus = "rejected"
+)
+
+// Proposal events.
+const (
+	ProposedEvent  = "proposed"
+	CommentedEvent = "commented"
+	ApprovedEvent  = "approved"
+	RejectedEvent  = "rejected"
+)
+
+// Proposal is a change to a crypto asset that waits for a reviewer to approve it before it is made. The changes hold
+// the fields to update, as they would be passed to an update, and the diff holds each field that the changes would
+// modify compared to the crypto asset when the change was proposed. The events record who proposed, commented on and
+// reviewed the proposal, oldest first.
+type Proposal struct {
+	ID            int                `json:"id"`
+	CryptoAssetID string             `json:"cryptoAssetId"`
+	Status        string             `json:"status"`
+	Changes       *CryptoAsset       `json:"changes"`
+	Diff          map[string]*Change `json:"diff"`
+	ProposedBy    string             `json:"proposedBy"`
+	ProposedAt    string             `json:"proposedAt"`
+	ReviewedBy    *string            `json:"reviewedBy,omitempty"`
+	ReviewedAt    *string            `json:"reviewedAt,omitempty"`
+	Events        []*ProposalEvent   `json:"events"`
+}
+
+// ProposalEvent is a single thing someone did to a proposal. The comment is optional except on a commented event.
+type ProposalEvent struct {
+	Actor     string  `json:"actor"`
+	Event     string  `json:"event"`
+	Comment   *string `json:"comment,omitempty"`
+	CreatedAt string  `json:"createdAt"`
+}
+
+// Review is what a reviewer passes when commenting on, approving or rejecting a proposal.
+type Review struct {
+	Comment string `json:"comment"`
+}
+
+// NewReview creates a new review from a request body. An empty request body is a review without a comment.
+func NewReview(requestBody io.ReadCloser) (*Review, error) {
+	review := &Review{}
+	decoder := json.NewDecoder(requestBody)
+	err := decoder.Decode(review)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	review.Comment = strings.TrimSpace(review.Comment)
+	return review, nil
+}
+
+// IsStatus determines whether the given string is a proposal status.
+func IsStatus(status string) bool {
+	return status == PendingStatus || status == ApprovedStatus || status == RejectedStatus
+}
@@ -0,0 +1,84 @@
+package models
+
+import "reflect"
+
+// Revision actions. A change to the role or tenure of a team member, neither of which the snapshot holds, is recorded
+// with the UpdateMembershipAction and the membership before and after the change in its diff.
+const (
+	CreateAction           = "create"
+	DeleteAction           = "delete"
+	ImportAction           = "import"
+	RenameAction           = "rename"
+	RestoreAction          = "restore"
+	RevertAction           = "revert"
+	UpdateAction           = "update"
+	UpdateMembershipAction = "updateMembership"
+)
+
+// membershipDiffKey is the key a change to a membership is recorded under in the diff of a revision.
+const membershipDiffKey = "membership"
+
+// snapshotFields lists the JSON key of every field of a crypto asset that is recorded in a revision.
+var snapshotFields = []string{"name", "symbol", "description", "team", "icoAmount", "blockReward", "fundingStatus",
+	"foundedDate", "coinType", "website", "deletedAt", "deletedBy"}
+
+// Revision is an immutable record of a change to a crypto asset. The snapshot is the whole crypto asset after the
+// change and the diff holds each field that the change modified, or the membership it modified. Revisions of a crypto
+// asset are numbered from 1 in the order they were made.
+type Revision struct {
+	Revision  int                `json:"revision"`
+	CreatedAt string             `json:"createdAt"`
+	Actor     string             `json:"actor"`
+	Action    string             `json:"action"`
+	Snapshot  *CryptoAsset       `json:"snapshot"`
+	Diff      map[string]*Change `json:"diff"`
+}
+
+// Change is the value of a single field before and after a revision. A field that was not set before the revision, as
+// is the case for every field when a crypto asset is created, changes from null.
+type Change struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// NewDiff compares two snapshots of a crypto asset and returns a change for each recorded field that differs, keyed by
+// the field's JSON key. The previous snapshot is nil if there is none.
+func NewDiff(previous, current *CryptoAsset) map[string]*Change {
+	if previous == nil {
+		previous = &CryptoAsset{}
+	}
+
+	before := previous.Project(snapshotFields)
+	after := current.Project(snapshotFields)
+	diff := make(map[string]*Change)
+	for _, field := range snapshotFields {
+		if !reflect.DeepEqual(before[field], after[field]) {
+			diff[field] = &Change{From: dereference(before[field]), To: dereference(after[field])}
+		}
+	}
+
+	return diff
+}
+
+// NewMembershipDiff returns the change to the role and tenure of the named person on a team, keyed by "membership", or
+// an empty diff if neither changed.
+func NewMembershipDiff(person string, previous, current *Membership) map[string]*Change {
+	diff := make(map[string]*Change)
+	before, after := previous.project(person), current.project(person)
+	if !reflect.DeepEqual(before, after) {
+		diff[membershipDiffKey] = &Change{From: before, To: after}
+	}
+	return diff
+}
+
+// dereference returns the value a pointer points to, or nil if the pointer is nil. Any other value is returned as is.
+func dereference(value interface{}) interface{} {
+	reflectValue := reflect.ValueOf(value)
+	if reflectValue.Kind() != reflect.Ptr {
+		return value
+	}
+	if reflectValue.IsNil() {
+		return nil
+	}
+	return reflectValue.Elem().Interface()
+}
@@ -0,0 +1,26 @@
+package models
+
+import "testing"
+
+func TestNewDiff(t *testing.T) {
+	name := "bitcoin"
+	blockReward := 12.5
+	previous := &CryptoAsset{Name: &name, BlockReward: &blockReward, Team: []string{"Satoshi Nakomoto"}}
+
+	// Every set field changes from null when there is no previous snapshot.
+	diff := NewDiff(nil, previous)
+	assertEquals(t, "diff length", 3, len(diff))
+	assertEquals(t, "name from", nil, diff["name"].From)
+	assertEquals(t, "name to", "bitcoin", diff["name"].To)
+
+	// Only the fields that changed are in the diff.
+	newBlockReward := 6.25
+	current := &CryptoAsset{Name: &name, BlockReward: &newBlockReward, Team: []string{"Satoshi Nakomoto"}}
+	diff = NewDiff(previous, current)
+	assertEquals(t, "diff length", 1, len(diff))
+	assertEquals(t, "blockReward from", 12.5, diff["blockReward"].From)
+	assertEquals(t, "blockReward to", 6.25, diff["blockReward"].To)
+
+	// Nothing changed.
+	assertEquals(t, "diff length", 0, len(NewDiff(current, current)))
+}
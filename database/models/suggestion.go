@@ -0,0 +1,19 @@
+package models
+
+import "strings"
+
+// Suggestion is a crypto asset suggested as the completion of a partially typed name or symbol. The distance is the
+// number of edits between what was typed and the start of the crypto asset's name or symbol, so an exact prefix match
+// has a distance of 0.
+type Suggestion struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Symbol   string `json:"symbol"`
+	Distance int    `json:"distance"`
+}
+
+// Format formats the name and symbol of a suggestion the same way CryptoAsset.Format does.
+func (suggestion *Suggestion) Format() {
+	suggestion.Name = *capitalize(strings.TrimSpace(suggestion.Name))
+	suggestion.Symbol = strings.ToUpper(strings.TrimSpace(suggestion.Symbol))
+}
@@ -0,0 +1,181 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Violation code constants. They say what kind of rule a field broke.
+	EnumViolation     = "enum"
+	FormatViolation   = "format"
+	RangeViolation    = "range"
+	RequiredViolation = "required"
+	UniqueViolation   = "unique"
+
+	// earliestFoundedDate is the day the bitcoin genesis block was mined. No crypto asset can have been founded before
+	// it.
+	earliestFoundedDate = "2009-01-03"
+)
+
+var (
+	// symbolPattern matches a normalized symbol.
+	symbolPattern = regexp.MustCompile("^[a-z0-9]{1,10}$")
+
+	// requiredFields are the JSON keys of every field of a crypto asset but the id, in the order they are validated.
+	requiredFields = []string{"name", "symbol", "description", "team", "icoAmount", "blockReward", "fundingStatus",
+		"foundedDate", "coinType", "website"}
+)
+
+// Violation is a rule that a field of a crypto asset breaks. The field is the JSON key of the field, with the index of
+// the team member for a member of the team, e.g. team[1].
+type Violation struct {
+	Field  string `json:"field"`
+	Code   string `json:"code"`
+	Detail string `json:"detail"`
+}
+
+// validator collects the violations of a crypto asset.
+type validator struct {
+	violations []*Violation
+}
+
+// add records a violation of the given field.
+func (v *validator) add(field, code, detail string) {
+	v.violations = append(v.violations, &Violation{Field: field, Code: code, Detail: detail})
+}
+
+// err returns a ValidationError holding every violation, or nil if there are none.
+func (v *validator) err() error {
+	if len(v.violations) == 0 {
+		return nil
+	}
+	return NewValidationError(v.violations)
+}
+
+// validate checks every field of a normalized crypto asset and returns a ValidationError listing every violation. The
+// funding status and coin type must be live values of their vocabularies. If complete is true every field but the id
+// is required, as it is when a crypto asset is created or replaced. Otherwise only the fields that are set are checked.
+// The format rules for the symbol, founded date and website were added after crypto assets were first stored, so they
+// are not applied to a value that is the same as the one in the stored crypto asset, if there is one.
+func (asset *CryptoAsset) validate(vocabularies Vocabularies, complete bool, stored *CryptoAsset) error {
+	v := &validator{}
+	if stored == nil {
+		stored = &CryptoAsset{}
+	}
+
+	if asset.ID != nil {
+		if _, err := strconv.Atoi(*asset.ID); err != nil {
+			v.add("id", FormatViolation, fmt.Sprintf("invalid id: %s", *asset.ID))
+		}
+	}
+
+	if complete {
+		for _, field := range asset.nullFields() {
+			v.add(field, RequiredViolation, fmt.Sprintf("%s cannot be null", field))
+		}
+	}
+
+	if asset.Name != nil && *asset.Name == "" {
+		v.add("name", RequiredViolation, "name cannot be empty")
+	}
+
+	if changed(asset.Symbol, stored.Symbol) && !symbolPattern.MatchString(*asset.Symbol) {
+		v.add("symbol", FormatViolation, "symbol must be 1 to 10 letters or digits")
+	}
+
+	for idx, name := range asset.Team {
+		if name == "" {
+			v.add(fmt.Sprintf("team[%d]", idx), RequiredViolation, "team member names cannot be empty")
+		}
+	}
+
+	if asset.ICOAmount != nil && *asset.ICOAmount < 0 {
+		v.add("icoAmount", RangeViolation, "ICO amount cannot be negative")
+	}
+
+	if asset.BlockReward != nil && *asset.BlockReward < 0 {
+		v.add("blockReward", RangeViolation, "block reward cannot be negative")
+	}
+
+	if asset.FundingStatus != nil {
+		v.checkVocabulary(vocabularies, FundingStatusVocabulary, *asset.FundingStatus)
+	}
+
+	// Ensure the date is ISO-8601 formatted and is neither in the future nor before any crypto asset could exist.
+	if asset.FoundedDate != nil {
+		date, err := time.Parse("2006-01-02", *asset.FoundedDate)
+		switch {
+		case err != nil:
+			v.add("foundedDate", FormatViolation, "date must be an ISO-8601 date in the past")
+		case date.After(time.Now()):
+			v.add("foundedDate", RangeViolation, "date must be an ISO-8601 date in the past")
+		case *asset.FoundedDate < earliestFoundedDate && changed(asset.FoundedDate, stored.FoundedDate):
+			v.add("foundedDate", RangeViolation, fmt.Sprintf("date cannot be before %s", earliestFoundedDate))
+		}
+	}
+
+	if asset.CoinType != nil {
+		v.checkVocabulary(vocabularies, CoinTypeVocabulary, *asset.CoinType)
+	}
+
+	if changed(asset.Website, stored.Website) && !isWebURL(*asset.Website) {
+		v.add("website", FormatViolation, "website must be an http or https URL")
+	}
+
+	return v.err()
+}
+
+// checkVocabulary records a violation of the field with the given vocabulary unless the value is a live value of it.
+func (v *validator) checkVocabulary(vocabularies Vocabularies, vocabulary, value string) {
+	vocabularyValue := vocabularies.lookup(vocabulary, value)
+	switch {
+	case vocabularyValue == nil:
+		v.add(vocabulary, EnumViolation, fmt.Sprintf("%s must be one of %s", vocabulary,
+			strings.Join(vocabularies.liveValues(vocabulary), ", ")))
+	case vocabularyValue.RetiredAt != nil:
+		v.add(vocabulary, EnumViolation, fmt.Sprintf("%s %s has been retired", vocabulary, value))
+	}
+}
+
+// nullFields returns the JSON keys of every null field in the crypto asset, ignoring the id.
+func (asset *CryptoAsset) nullFields() []string {
+	set := map[string]bool{
+		"name":          asset.Name != nil,
+		"symbol":        asset.Symbol != nil,
+		"description":   asset.Description != nil,
+		"team":          asset.Team != nil,
+		"icoAmount":     asset.ICOAmount != nil,
+		"blockReward":   asset.BlockReward != nil,
+		"fundingStatus": asset.FundingStatus != nil,
+		"foundedDate":   asset.FoundedDate != nil,
+		"coinType":      asset.CoinType != nil,
+		"website":       asset.Website != nil,
+	}
+
+	var nullFields []string
+	for _, field := range requiredFields {
+		if !set[field] {
+			nullFields = append(nullFields, field)
+		}
+	}
+	return nullFields
+}
+
+// changed returns whether the value is set and differs from the stored value.
+func changed(value, storedValue *string) bool {
+	return value != nil && (storedValue == nil || *value != *storedValue)
+}
+
+// isWebURL returns whether the string is an absolute http or https URL with a host.
+func isWebURL(str string) bool {
+	parsedURL, err := url.Parse(str)
+	if err != nil {
+		return false
+	}
+	return (parsedURL.Scheme == "http" || parsedURL.Scheme == "https") && parsedURL.Host != ""
+}
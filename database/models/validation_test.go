@@ -0,0 +1,141 @@
+package models
+
+import "testing"
+
+func TestCryptoAsset_Validate(t *testing.T) {
+	testEveryViolation(t)
+	testRequiredFields(t)
+	testDateBeforeGenesis(t)
+	testCompleteSuccess(t)
+	testStoredValues(t)
+}
+
+func testEveryViolation(t *testing.T) {
+	id := "a"
+	name := "   "
+	symbol := "b!tcoin"
+	icoAmount := -1.0
+	fundingStatus := "crowdsale"
+	coinType := "meme"
+	website := "bitcoin.org"
+	cryptoAsset := &CryptoAsset{
+		ID:            &id,
+		Name:          &name,
+		Symbol:        &symbol,
+		Team:          []string{"Satoshi Nakomoto", "  "},
+		ICOAmount:     &icoAmount,
+		FundingStatus: &fundingStatus,
+		CoinType:      &coinType,
+		Website:       &website,
+	}
+
+	_, err := cryptoAsset.Normalize(newTestVocabularies(), false)
+	assertViolations(t, []*Violation{
+		{"id", FormatViolation, "invalid id: a"},
+		{"name", RequiredViolation, "name cannot be empty"},
+		{"symbol", FormatViolation, "symbol must be 1 to 10 letters or digits"},
+		{"team[1]", RequiredViolation, "team member names cannot be empty"},
+		{"icoAmount", RangeViolation, "ICO amount cannot be negative"},
+		{"fundingStatus", EnumViolation, "fundingStatus must be one of ico, no-ico, post-ico"},
+		{"coinType", EnumViolation, "coinType must be one of currency, platform, token"},
+		{"website", FormatViolation, "website must be an http or https URL"},
+	}, err)
+}
+
+func testRequiredFields(t *testing.T) {
+	name := "bitcoin"
+	cryptoAsset := &CryptoAsset{Name: &name, Team: []string{}}
+
+	// A partial crypto asset is fine unless it must be complete.
+	if _, err := cryptoAsset.Normalize(newTestVocabularies(), false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err := cryptoAsset.Normalize(newTestVocabularies(), true)
+	assertViolations(t, []*Violation{
+		{"symbol", RequiredViolation, "symbol cannot be null"},
+		{"description", RequiredViolation, "description cannot be null"},
+		{"icoAmount", RequiredViolation, "icoAmount cannot be null"},
+		{"blockReward", RequiredViolation, "blockReward cannot be null"},
+		{"fundingStatus", RequiredViolation, "fundingStatus cannot be null"},
+		{"foundedDate", RequiredViolation, "foundedDate cannot be null"},
+		{"coinType", RequiredViolation, "coinType cannot be null"},
+		{"website", RequiredViolation, "website cannot be null"},
+	}, err)
+	assertEquals(t, "error", "symbol cannot be null; description cannot be null; icoAmount cannot be null; "+
+		"blockReward cannot be null; fundingStatus cannot be null; foundedDate cannot be null; "+
+		"coinType cannot be null; website cannot be null", err.Error())
+}
+
+func testDateBeforeGenesis(t *testing.T) {
+	date := "2008-10-31"
+	cryptoAsset := &CryptoAsset{FoundedDate: &date}
+	_, err := cryptoAsset.Normalize(newTestVocabularies(), false)
+	assertViolations(t, []*Violation{{"foundedDate", RangeViolation, "date cannot be before 2009-01-03"}}, err)
+}
+
+func testCompleteSuccess(t *testing.T) {
+	name := "bitcoin"
+	symbol := "BTC"
+	description := "The original cryptocurrency"
+	icoAmount := 0.0
+	blockReward := 12.5
+	fundingStatus := "NO-ICO"
+	foundedDate := "2009-01-03"
+	coinType := "Currency"
+	website := "https://bitcoin.org/en/"
+	cryptoAsset := &CryptoAsset{
+		Name:          &name,
+		Symbol:        &symbol,
+		Description:   &description,
+		Team:          []string{},
+		ICOAmount:     &icoAmount,
+		BlockReward:   &blockReward,
+		FundingStatus: &fundingStatus,
+		FoundedDate:   &foundedDate,
+		CoinType:      &coinType,
+		Website:       &website,
+	}
+
+	_, err := cryptoAsset.Normalize(newTestVocabularies(), true)
+	assertEquals(t, "error", nil, err)
+}
+
+func testStoredValues(t *testing.T) {
+	symbol, foundedDate, website := "b.tc", "2008-10-31", "bitcoin.org"
+	stored := &CryptoAsset{Symbol: &symbol, FoundedDate: &foundedDate, Website: &website}
+
+	// Values stored before the format rules were added are let through as long as they are not changed.
+	sameSymbol, sameFoundedDate, sameWebsite := " B.TC ", "2008-10-31", "Bitcoin.org"
+	cryptoAsset := &CryptoAsset{Symbol: &sameSymbol, FoundedDate: &sameFoundedDate, Website: &sameWebsite}
+	_, err := cryptoAsset.NormalizeChanges(newTestVocabularies(), false, stored)
+	assertEquals(t, "error", nil, err)
+
+	// A changed value is held to the rules.
+	newSymbol, newFoundedDate, newWebsite := "bt.c", "2008-11-01", "bitcoin.com"
+	cryptoAsset = &CryptoAsset{Symbol: &newSymbol, FoundedDate: &newFoundedDate, Website: &newWebsite}
+	_, err = cryptoAsset.NormalizeChanges(newTestVocabularies(), false, stored)
+	assertViolations(t, []*Violation{
+		{"symbol", FormatViolation, "symbol must be 1 to 10 letters or digits"},
+		{"foundedDate", RangeViolation, "date cannot be before 2009-01-03"},
+		{"website", FormatViolation, "website must be an http or https URL"},
+	}, err)
+}
+
+func assertViolations(t *testing.T, expectedViolations []*Violation, err error) {
+	validationErr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatalf("expected a validation error, got: %v", err)
+	}
+
+	violations := validationErr.Violations()
+	if len(expectedViolations) != len(violations) {
+		t.Fatalf("expected %d violations, got %d: %v", len(expectedViolations), len(violations), err)
+	}
+	for idx, expectedViolation := range expectedViolations {
+		if *expectedViolation != *violations[idx] {
+			t.Fatalf("violation comparison at index %d failed\n\nexpected: %+v\nactual: %+v", idx, expectedViolation,
+				violations[idx])
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/paddyquinn/messari/util"
+)
+
+// Controlled vocabularies. Each is named after the JSON key of the field of a crypto asset whose values it controls.
+const (
+	CoinTypeVocabulary      = "coinType"
+	FundingStatusVocabulary = "fundingStatus"
+)
+
+// VocabularyValue is a value that a field with a controlled vocabulary can have, along with the aliases that are
+// normalized to it. A retired value can no longer be given to a crypto asset but is kept for the crypto assets that
+// already have it.
+type VocabularyValue struct {
+	Value     string   `json:"value"`
+	Aliases   []string `json:"aliases"`
+	RetiredAt *string  `json:"retiredAt,omitempty"`
+}
+
+// NewVocabularyValue creates a new vocabulary value from the value and aliases in a JSON request body. The value and
+// aliases are normalized the same way as the fields of a crypto asset.
+func NewVocabularyValue(requestBody io.ReadCloser) (*VocabularyValue, error) {
+	value := &VocabularyValue{}
+	decoder := json.NewDecoder(requestBody)
+	err := decoder.Decode(value)
+	if err != nil {
+		return nil, err
+	}
+
+	value.Value = *util.Normalize(value.Value)
+	aliases := make([]string, 0, len(value.Aliases))
+	for _, alias := range value.Aliases {
+		aliases = append(aliases, *util.Normalize(alias))
+	}
+	value.Aliases = aliases
+	return value, nil
+}
+
+// Vocabularies maps the name of each controlled vocabulary to its values.
+type Vocabularies map[string][]*VocabularyValue
+
+// IsVocabulary determines whether the given string is the name of a controlled vocabulary.
+func IsVocabulary(vocabulary string) bool {
+	return vocabulary == CoinTypeVocabulary || vocabulary == FundingStatusVocabulary
+}
+
+// Canonicalize normalizes the string and replaces it by the value of the vocabulary it is an alias of, if any.
+func (v Vocabularies) Canonicalize(vocabulary, str string) string {
+	normalizedStr := *util.Normalize(str)
+	if value := v.lookup(vocabulary, normalizedStr); value != nil {
+		return value.Value
+	}
+	return normalizedStr
+}
+
+// lookup returns the value of the vocabulary that the given normalized string is, or is an alias of, or nil if it is
+// neither.
+func (v Vocabularies) lookup(vocabulary, str string) *VocabularyValue {
+	for _, value := range v[vocabulary] {
+		if value.Value == str {
+			return value
+		}
+		for _, alias := range value.Aliases {
+			if alias == str {
+				return value
+			}
+		}
+	}
+	return nil
+}
+
+// liveValues returns every value of the vocabulary that has not been retired.
+func (v Vocabularies) liveValues(vocabulary string) []string {
+	var values []string
+	for _, value := range v[vocabulary] {
+		if value.RetiredAt == nil {
+			values = append(values, value.Value)
+		}
+	}
+	return values
+}
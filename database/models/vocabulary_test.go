@@ -0,0 +1,45 @@
+package models
+
+import "testing"
+
+func TestCryptoAsset_NormalizeVocabularies(t *testing.T) {
+	testAliases(t)
+	testRetiredValue(t)
+}
+
+func testAliases(t *testing.T) {
+	fundingStatus := "  No ICO "
+	coinType := "COIN"
+	cryptoAsset := &CryptoAsset{FundingStatus: &fundingStatus, CoinType: &coinType}
+
+	_, err := cryptoAsset.Normalize(newTestVocabularies(), false)
+	assertEquals(t, "error", nil, err)
+	assertEquals(t, "fundingStatus", "no-ico", *cryptoAsset.FundingStatus)
+	assertEquals(t, "coinType", "currency", *cryptoAsset.CoinType)
+}
+
+func testRetiredValue(t *testing.T) {
+	fundingStatus := "presale"
+	cryptoAsset := &CryptoAsset{FundingStatus: &fundingStatus}
+
+	_, err := cryptoAsset.Normalize(newTestVocabularies(), false)
+	assertViolations(t, []*Violation{{"fundingStatus", EnumViolation, "fundingStatus pre-ico has been retired"}}, err)
+}
+
+// newTestVocabularies creates vocabularies that can be used in tests. The pre-ico funding status has been retired.
+func newTestVocabularies() Vocabularies {
+	retiredAt := "2018-06-01T00:00:00Z"
+	return Vocabularies{
+		FundingStatusVocabulary: {
+			{Value: "ico", Aliases: []string{}},
+			{Value: "no-ico", Aliases: []string{"no ico", "none"}},
+			{Value: "post-ico", Aliases: []string{}},
+			{Value: "pre-ico", Aliases: []string{"presale"}, RetiredAt: &retiredAt},
+		},
+		CoinTypeVocabulary: {
+			{Value: "currency", Aliases: []string{"coin"}},
+			{Value: "platform", Aliases: []string{}},
+			{Value: "token", Aliases: []string{}},
+		},
+	}
+}
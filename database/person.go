@@ -0,0 +1,298 @@
+package database
+
+import (
+	"database/sql"
+	"strconv"
+
+	"github.com/paddyquinn/messari/database/models"
+)
+
+// Every person on the team of a crypto asset has a row in the person table, with their aliases in the person_alias
+// table and their social handles in the person_handle table. The team_member table links crypto assets to people, in
+// the order their teams were given in, along with the role and tenure of each person on the team. The team_member_name
+// view lists the name of every team member so that the team of a crypto asset can still be read as a list of names.
+
+// People returns every person whose name or one of whose aliases is the given name, as a team lists them, along with
+// their aliases and social handles, ordered by id.
+func (s *SQLite) People(name string) ([]*models.Person, error) {
+	rows, err := s.connection.Query("SELECT id FROM person WHERE name = ? UNION SELECT personId FROM person_alias "+
+		"WHERE alias = ? ORDER BY 1;", name, name)
+	if err != nil {
+		return nil, err
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err = rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	people := []*models.Person{}
+	for _, id := range ids {
+		person, err := s.Person(id)
+		if err != nil {
+			return nil, err
+		}
+		people = append(people, person)
+	}
+	return people, nil
+}
+
+// Person returns the person with the given id along with their aliases and social handles. An UnknownPersonError is
+// returned if there is no person with the given id.
+func (s *SQLite) Person(id int) (*models.Person, error) {
+	person := &models.Person{ID: id, Aliases: []string{}, SocialHandles: map[string]string{}}
+	err := s.connection.QueryRow("SELECT name FROM person WHERE id = ?;", id).Scan(&person.Name)
+	if err == sql.ErrNoRows {
+		return nil, NewUnknownPersonError(id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.connection.Query("SELECT alias FROM person_alias WHERE personId = ? ORDER BY alias;", id)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var alias string
+		if err = rows.Scan(&alias); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		person.Aliases = append(person.Aliases, alias)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = s.connection.Query("SELECT network, handle FROM person_handle WHERE personId = ?;", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var network, handle string
+		if err = rows.Scan(&network, &handle); err != nil {
+			return nil, err
+		}
+		person.SocialHandles[network] = handle
+	}
+
+	return person, rows.Err()
+}
+
+// PersonAssets returns the membership of the person with the given id on the team of every live crypto asset they are
+// on, ordered by the id of the crypto asset. An UnknownPersonError is returned if there is no person with the given
+// id.
+func (s *SQLite) PersonAssets(id int) ([]*models.Membership, error) {
+	var exists bool
+	err := s.connection.QueryRow("SELECT EXISTS(SELECT 1 FROM person WHERE id = ?);", id).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, NewUnknownPersonError(id)
+	}
+
+	// A person listed more than once on the same team has the same role and tenure each time.
+	rows, err := s.connection.Query("SELECT DISTINCT ca.id, ca.symbol, ca.name, tm.role, tm.startDate, tm.endDate "+
+		"FROM team_member tm JOIN crypto_asset ca ON ca.id = tm.cryptoAssetId WHERE tm.personId = ? AND "+
+		"ca.deletedAt IS NULL ORDER BY ca.id;", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	memberships := []*models.Membership{}
+	for rows.Next() {
+		var cryptoAssetID int
+		membership := &models.Membership{}
+		err = rows.Scan(&cryptoAssetID, &membership.Symbol, &membership.Name, &membership.Role, &membership.StartDate,
+			&membership.EndDate)
+		if err != nil {
+			return nil, err
+		}
+		membership.CryptoAssetID = strconv.Itoa(cryptoAssetID)
+		memberships = append(memberships, membership)
+	}
+
+	return memberships, rows.Err()
+}
+
+// UpdatePerson updates the person with the given id with the fields the passed person contains. Renaming a person
+// renames them on the team of every crypto asset they are on, which the actor is recorded as having done in the history
+// of each crypto asset. An UnknownPersonError is returned if there is no person with the given id, an EmptyUpdateError
+// is returned if there is nothing to update and a NullConstraintError is returned if the name, an alias or a social
+// network or handle is empty.
+func (s *SQLite) UpdatePerson(id int, person *models.Person, actor string) error {
+	if person.Name == nil && person.Aliases == nil && person.SocialHandles == nil {
+		return NewEmptyUpdateError()
+	}
+
+	// Begin a SQL transaction so that the person and every crypto asset they are on are updated together.
+	transaction, err := s.connection.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err = updatePerson(transaction, id, person, actor); err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	// Commit the transaction and return.
+	return transaction.Commit()
+}
+
+// updatePerson updates a person as described by UpdatePerson as part of a SQL transaction, which the caller must roll
+// back if an error is returned.
+func updatePerson(transaction *sql.Tx, id int, person *models.Person, actor string) error {
+	var name string
+	err := transaction.QueryRow("SELECT name FROM person WHERE id = ?;", id).Scan(&name)
+	if err == sql.ErrNoRows {
+		return NewUnknownPersonError(id)
+	}
+	if err != nil {
+		return err
+	}
+
+	if person.Aliases != nil {
+		if _, err = transaction.Exec("DELETE FROM person_alias WHERE personId = ?;", id); err != nil {
+			return err
+		}
+		for _, alias := range person.Aliases {
+			if alias == emptyString {
+				return NewNullConstraintError("aliases")
+			}
+			_, err = transaction.Exec("INSERT OR IGNORE INTO person_alias(personId, alias) VALUES(?, ?);", id, alias)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if person.SocialHandles != nil {
+		if _, err = transaction.Exec("DELETE FROM person_handle WHERE personId = ?;", id); err != nil {
+			return err
+		}
+		for network, handle := range person.SocialHandles {
+			if network == emptyString || handle == emptyString {
+				return NewNullConstraintError("socialHandles")
+			}
+			_, err = transaction.Exec("INSERT INTO person_handle(personId, network, handle) VALUES(?, ?, ?);", id,
+				network, handle)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if person.Name == nil || *person.Name == name {
+		return nil
+	}
+	if *person.Name == emptyString {
+		return NewNullConstraintError("name")
+	}
+	if _, err = transaction.Exec("UPDATE person SET name = ? WHERE id = ?;", *person.Name, id); err != nil {
+		return err
+	}
+
+	// The team of every crypto asset the person is on has changed, so each is reindexed and the change is recorded in
+	// its history.
+	ids, err := selectIDs(transaction, "SELECT DISTINCT cryptoAssetId FROM team_member WHERE personId = ? "+
+		"ORDER BY cryptoAssetId;", id)
+	if err != nil {
+		return err
+	}
+	for _, cryptoAssetID := range ids {
+		if err = indexCryptoAsset(transaction, cryptoAssetID); err != nil {
+			return err
+		}
+		if err = recordRevision(transaction, cryptoAssetID, actor, models.RenameAction); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UpdateMembership replaces the role and tenure of the person with the given id on the team of the crypto asset with
+// the given id. A change is recorded in the history of the crypto asset as having been made by the actor. An
+// UnknownMembershipError is returned if the person is not on the team of the crypto asset.
+func (s *SQLite) UpdateMembership(personID, cryptoAssetID int, membership *models.Membership, actor string) error {
+	// Begin a SQL transaction so that the membership and the revision it makes are written together.
+	transaction, err := s.connection.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err = updateMembership(transaction, personID, cryptoAssetID, membership, actor); err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	// Commit the transaction and return.
+	return transaction.Commit()
+}
+
+// updateMembership updates a membership as described by UpdateMembership as part of a SQL transaction, which the caller
+// must roll back if an error is returned.
+func updateMembership(transaction *sql.Tx, personID, cryptoAssetID int, membership *models.Membership,
+	actor string) error {
+	// A person listed more than once on the same team has the same role and tenure each time.
+	var name string
+	previous := &models.Membership{}
+	err := transaction.QueryRow("SELECT p.name, tm.role, tm.startDate, tm.endDate FROM team_member tm JOIN person p "+
+		"ON p.id = tm.personId WHERE tm.personId = ? AND tm.cryptoAssetId = ? LIMIT 1;", personID, cryptoAssetID).
+		Scan(&name, &previous.Role, &previous.StartDate, &previous.EndDate)
+	if err == sql.ErrNoRows {
+		return NewUnknownMembershipError(personID, cryptoAssetID)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Nothing is written, and so nothing is recorded, if the role and tenure are unchanged.
+	diff := models.NewMembershipDiff(name, previous, membership)
+	if len(diff) == 0 {
+		return nil
+	}
+	_, err = transaction.Exec("UPDATE team_member SET role = ?, startDate = ?, endDate = ? WHERE personId = ? AND "+
+		"cryptoAssetId = ?;", membership.Role, membership.StartDate, membership.EndDate, personID, cryptoAssetID)
+	if err != nil {
+		return err
+	}
+
+	return recordRevisionWithChanges(transaction, cryptoAssetID, actor, models.UpdateMembershipAction, diff)
+}
+
+// linkPerson returns the id of the person a team member name refers to as part of a SQL transaction. That is the
+// person with the name or, failing that, the person with the name as an alias. If several people match, the one with
+// the lowest id is chosen. A new person with the name is created if no one matches.
+func linkPerson(transaction *sql.Tx, name string) (int, error) {
+	var personID *int
+	err := transaction.QueryRow("SELECT coalesce((SELECT min(id) FROM person WHERE name = ?), "+
+		"(SELECT min(personId) FROM person_alias WHERE alias = ?));", name, name).Scan(&personID)
+	if err != nil {
+		return -1, err
+	}
+	if personID != nil {
+		return *personID, nil
+	}
+
+	result, err := transaction.Exec("INSERT INTO person(name) VALUES(?);", name)
+	if err != nil {
+		return -1, err
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
@@ -0,0 +1,207 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/paddyquinn/messari/database/models"
+)
+
+func TestSQLite_People(t *testing.T) {
+	db := newTestSQLite(t)
+	defer db.Close()
+
+	insertTestCryptoAsset(t, db, "ethereum", "eth", []string{"Vitalik Buterin"})
+	insertTestCryptoAsset(t, db, "bitcoin", "btc", []string{"Satoshi Nakamoto"})
+	if err := db.UpdatePerson(1, &models.Person{Aliases: []string{"Vitalik"}}, "bob"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A person is found by their name or by an alias, and an unknown name finds nobody.
+	for _, name := range []string{"Vitalik Buterin", "Vitalik"} {
+		people, err := db.People(name)
+		if err != nil {
+			t.Fatalf("unexpected error finding people: %s", err.Error())
+		}
+		if len(people) != 1 || people[0].ID != 1 || *people[0].Name != "Vitalik Buterin" ||
+			!reflect.DeepEqual(people[0].Aliases, []string{"Vitalik"}) {
+			t.Fatalf("expected %s to find Vitalik Buterin, got %+v", name, people)
+		}
+	}
+	people, err := db.People("Gavin Wood")
+	if err != nil {
+		t.Fatalf("unexpected error finding people: %s", err.Error())
+	}
+	if len(people) != 0 {
+		t.Fatalf("expected nobody, got %+v", people)
+	}
+}
+
+func TestSQLite_PersonAssets(t *testing.T) {
+	db := newTestSQLite(t)
+	defer db.Close()
+
+	// A person on the teams of two crypto assets is linked to both.
+	insertTestCryptoAsset(t, db, "ethereum", "eth", []string{"Vitalik Buterin", "Gavin Wood"})
+	insertTestCryptoAsset(t, db, "polkadot", "dot", []string{"Gavin Wood"})
+	role, startDate := "co-founder", "2013-11-27"
+	if err := db.UpdateMembership(2, 1, &models.Membership{Role: &role, StartDate: &startDate}, "bob"); err != nil {
+		t.Fatalf("unexpected error updating a membership: %s", err.Error())
+	}
+	memberships, err := db.PersonAssets(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(memberships) != 2 || memberships[0].CryptoAssetID != "1" || *memberships[0].Role != "co-founder" ||
+		memberships[1].CryptoAssetID != "2" || memberships[1].Role != nil {
+		t.Fatalf("unexpected memberships: %+v", memberships)
+	}
+
+	// A person who stays on a replaced team keeps their role and tenure, and the team keeps its new order.
+	if err = db.Update(1, &models.CryptoAsset{Team: []string{"Gavin Wood", "Joseph Lubin"}}, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	assertTeam(t, db, 1, []string{"Gavin Wood", "Joseph Lubin"})
+	memberships, err = db.PersonAssets(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *memberships[0].Role != "co-founder" || *memberships[0].StartDate != "2013-11-27" {
+		t.Fatalf("expected the role and tenure to be kept, got %+v", memberships[0])
+	}
+
+	// Only people on the team have a membership to update, and only people that exist have crypto assets.
+	if _, ok := db.UpdateMembership(1, 2, &models.Membership{}, "bob").(*UnknownMembershipError); !ok {
+		t.Fatal("expected an unknown membership error updating a person not on the team")
+	}
+	if _, err = db.PersonAssets(10); err == nil {
+		t.Fatal("expected an unknown person error")
+	} else if _, ok := err.(*UnknownPersonError); !ok {
+		t.Fatalf("expected an unknown person error, got %v", err)
+	}
+}
+
+func TestSQLite_UpdateMembership(t *testing.T) {
+	db := newTestSQLite(t)
+	defer db.Close()
+
+	insertTestCryptoAsset(t, db, "ethereum", "eth", []string{"Vitalik Buterin"})
+
+	// A change to the role or tenure of a team member is recorded in the history of the crypto asset and gives it a new
+	// version.
+	role, startDate := "co-founder", "2013-11-27"
+	membership := &models.Membership{Role: &role, StartDate: &startDate}
+	if err := db.UpdateMembership(1, 1, membership, "bob"); err != nil {
+		t.Fatalf("unexpected error updating a membership: %s", err.Error())
+	}
+	revisions, err := db.History(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 2 {
+		t.Fatalf("expected 2 revisions, got %d", len(revisions))
+	}
+	lastRevision := revisions[1]
+	expectedDiff := map[string]*models.Change{"membership": {
+		From: map[string]interface{}{"person": "Vitalik Buterin", "role": nil, "startDate": nil, "endDate": nil},
+		To: map[string]interface{}{"person": "Vitalik Buterin", "role": "co-founder", "startDate": "2013-11-27",
+			"endDate": nil},
+	}}
+	if lastRevision.Action != models.UpdateMembershipAction || lastRevision.Actor != "bob" ||
+		!reflect.DeepEqual(expectedDiff, lastRevision.Diff) {
+		t.Fatalf("unexpected revision: %+v", lastRevision)
+	}
+	cryptoAsset, err := db.Get(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *cryptoAsset.Version != 2 {
+		t.Fatalf("expected version 2, got %d", *cryptoAsset.Version)
+	}
+
+	// Setting the same role and tenure again records nothing.
+	if err = db.UpdateMembership(1, 1, membership, "bob"); err != nil {
+		t.Fatalf("unexpected error updating a membership: %s", err.Error())
+	}
+	if revisions, err = db.History(1); err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 2 {
+		t.Fatalf("expected an unchanged membership to record nothing, got %d revisions", len(revisions))
+	}
+}
+
+func TestSQLite_UpdatePerson(t *testing.T) {
+	db := newTestSQLite(t)
+	defer db.Close()
+
+	insertTestCryptoAsset(t, db, "ethereum", "eth", []string{"Vitalik Buterin"})
+
+	// Nothing to update is an error, as is an unknown person.
+	if _, ok := db.UpdatePerson(1, &models.Person{}, "bob").(*EmptyUpdateError); !ok {
+		t.Fatal("expected an empty update error")
+	}
+	name := "Vitalik"
+	if _, ok := db.UpdatePerson(10, &models.Person{Name: &name}, "bob").(*UnknownPersonError); !ok {
+		t.Fatal("expected an unknown person error")
+	}
+
+	// The aliases and social handles of a person are replaced.
+	err := db.UpdatePerson(1, &models.Person{Aliases: []string{"vitalik.eth", "Vitalik"},
+		SocialHandles: map[string]string{"twitter": "VitalikButerin"}}, "bob")
+	if err != nil {
+		t.Fatalf("unexpected error updating a person: %s", err.Error())
+	}
+	person, err := db.Person(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *person.Name != "Vitalik Buterin" || !reflect.DeepEqual(person.Aliases, []string{"Vitalik", "vitalik.eth"}) ||
+		!reflect.DeepEqual(person.SocialHandles, map[string]string{"twitter": "VitalikButerin"}) {
+		t.Fatalf("unexpected person: %+v", person)
+	}
+
+	// A team member given by an alias is linked to the person with that alias.
+	insertTestCryptoAsset(t, db, "ethereum classic", "etc", []string{"vitalik.eth"})
+	assertTeam(t, db, 2, []string{"Vitalik Buterin"})
+
+	// Renaming a person renames them on every team they are on, which is recorded in the history of each crypto asset.
+	name = "Vitalik B."
+	if err = db.UpdatePerson(1, &models.Person{Name: &name}, "bob"); err != nil {
+		t.Fatalf("unexpected error renaming a person: %s", err.Error())
+	}
+	assertTeam(t, db, 1, []string{"Vitalik B."})
+	assertTeam(t, db, 2, []string{"Vitalik B."})
+	revisions, err := db.History(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lastRevision := revisions[len(revisions)-1]
+	if lastRevision.Action != models.RenameAction || lastRevision.Actor != "bob" {
+		t.Fatalf("expected a rename by bob, got a %s by %s", lastRevision.Action, lastRevision.Actor)
+	}
+}
+
+// insertTestCryptoAsset inserts a crypto asset with the given name, symbol and team.
+func insertTestCryptoAsset(t *testing.T, db *SQLite, name, symbol string, team []string) {
+	description, website := "A platform", "https://example.org"
+	var icoAmount, blockReward float64
+	fundingStatus, foundedDate, coinType := "no-ico", "2015-07-30", "platform"
+	_, err := db.Insert(&models.CryptoAsset{Name: &name, Symbol: &symbol, Description: &description, Team: team,
+		ICOAmount: &icoAmount, BlockReward: &blockReward, FundingStatus: &fundingStatus, FoundedDate: &foundedDate,
+		CoinType: &coinType, Website: &website}, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// assertTeam fails the test unless the crypto asset with the given id has the expected team.
+func assertTeam(t *testing.T, db *SQLite, id int, expectedTeam []string) {
+	cryptoAsset, err := db.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expectedTeam, cryptoAsset.Team) {
+		t.Fatalf("expected crypto asset %d to have the team %v, got %v", id, expectedTeam, cryptoAsset.Team)
+	}
+}
@@ -0,0 +1,280 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/paddyquinn/messari/database/models"
+)
+
+// A proposal is a change to a crypto asset that is stored in the proposal table until a reviewer approves or rejects
+// it. Everything anyone does to a proposal is recorded in the proposal_event table.
+
+// ApproveProposal approves the pending proposal with the given id and applies its changes to the crypto asset the
+// same way Update does, recording the reviewer as the actor. If the changes cannot be applied, for example because the
+// symbol they change to has since been taken, the error is returned and the proposal stays pending.
+func (s *SQLite) ApproveProposal(id int, actor, comment string) error {
+	return s.reviewProposal(id, actor, comment, models.ApprovedStatus)
+}
+
+// CommentOnProposal records a comment on the proposal with the given id, whatever its status. A NullConstraintError
+// is returned if the comment is empty and an UnknownProposalError is returned if there is no proposal with the given id.
+func (s *SQLite) CommentOnProposal(id int, actor, comment string) error {
+	if comment == emptyString {
+		return NewNullConstraintError("comment")
+	}
+
+	// Begin a SQL transaction so that the proposal cannot change between looking it up and commenting on it.
+	transaction, err := s.connection.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err = selectProposal(transaction, id); err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	err = insertProposalEvent(transaction, id, actor, models.CommentedEvent, comment,
+		time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	// Commit the transaction and return.
+	return transaction.Commit()
+}
+
+// Proposal retrieves a single proposal and its events by id. An UnknownProposalError is returned if there is no
+// proposal with the given id.
+func (s *SQLite) Proposal(id int) (*models.Proposal, error) {
+	return selectProposal(s.connection, id)
+}
+
+// Proposals returns every proposal with the given status, or every proposal if the status is empty, oldest first.
+func (s *SQLite) Proposals(status string) ([]*models.Proposal, error) {
+	if status == emptyString {
+		return selectProposals(s.connection, "1 = 1")
+	}
+	return selectProposals(s.connection, "p.status = ?", status)
+}
+
+// Propose stores the passed crypto asset as a pending proposal to update the crypto asset with the given id and
+// returns the id of the proposal. The crypto asset must already be normalized. An EmptyUpdateError is returned if the
+// proposal would not change anything and an UnknownIDError is returned if there is no live crypto asset with the given
+// id.
+func (s *SQLite) Propose(id int, cryptoAsset *models.CryptoAsset, actor string) (int, error) {
+	// Begin a SQL transaction so that the diff is taken against the crypto asset as it is when the proposal is stored.
+	transaction, err := s.connection.Begin()
+	if err != nil {
+		return -1, err
+	}
+
+	current, err := getCryptoAsset(transaction, id)
+	if err == nil && current.DeletedAt != nil {
+		err = NewUnknownIDError(id)
+	}
+	if err != nil {
+		transaction.Rollback()
+		return -1, err
+	}
+
+	// Only the fields the proposal would change are kept in the diff.
+	diff := models.NewDiff(current, current.Apply(cryptoAsset))
+	if len(diff) == 0 {
+		transaction.Rollback()
+		return -1, NewEmptyUpdateError()
+	}
+	changesJSON, err := json.Marshal(cryptoAsset)
+	if err != nil {
+		transaction.Rollback()
+		return -1, err
+	}
+	diffJSON, err := json.Marshal(diff)
+	if err != nil {
+		transaction.Rollback()
+		return -1, err
+	}
+
+	// Insert the proposal and record who proposed it.
+	now := time.Now().UTC().Format(time.RFC3339)
+	result, err := transaction.Exec("INSERT INTO proposal(cryptoAssetId, status, changes, diff, proposedBy, proposedAt) "+
+		"VALUES(?, ?, ?, ?, ?, ?);", id, models.PendingStatus, string(changesJSON), string(diffJSON), actor, now)
+	if err != nil {
+		transaction.Rollback()
+		return -1, err
+	}
+
+	proposalID, err := result.LastInsertId()
+	if err != nil {
+		transaction.Rollback()
+		return -1, err
+	}
+
+	err = insertProposalEvent(transaction, int(proposalID), actor, models.ProposedEvent, emptyString, now)
+	if err != nil {
+		transaction.Rollback()
+		return -1, err
+	}
+
+	// Commit the transaction and return the id of the proposal.
+	if err = transaction.Commit(); err != nil {
+		return -1, err
+	}
+	return int(proposalID), nil
+}
+
+// RejectProposal rejects the pending proposal with the given id without changing the crypto asset.
+func (s *SQLite) RejectProposal(id int, actor, comment string) error {
+	return s.reviewProposal(id, actor, comment, models.RejectedStatus)
+}
+
+// reviewProposal approves or rejects the pending proposal with the given id, depending on the status. The review is
+// recorded along with the comment, if there is one. An UnknownProposalError is returned if there is no proposal with
+// the given id and a ProposalReviewedError is returned if it is not pending.
+func (s *SQLite) reviewProposal(id int, actor, comment, status string) error {
+	// Begin a SQL transaction to guarantee the proposal is only marked as approved if its changes are applied.
+	transaction, err := s.connection.Begin()
+	if err != nil {
+		return err
+	}
+
+	proposal, err := selectProposal(transaction, id)
+	if err == nil && proposal.Status != models.PendingStatus {
+		err = NewProposalReviewedError(id, proposal.Status)
+	}
+	if err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	// Apply the changes of an approved proposal. The vocabularies may have changed since the proposal was made, so its
+	// changes are normalized and validated again against the current ones.
+	if status == models.ApprovedStatus {
+		vocabularies, err := selectVocabularies(transaction, true)
+		if err == nil {
+			_, err = proposal.Changes.Normalize(vocabularies, false)
+		}
+		var cryptoAssetID int
+		if err == nil {
+			cryptoAssetID, err = strconv.Atoi(proposal.CryptoAssetID)
+		}
+		if err == nil {
+			err = updateCryptoAsset(transaction, cryptoAssetID, proposal.Changes, actor, models.UpdateAction)
+		}
+		if err != nil {
+			transaction.Rollback()
+			return err
+		}
+	}
+
+	// Mark the proposal as reviewed and record the review. The event has the same name as the status.
+	now := time.Now().UTC().Format(time.RFC3339)
+	_, err = transaction.Exec("UPDATE proposal SET status = ?, reviewedBy = ?, reviewedAt = ? WHERE id = ?;", status,
+		actor, now, id)
+	if err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	if err = insertProposalEvent(transaction, id, actor, status, comment, now); err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	// Commit the transaction and return.
+	return transaction.Commit()
+}
+
+// insertProposalEvent records that the actor did something to a proposal as part of a SQL transaction. An empty
+// comment is stored as null.
+func insertProposalEvent(transaction *sql.Tx, id int, actor, event, comment, createdAt string) error {
+	var commentValue *string
+	if comment != emptyString {
+		commentValue = &comment
+	}
+
+	_, err := transaction.Exec("INSERT INTO proposal_event(proposalId, actor, event, comment, createdAt) "+
+		"VALUES(?, ?, ?, ?, ?);", id, actor, event, commentValue, createdAt)
+	return err
+}
+
+// selectProposal retrieves a single proposal and its events by id using either a SQL connection or a SQL transaction.
+// An UnknownProposalError is returned if there is no proposal with the given id.
+func selectProposal(q queryer, id int) (*models.Proposal, error) {
+	proposals, err := selectProposals(q, "p.id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(proposals) == 0 {
+		return nil, NewUnknownProposalError(id)
+	}
+
+	return proposals[0], nil
+}
+
+// selectProposals returns the proposals matching the condition, which is written against the proposal table aliased
+// as p, along with their events, oldest first.
+func selectProposals(q queryer, condition string, args ...interface{}) ([]*models.Proposal, error) {
+	rows, err := q.Query("SELECT p.id, p.cryptoAssetId, p.status, p.changes, p.diff, p.proposedBy, p.proposedAt, "+
+		"p.reviewedBy, p.reviewedAt FROM proposal p WHERE "+condition+" ORDER BY p.id;", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	proposals := []*models.Proposal{}
+	proposalMap := make(map[int]*models.Proposal)
+	for rows.Next() {
+		var (
+			proposal      = &models.Proposal{Events: []*models.ProposalEvent{}}
+			cryptoAssetID int
+			changesJSON   string
+			diffJSON      string
+		)
+		err = rows.Scan(&proposal.ID, &cryptoAssetID, &proposal.Status, &changesJSON, &diffJSON, &proposal.ProposedBy,
+			&proposal.ProposedAt, &proposal.ReviewedBy, &proposal.ReviewedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		proposal.CryptoAssetID = strconv.Itoa(cryptoAssetID)
+		if err = json.Unmarshal([]byte(changesJSON), &proposal.Changes); err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal([]byte(diffJSON), &proposal.Diff); err != nil {
+			return nil, err
+		}
+		proposalMap[proposal.ID] = proposal
+		proposals = append(proposals, proposal)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	// Attach the events to their proposals.
+	eventRows, err := q.Query("SELECT proposalId, actor, event, comment, createdAt FROM proposal_event WHERE "+
+		"proposalId IN (SELECT p.id FROM proposal p WHERE "+condition+") ORDER BY id;", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer eventRows.Close()
+
+	for eventRows.Next() {
+		var proposalID int
+		event := &models.ProposalEvent{}
+		err = eventRows.Scan(&proposalID, &event.Actor, &event.Event, &event.Comment, &event.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if proposal, ok := proposalMap[proposalID]; ok {
+			proposal.Events = append(proposal.Events, event)
+		}
+	}
+
+	return proposals, eventRows.Err()
+}
@@ -0,0 +1,300 @@
+package database
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/paddyquinn/messari/database/models"
+)
+
+const (
+	// MaxLimit is the largest number of crypto assets that can be returned in a single page.
+	MaxLimit = 1000
+
+	// descendingPrefix is prepended to a sort field to sort in descending order, e.g. "-icoAmount".
+	descendingPrefix = "-"
+
+	// idField is the field every query returns and breaks sort ties with.
+	idField = "id"
+
+	// relevanceField and snippetField are only available to full-text searches. The former is how well a crypto asset
+	// matches the search text, where higher is better, and the latter is an excerpt of the crypto asset with each match
+	// highlighted.
+	relevanceField = "relevance"
+	snippetField   = "snippet"
+
+	// teamField is the only field that is not a column of the crypto_asset table.
+	teamField = "team"
+
+	// versionColumn is the column of the crypto_asset table that counts its revisions. It is not a field that can be
+	// searched, sorted or projected.
+	versionColumn = "version"
+)
+
+// columns lists every column of the crypto_asset table in the order they are selected. Each column has the same name
+// as the crypto asset's JSON key.
+var columns = []string{"id", "name", "symbol", "description", "icoAmount", "blockReward", "fundingStatus",
+	"foundedDate", "coinType", "website", "deletedAt", "deletedBy"}
+
+// sortFields is the set of crypto asset fields that search results can be sorted by.
+var sortFields = map[string]bool{
+	"id":          true,
+	"name":        true,
+	"symbol":      true,
+	"foundedDate": true,
+	"icoAmount":   true,
+	"blockReward": true,
+	"relevance":   true,
+}
+
+// Query describes a search for crypto assets. A crypto asset must match at least one value of every non-empty filter. A
+// crypto asset matches a team member if anyone on its team has that name, ignoring case, and its whole team is returned
+// either way. The date and numeric range filters are inclusive and are ignored when nil or empty. HasBlockReward
+// filters for crypto assets with a non-zero block reward when true and a block reward of zero when false. Deleted
+// crypto assets are only included if IncludeDeleted is true. If AsOf is set to an RFC 3339 UTC timestamp, e.g.
+// "2018-06-01T00:00:00Z", the crypto assets are searched as they were at that time. A search as of a point in time
+// cannot be a full-text search.
+//
+// Results are ordered by the sort keys, with ties broken by ascending id. If there are no sort keys, full-text search
+// results are sorted by descending relevance and all other results by id alone. A limit of 0 returns every result;
+// otherwise the cursor returned with one page is passed to get the next. If fields are given, only those fields and the
+// id are guaranteed to be set on each crypto asset.
+type Query struct {
+	// Filters.
+	Names           []string
+	Symbols         []string
+	FundingStatuses []string
+	CoinTypes       []string
+	TeamMembers     []string
+	StartDate       string
+	EndDate         string
+	MinICOAmount    *float64
+	MaxICOAmount    *float64
+	MinBlockReward  *float64
+	MaxBlockReward  *float64
+	HasBlockReward  *bool
+	Text            string
+	IncludeDeleted  bool
+	AsOf            string
+
+	// Ordering and pagination.
+	Sort   []SortKey
+	Limit  int
+	Cursor string
+
+	// Projection.
+	Fields []string
+}
+
+// SortKey is a field to sort search results by and the direction to sort it in.
+type SortKey struct {
+	Field      string
+	Descending bool
+}
+
+// ParseSortKey parses a sort key from a field optionally prefixed with "-" to sort in descending order.
+func ParseSortKey(str string) SortKey {
+	return SortKey{Field: strings.TrimPrefix(str, descendingPrefix), Descending: strings.HasPrefix(str, descendingPrefix)}
+}
+
+// String makes SortKey adhere to the fmt.Stringer interface. It is the inverse of ParseSortKey.
+func (k SortKey) String() string {
+	if k.Descending {
+		return descendingPrefix + k.Field
+	}
+	return k.Field
+}
+
+// cursor marks the last crypto asset returned on a page. The next page starts immediately after it. The sort is
+// recorded so that a cursor cannot be reused with a different ordering.
+type cursor struct {
+	Sort   []string      `json:"s"`
+	Values []interface{} `json:"v"`
+	ID     int           `json:"id"`
+}
+
+// validate checks that the query can be run and decodes its cursor, which is nil if the query has none.
+func (q *Query) validate() (*cursor, error) {
+	if q.Limit < 0 || q.Limit > MaxLimit {
+		return nil, NewInvalidLimitError(q.Limit)
+	}
+
+	// The full-text search index only holds the current state of each crypto asset.
+	if q.AsOf != emptyString && q.isFullText() {
+		return nil, NewUnsupportedQueryError("full-text search cannot be combined with asOf")
+	}
+
+	for _, key := range q.sort() {
+		if !sortFields[key.Field] || (key.Field == relevanceField && !q.isFullText()) {
+			return nil, NewUnknownSortFieldError(key.Field)
+		}
+	}
+
+	for _, field := range q.Fields {
+		if field != teamField && !isColumn(field) && !(q.isFullText() && isSearchField(field)) {
+			return nil, NewUnknownFieldError(field)
+		}
+	}
+
+	if q.Cursor == emptyString {
+		return nil, nil
+	}
+
+	after, err := decodeCursor(q.Cursor)
+	if err != nil || strings.Join(after.Sort, ",") != strings.Join(q.sortStrings(), ",") ||
+		len(after.Values) != len(q.sort()) {
+
+		return nil, NewInvalidCursorError()
+	}
+	return after, nil
+}
+
+// columns returns the crypto_asset columns the query selects, followed by the relevance and snippet of a full-text
+// search. The id and every sort field are always selected, the former to tell crypto assets apart and the latter to
+// create the cursor to the next page.
+func (q *Query) columns() []string {
+	availableColumns := columns
+	if q.isFullText() {
+		availableColumns = append(append([]string{}, columns...), relevanceField, snippetField)
+	}
+
+	if len(q.Fields) == 0 {
+		return availableColumns
+	}
+
+	selected := map[string]bool{idField: true}
+	for _, field := range q.Fields {
+		selected[field] = true
+	}
+	for _, key := range q.sort() {
+		selected[key.Field] = true
+	}
+
+	var queryColumns []string
+	for _, column := range availableColumns {
+		if selected[column] {
+			queryColumns = append(queryColumns, column)
+		}
+	}
+	return queryColumns
+}
+
+// isFullText determines whether the query is a full-text search.
+func (q *Query) isFullText() bool {
+	return len(q.searchTerms()) > 0
+}
+
+// searchTerms splits the query's text into the words to search for.
+func (q *Query) searchTerms() []string {
+	return strings.Fields(q.Text)
+}
+
+// matchExpression creates the FTS5 MATCH expression for the query's text. Each word is quoted so that characters with
+// a special meaning in the FTS5 query syntax are searched for rather than interpreted, and every word must match.
+func (q *Query) matchExpression() string {
+	terms := q.searchTerms()
+	for idx, term := range terms {
+		terms[idx] = fmt.Sprintf("\"%s\"", strings.Replace(term, "\"", "\"\"", -1))
+	}
+	return strings.Join(terms, " ")
+}
+
+// sort returns the keys the query's results are sorted by, which are the most relevant first for a full-text search
+// without sort keys.
+func (q *Query) sort() []SortKey {
+	if len(q.Sort) == 0 && q.isFullText() {
+		return []SortKey{{Field: relevanceField, Descending: true}}
+	}
+	return q.Sort
+}
+
+// includesTeam determines whether the query selects the team members of each crypto asset.
+func (q *Query) includesTeam() bool {
+	if len(q.Fields) == 0 {
+		return true
+	}
+
+	for _, field := range q.Fields {
+		if field == teamField {
+			return true
+		}
+	}
+	return false
+}
+
+// nextCursor creates the opaque cursor pointing just past the given crypto asset, which must be the last crypto asset
+// on the current page.
+func (q *Query) nextCursor(last *models.CryptoAsset) string {
+	sort := q.sort()
+	after := &cursor{Sort: q.sortStrings(), Values: make([]interface{}, len(sort))}
+	for idx, key := range sort {
+		after.Values[idx] = fieldValue(last, key.Field)
+	}
+	after.ID, _ = strconv.Atoi(*last.ID)
+
+	buffer, _ := json.Marshal(after)
+	return base64.RawURLEncoding.EncodeToString(buffer)
+}
+
+// sortStrings returns the string form of each of the query's sort keys.
+func (q *Query) sortStrings() []string {
+	sort := q.sort()
+	sortStrings := make([]string, len(sort))
+	for idx, key := range sort {
+		sortStrings[idx] = key.String()
+	}
+	return sortStrings
+}
+
+// decodeCursor decodes an opaque cursor previously created by nextCursor.
+func decodeCursor(encodedCursor string) (*cursor, error) {
+	buffer, err := base64.RawURLEncoding.DecodeString(encodedCursor)
+	if err != nil {
+		return nil, err
+	}
+
+	after := &cursor{}
+	if err = json.Unmarshal(buffer, after); err != nil {
+		return nil, err
+	}
+	return after, nil
+}
+
+// fieldValue returns the value of a sortable field of the crypto asset.
+func fieldValue(cryptoAsset *models.CryptoAsset, field string) interface{} {
+	switch field {
+	case "name":
+		return *cryptoAsset.Name
+	case "symbol":
+		return *cryptoAsset.Symbol
+	case "foundedDate":
+		return *cryptoAsset.FoundedDate
+	case "icoAmount":
+		return *cryptoAsset.ICOAmount
+	case "blockReward":
+		return *cryptoAsset.BlockReward
+	case "relevance":
+		return *cryptoAsset.Relevance
+	}
+
+	id, _ := strconv.Atoi(*cryptoAsset.ID)
+	return id
+}
+
+// isSearchField determines whether the field is only available to full-text searches.
+func isSearchField(field string) bool {
+	return field == relevanceField || field == snippetField
+}
+
+// isColumn determines whether the field is a column of the crypto_asset table.
+func isColumn(field string) bool {
+	for _, column := range columns {
+		if column == field {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/paddyquinn/messari/database/models"
+)
+
+func TestQuery_validate(t *testing.T) {
+	// A cursor created for one sort cannot be used with another.
+	nameQuery := &Query{Sort: []SortKey{{Field: "name"}}}
+	nameCursor := nameQuery.nextCursor(&models.CryptoAsset{ID: stringPointer("1"), Name: stringPointer("bitcoin")})
+	symbolQuery := &Query{Sort: []SortKey{{Field: "symbol"}}, Cursor: nameCursor}
+	if _, err := symbolQuery.validate(); err == nil {
+		t.Fatal("expected an error using a name cursor to sort by symbol")
+	}
+
+	if _, err := (&Query{Limit: -1}).validate(); err == nil {
+		t.Fatal("expected an error using a negative limit")
+	}
+
+	if _, err := (&Query{Sort: []SortKey{{Field: "website"}}}).validate(); err == nil {
+		t.Fatal("expected an error sorting by an unsortable field")
+	}
+
+	if _, err := (&Query{Fields: []string{"price"}}).validate(); err == nil {
+		t.Fatal("expected an error projecting an unknown field")
+	}
+
+	nameQuery.Cursor = nameCursor
+	after, err := nameQuery.validate()
+	if err != nil {
+		t.Fatalf("unexpected error validating query: %s", err.Error())
+	}
+	if after.ID != 1 || after.Values[0] != "bitcoin" {
+		t.Fatalf("unexpected cursor\n\nexpected: 1 [bitcoin]\nactual: %d %v", after.ID, after.Values)
+	}
+}
+
+func TestParseSortKey(t *testing.T) {
+	key := ParseSortKey("-icoAmount")
+	if key.Field != "icoAmount" || !key.Descending {
+		t.Fatalf("unexpected sort key\n\nexpected: icoAmount descending\nactual: %s", key)
+	}
+
+	if str := key.String(); str != "-icoAmount" {
+		t.Fatalf("unexpected sort key string\n\nexpected: -icoAmount\nactual: %s", str)
+	}
+}
@@ -0,0 +1,193 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/paddyquinn/messari/database/models"
+)
+
+// Every change to a crypto asset is recorded as a revision in the crypto_asset_revision table, which has a column for
+// every column of the crypto_asset table holding its value after the change. The team after the change is recorded in
+// the team_member_revision table. Revisions are never updated or deleted.
+
+// revisionColumns lists the columns of the crypto_asset_revision table that snapshot the crypto_asset table, in the
+// order they are selected.
+var revisionColumns = []string{"name", "symbol", "description", "icoAmount", "blockReward", "fundingStatus",
+	"foundedDate", "coinType", "website", "deletedAt", "deletedBy"}
+
+// queryer is implemented by both SQL connections and SQL transactions.
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// History returns every revision of the crypto asset with the given id, oldest first. An UnknownIDError is returned if
+// there is no crypto asset with the given id.
+func (s *SQLite) History(id int) ([]*models.Revision, error) {
+	revisions, err := selectRevisions(s.connection, "r.cryptoAssetId = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) == 0 {
+		return nil, NewUnknownIDError(id)
+	}
+
+	return revisions, nil
+}
+
+// Revision returns the revision of the crypto asset with the given id that has the given number. An
+// UnknownRevisionError is returned if there is no such revision.
+func (s *SQLite) Revision(id, revision int) (*models.Revision, error) {
+	revisions, err := selectRevisions(s.connection, "r.cryptoAssetId = ? AND r.revision = ?", id, revision)
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) == 0 {
+		return nil, NewUnknownRevisionError(id, revision)
+	}
+
+	return revisions[0], nil
+}
+
+// recordRevision records the current state of the crypto asset with the given id as a new revision as part of a SQL
+// transaction and sets the version of the crypto asset to the number of the new revision. It must be called after every
+// change to a crypto asset. Nothing is recorded if the crypto asset has not changed since its last revision.
+func recordRevision(transaction *sql.Tx, id int, actor, action string) error {
+	return recordRevisionWithChanges(transaction, id, actor, action, nil)
+}
+
+// recordRevisionWithChanges records a revision like recordRevision, with the given changes, which the snapshot does not
+// hold, added to its diff. A revision is recorded if there are any such changes, even if the snapshot is unchanged.
+func recordRevisionWithChanges(transaction *sql.Tx, id int, actor, action string,
+	changes map[string]*models.Change) error {
+	current, err := getCryptoAsset(transaction, id)
+	if err != nil {
+		return err
+	}
+
+	// Compare the crypto asset to its last revision, if it has one.
+	var (
+		previous       *models.CryptoAsset
+		revisionNumber = 1
+	)
+	lastRevisions, err := selectRevisions(transaction, "r.cryptoAssetId = ? AND r.revision = (SELECT max(revision) "+
+		"FROM crypto_asset_revision WHERE cryptoAssetId = r.cryptoAssetId)", id)
+	if err != nil {
+		return err
+	}
+	if len(lastRevisions) > 0 {
+		previous = lastRevisions[0].Snapshot
+		revisionNumber = lastRevisions[0].Revision + 1
+	}
+
+	diff := models.NewDiff(previous, current)
+	for field, change := range changes {
+		diff[field] = change
+	}
+	if len(diff) == 0 {
+		return nil
+	}
+	diffJSON, err := json.Marshal(diff)
+	if err != nil {
+		return err
+	}
+
+	// Insert the revision and its team.
+	result, err := transaction.Exec("INSERT INTO crypto_asset_revision(cryptoAssetId, revision, createdAt, actor, "+
+		"action, diff, name, symbol, description, icoAmount, blockReward, fundingStatus, foundedDate, coinType, website, "+
+		"deletedAt, deletedBy) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);", id, revisionNumber,
+		time.Now().UTC().Format(time.RFC3339), actor, action, string(diffJSON), current.Name, current.Symbol,
+		current.Description, current.ICOAmount, current.BlockReward, current.FundingStatus, current.FoundedDate,
+		current.CoinType, current.Website, current.DeletedAt, current.DeletedBy)
+	if err != nil {
+		return err
+	}
+
+	revisionID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	for _, teamMember := range current.Team {
+		_, err = transaction.Exec("INSERT INTO team_member_revision(revisionId, name) VALUES(?, ?);", revisionID,
+			teamMember)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The version of a crypto asset is the number of its latest revision.
+	_, err = transaction.Exec("UPDATE crypto_asset SET version = ? WHERE id = ?;", revisionNumber, id)
+	return err
+}
+
+// selectRevisions returns the revisions matching the condition, which is written against the crypto_asset_revision
+// table aliased as r, ordered by crypto asset and then oldest first.
+func selectRevisions(q queryer, condition string, args ...interface{}) ([]*models.Revision, error) {
+	rows, err := q.Query(fmt.Sprintf("SELECT r.id, r.cryptoAssetId, r.revision, r.createdAt, r.actor, r.action, "+
+		"r.diff, %s, team_member_revision.name FROM crypto_asset_revision r LEFT JOIN team_member_revision ON "+
+		"r.id = team_member_revision.revisionId WHERE %s ORDER BY r.cryptoAssetId, r.revision, "+
+		"team_member_revision.rowid;", createRevisionColumnList(), condition), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Like scanCryptoAssets, there is one row per team member of each revision, so the rows are collapsed by revision id.
+	var revisions []*models.Revision
+	revisionMap := make(map[int64]*models.Revision)
+	for rows.Next() {
+		var (
+			revisionID    int64
+			cryptoAssetID int
+			teamMember    *string
+			diffJSON      string
+		)
+		revision := &models.Revision{Snapshot: &models.CryptoAsset{Team: []string{}}}
+		destinations := []interface{}{&revisionID, &cryptoAssetID, &revision.Revision, &revision.CreatedAt,
+			&revision.Actor, &revision.Action, &diffJSON}
+		for _, column := range revisionColumns {
+			destinations = append(destinations, scanDestination(revision.Snapshot, column))
+		}
+		destinations = append(destinations, &teamMember)
+		if err = rows.Scan(destinations...); err != nil {
+			return nil, err
+		}
+
+		if found, ok := revisionMap[revisionID]; ok {
+			revision = found
+		} else {
+			cryptoAssetIDString := strconv.Itoa(cryptoAssetID)
+			revision.Snapshot.ID = &cryptoAssetIDString
+			if err = json.Unmarshal([]byte(diffJSON), &revision.Diff); err != nil {
+				return nil, err
+			}
+			revisionMap[revisionID] = revision
+			revisions = append(revisions, revision)
+		}
+		if teamMember != nil {
+			revision.Snapshot.Team = append(revision.Snapshot.Team, *teamMember)
+		}
+	}
+
+	return revisions, rows.Err()
+}
+
+// createRevisionColumnList creates the list of snapshot columns selected from the crypto_asset_revision table.
+func createRevisionColumnList() string {
+	return "r." + strings.Join(revisionColumns, ", r.")
+}
+
+// createHistoricalSource creates a subquery that stands in for the crypto_asset table as it was at the given time. It
+// has a row for every crypto asset that existed at that time, holding the values of its latest revision up to then,
+// and additionally selects the id of that revision so that the team can be joined from the team_member_revision
+// table. Its only argument is the time.
+func createHistoricalSource() string {
+	return fmt.Sprintf("(SELECT r.id AS revisionId, r.cryptoAssetId AS id, %s FROM crypto_asset_revision r "+
+		"WHERE r.revision = (SELECT max(revision) FROM crypto_asset_revision WHERE cryptoAssetId = r.cryptoAssetId AND "+
+		"createdAt <= ?))", createRevisionColumnList())
+}
@@ -0,0 +1,28 @@
+package database
+
+import "database/sql"
+
+// The crypto_asset_search table is an FTS5 full-text index over the name, symbol, description and team of every crypto
+// asset. The rowid of each row is the id of the crypto asset it indexes. The team members of a crypto asset are indexed
+// as a single comma separated column. The table is created by a migration, and note that the SQLite driver must be
+// built with the fts5 build tag, which the Makefile passes, for the database to be opened at all.
+
+// indexCryptoAsset replaces the crypto asset with the given id in the full-text index with its current name, symbol,
+// description and team as part of a SQL transaction. It must be called after every insert or update.
+func indexCryptoAsset(transaction *sql.Tx, id int) error {
+	if err := unindexCryptoAsset(transaction, id); err != nil {
+		return err
+	}
+
+	_, err := transaction.Exec("INSERT INTO crypto_asset_search(rowid, name, symbol, description, team) "+
+		"SELECT id, name, symbol, description, (SELECT group_concat(name, ', ') FROM "+
+		"team_member_name WHERE cryptoAssetId = ca.id) FROM crypto_asset ca WHERE id = ?;", id)
+	return err
+}
+
+// unindexCryptoAsset removes the crypto asset with the given id from the full-text index as part of a SQL transaction.
+// It must be called after every delete.
+func unindexCryptoAsset(transaction *sql.Tx, id int) error {
+	_, err := transaction.Exec("DELETE FROM crypto_asset_search WHERE rowid = ?;", id)
+	return err
+}
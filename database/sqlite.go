@@ -5,18 +5,26 @@ import (
 	"database/sql"
 	//"errors"
 	"fmt"
-	"os"
 	"strconv"
+	"time"
 	//"strings"
 
 	"github.com/mattn/go-sqlite3"
 	"github.com/paddyquinn/messari/database/models"
+	"github.com/paddyquinn/messari/util"
+	"sort"
 	"strings"
 )
 
 const (
-	dbFile      = "database/data/sqlite"
+	// InMemoryPath is the path that opens a database that only lives in memory rather than in a file. Everything in it
+	// is lost when it is closed, which makes it useful for tests.
+	InMemoryPath = ":memory:"
+
 	emptyString = ""
+
+	// maxRune is the largest possible rune. Every string starting with a prefix sorts before the prefix followed by it.
+	maxRune = "\U0010FFFF"
 )
 
 // SQLite is an implementation of the database interface to connect to a SQLite database.
@@ -24,55 +32,241 @@ type SQLite struct {
 	connection *sql.DB
 }
 
-// NewSQLite creates a new SQLite database connection. If the SQLite file does not exist, it is created and the
-// crypto_asset and team_member tables are created.
-func NewSQLite() (*SQLite, error) {
+// NewSQLite creates a new SQLite database connection to the file at the given path. If the SQLite file does not exist,
+// it is created. The path ":memory:" creates a database that only lives in memory for as long as the connection is
+// open. Either way, the database schema is brought up to date before the connection is returned.
+func NewSQLite(path string) (*SQLite, error) {
 	// Open a database connection to a sqlite db file with foreign keys enabled. Note: not all sqlite binaries support
 	// foreign keys.
-	conn, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_foreign_keys=1", dbFile))
+	conn, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_foreign_keys=1", path))
 	if err != nil {
 		return nil, err
 	}
 
-	// Create tables if the database file did not previously exist.
-	if _, err = os.Stat(dbFile); os.IsNotExist(err) {
-		_, err = conn.Exec("CREATE TABLE crypto_asset(id INTEGER PRIMARY KEY, name TEXT NOT NULL, " +
-			"symbol TEXT UNIQUE NOT NULL, description TEXT NOT NULL, icoAmount REAL NOT NULL, blockReward REAL NOT NULL, " +
-			"fundingStatus TEXT NOT NULL, foundedDate TEXT NOT NULL, coinType TEXT NOT NULL, website TEXT NOT NULL);")
-		if err != nil {
-			// TODO: what if this errors?
-			os.Remove(dbFile)
-			return nil, err
+	// Every connection to an in-memory database gets its own empty database, so only ever open one. It is kept open
+	// for as long as the database is in use.
+	if path == InMemoryPath {
+		conn.SetMaxOpenConns(1)
+		conn.SetMaxIdleConns(1)
+	}
+
+	// Bring the database schema up to date, creating it if the database file did not previously exist.
+	if err = migrate(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &SQLite{connection: conn}, nil
+}
+
+// Autocomplete returns up to limit live crypto assets whose name or symbol start with the prefix, which must be
+// normalized. Crypto assets whose symbol is exactly the prefix come first, followed by the rest of the prefix matches
+// with the shortest names first. If there are fewer than limit prefix matches, they are followed by the crypto assets
+// whose name or symbol is within a small number of edits of starting with the prefix, closest first.
+func (s *SQLite) Autocomplete(prefix string, limit int) ([]*models.Suggestion, error) {
+	// Find the prefix matches. The range comparisons, unlike LIKE, can use the indexes on the name and symbol columns.
+	rows, err := s.connection.Query("SELECT id, name, symbol FROM crypto_asset WHERE deletedAt IS NULL AND "+
+		"((name >= ? AND name < ?) OR (symbol >= ? AND symbol < ?)) ORDER BY symbol = ? DESC, length(name), name, id "+
+		"LIMIT ?;", prefix, prefix+maxRune, prefix, prefix+maxRune, prefix, limit)
+	if err != nil {
+		return nil, err
+	}
+	suggestions, err := scanSuggestions(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only look for near matches if there is room for them and the prefix is long enough for a near match to mean
+	// something.
+	maxDistance := maxEditDistance(prefix)
+	if len(suggestions) >= limit || maxDistance == 0 {
+		return suggestions, nil
+	}
+
+	// Measure how close every other crypto asset comes to starting with the prefix.
+	rows, err = s.connection.Query("SELECT id, name, symbol FROM crypto_asset WHERE deletedAt IS NULL;")
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := scanSuggestions(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make(map[string]bool)
+	for _, suggestion := range suggestions {
+		matched[suggestion.ID] = true
+	}
+
+	var nearMatches []*models.Suggestion
+	for _, candidate := range candidates {
+		if matched[candidate.ID] {
+			continue
 		}
 
-		_, err = conn.Exec("CREATE TABLE team_member(cryptoAssetId INTEGER NOT NULL, name TEXT NOT NULL, " +
-			"FOREIGN KEY(cryptoAssetId) REFERENCES crypto_asset(id));")
-		if err != nil {
-			// TODO: what if this errors?
-			os.Remove(dbFile)
-			return nil, err
+		candidate.Distance = util.PrefixDistance(prefix, candidate.Name, maxDistance)
+		if symbolDistance := util.PrefixDistance(prefix, candidate.Symbol, maxDistance); symbolDistance <
+			candidate.Distance {
+
+			candidate.Distance = symbolDistance
+		}
+		if candidate.Distance <= maxDistance {
+			nearMatches = append(nearMatches, candidate)
 		}
 	}
 
-	return &SQLite{connection: conn}, nil
+	// Sort the near matches closest first, breaking ties the same way as the prefix matches.
+	sort.Slice(nearMatches, func(i, j int) bool {
+		if nearMatches[i].Distance != nearMatches[j].Distance {
+			return nearMatches[i].Distance < nearMatches[j].Distance
+		}
+		if len(nearMatches[i].Name) != len(nearMatches[j].Name) {
+			return len(nearMatches[i].Name) < len(nearMatches[j].Name)
+		}
+		return nearMatches[i].Name < nearMatches[j].Name
+	})
+
+	suggestions = append(suggestions, nearMatches...)
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions, nil
+}
+
+// Delete soft deletes the crypto asset with the given id by marking when and by whom it was deleted. The crypto asset
+// and its team members are kept so that it can be restored, but it is left out of searches by default and its symbol
+// can be registered again. An UnknownIDError is returned if there is no live crypto asset with the given id. If a
+// version is given, a VersionMismatchError is returned unless the crypto asset is still at that version.
+func (s *SQLite) Delete(id int, version *int, actor string) error {
+	// Begin a SQL transaction to guarantee the deletion is recorded in the crypto asset's history.
+	transaction, err := s.connection.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err = checkVersion(transaction, id, version); err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	result, err := transaction.Exec("UPDATE crypto_asset SET deletedAt = ?, deletedBy = ? WHERE id = ? AND "+
+		"deletedAt IS NULL;", time.Now().UTC().Format(time.RFC3339), actor, id)
+	if err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	// If no rows were affected there is no live asset with the given id.
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		transaction.Rollback()
+		return err
+	}
+	if rowsAffected != 1 {
+		transaction.Rollback()
+		return NewUnknownIDError(id)
+	}
+
+	// Record the deletion in the crypto asset's history.
+	if err = recordRevision(transaction, id, actor, models.DeleteAction); err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	// Commit the transaction and return.
+	return transaction.Commit()
+}
+
+// Export calls each with every crypto asset matching the query, in the order described by the query, as it is read
+// from the database, so that every crypto asset can be exported without holding them all in memory. An export cannot
+// be paginated, so the query must not have a limit or a cursor. Reading stops at the first error returned by each,
+// which is returned.
+func (s *SQLite) Export(query *Query, each func(cryptoAsset *models.CryptoAsset) error) error {
+	if query.Limit != 0 || query.Cursor != emptyString {
+		return NewUnsupportedQueryError("an export cannot be paginated")
+	}
+
+	// Ensure the query can be run before building any SQL from it.
+	after, err := query.validate()
+	if err != nil {
+		return err
+	}
+
+	// Create and execute the select statement, then pass on each crypto asset as it is read.
+	stmt := _createSelectStatement(query, after)
+	rows, err := s.connection.Query(stmt.sql, stmt.args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	return scanEachCryptoAsset(rows, query.columns(), query.includesTeam(), each)
 }
 
-// Insert inserts the crypto asset into the crypto_asset table and its team members into the team_member table.
-func (s *SQLite) Insert(cryptoAsset *models.CryptoAsset) (string, error) {
+// Get retrieves a single crypto asset and its team members by id, whether or not it has been deleted. An UnknownIDError
+// is returned if there is no crypto asset with the given id.
+func (s *SQLite) Get(id int) (*models.CryptoAsset, error) {
+	return getCryptoAsset(s.connection, id)
+}
+
+// getCryptoAsset retrieves a single crypto asset and its team members by id using either a SQL connection or a SQL
+// transaction.
+func getCryptoAsset(q queryer, id int) (*models.CryptoAsset, error) {
+	// Unlike a search, the version of the crypto asset is read along with its fields.
+	versionedColumns := append(append([]string{}, columns...), versionColumn)
+	rows, err := q.Query(fmt.Sprintf("SELECT %s FROM crypto_asset ca LEFT JOIN team_member_name team_member ON "+
+		"ca.id = cryptoAssetId WHERE ca.id = ? ORDER BY team_member.position;", createColumnList(versionedColumns, true)),
+		id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cryptoAssets, err := scanCryptoAssets(rows, versionedColumns, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(cryptoAssets) == 0 {
+		return nil, NewUnknownIDError(id)
+	}
+
+	return cryptoAssets[0], nil
+}
+
+// Insert inserts the crypto asset into the crypto_asset table and its team members into the team_member table. The
+// actor is recorded as having created the crypto asset in its history.
+func (s *SQLite) Insert(cryptoAsset *models.CryptoAsset, actor string) (string, error) {
 	// Begin a SQL transaction to guarantee all inserts are executed or a rollback occurs.
 	transaction, err := s.connection.Begin()
 	if err != nil {
 		return emptyString, err
 	}
 
+	id, err := insertCryptoAsset(transaction, cryptoAsset, actor, models.CreateAction)
+	if err != nil {
+		transaction.Rollback()
+		return emptyString, err
+	}
+
+	// Commit the transaction.
+	err = transaction.Commit()
+	if err != nil {
+		return emptyString, err
+	}
+
+	// Return the new id of the inserted crypto asset.
+	return strconv.Itoa(id), nil
+}
+
+// insertCryptoAsset inserts a crypto asset as described by Insert as part of a SQL transaction, which the caller must
+// roll back if an error is returned, and records its creation in its history with the given action.
+func insertCryptoAsset(transaction *sql.Tx, cryptoAsset *models.CryptoAsset, actor, action string) (int, error) {
 	// Insert the crypto asset into the database.
 	result, err := transaction.Exec("INSERT INTO crypto_asset(name, symbol, description, icoAmount, blockReward, "+
 		"fundingStatus, foundedDate, coinType, website) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)",
 		cryptoAsset.Name, cryptoAsset.Symbol, cryptoAsset.Description, cryptoAsset.ICOAmount, cryptoAsset.BlockReward,
 		cryptoAsset.FundingStatus, cryptoAsset.FoundedDate, cryptoAsset.CoinType, cryptoAsset.Website)
 	if err != nil {
-		transaction.Rollback()
-
 		// If the error is a constraint error return an error type that we've created and the server package knows how to
 		// handle. Otherwise, just return the SQLite driver error. If the error returned from the SQLite driver is a null
 		// constraint error it will be of the form 'NOT NULL constraint failed: crypto_asset.name' so the offending field is
@@ -82,126 +276,198 @@ func (s *SQLite) Insert(cryptoAsset *models.CryptoAsset) (string, error) {
 		case sqlite3.ErrConstraintNotNull:
 			errString := sqliteErr.Error()
 			nullField := errString[strings.LastIndex(errString, ".")+1:]
-			return emptyString, NewNullConstraintError(nullField)
+			return -1, NewNullConstraintError(nullField)
 		case sqlite3.ErrConstraintUnique:
-			return emptyString, NewUniqueConstraintError(*cryptoAsset.Symbol)
+			return -1, NewUniqueConstraintError(*cryptoAsset.Symbol)
+		case sqlite3.ErrConstraintForeignKey:
+			return -1, unknownVocabularyValue(transaction, cryptoAsset, sqliteErr)
 		}
 
-		return emptyString, sqliteErr
+		return -1, sqliteErr
 	}
 
 	id64, err := result.LastInsertId()
 	if err != nil {
-		transaction.Rollback()
-		return emptyString, err
+		return -1, err
 	}
 	id := int(id64)
 
 	// Insert team members into the team_member table.
-	err = insertTeamMembers(transaction, id, cryptoAsset.Team)
+	err = insertTeamMembers(transaction, id, cryptoAsset.Team, nil)
 	if err != nil {
+		return -1, err
+	}
+
+	// Add the crypto asset to the full-text search index.
+	if err = indexCryptoAsset(transaction, id); err != nil {
+		return -1, err
+	}
+
+	// Record the creation of the crypto asset as its first revision.
+	if err = recordRevision(transaction, id, actor, action); err != nil {
+		return -1, err
+	}
+
+	return id, nil
+}
+
+// Restore restores the deleted crypto asset with the given id and records the actor as having restored it in its
+// history. Restoring a crypto asset that is not deleted does nothing. An UnknownIDError is returned if there is no
+// crypto asset with the given id and a UniqueConstraintError is returned if its symbol has been registered again since
+// it was deleted. If a version is given, a VersionMismatchError is returned unless the crypto asset is still at that
+// version.
+func (s *SQLite) Restore(id int, version *int, actor string) error {
+	// Begin a SQL transaction so that the crypto asset cannot change between looking it up and restoring it.
+	transaction, err := s.connection.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err = checkVersion(transaction, id, version); err != nil {
 		transaction.Rollback()
-		return emptyString, err
+		return err
 	}
 
-	// Commit the transaction.
-	err = transaction.Commit()
+	var (
+		symbol    string
+		deletedAt *string
+	)
+	err = transaction.QueryRow("SELECT symbol, deletedAt FROM crypto_asset WHERE id = ?;", id).Scan(&symbol, &deletedAt)
 	if err != nil {
-		return emptyString, err
+		transaction.Rollback()
+		if err == sql.ErrNoRows {
+			return NewUnknownIDError(id)
+		}
+		return err
+	}
+	if deletedAt == nil {
+		return transaction.Rollback()
 	}
 
-	// Return the new id of the inserted crypto asset.
-	return strconv.Itoa(id), nil
+	_, err = transaction.Exec("UPDATE crypto_asset SET deletedAt = NULL, deletedBy = NULL WHERE id = ?;", id)
+	if err != nil {
+		transaction.Rollback()
+
+		// Another live crypto asset holding the symbol breaks the unique index on the symbol of live crypto assets.
+		if sqliteErr, ok := err.(sqlite3.Error); ok && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return NewUniqueConstraintError(symbol)
+		}
+		return err
+	}
+
+	// Record the restoration in the crypto asset's history.
+	if err = recordRevision(transaction, id, actor, models.RestoreAction); err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	// Commit the transaction and return.
+	return transaction.Commit()
 }
 
-// Select searches for crypto assets given the passed parameters.
-func (s *SQLite) Select(names, symbols, fundingStatuses, coinTypes []string, startDate,
-	endDate string) ([]*models.CryptoAsset, error) {
+// Select searches for crypto assets matching the query. The crypto assets are returned in the order described by the
+// query, along with a cursor pointing to the next page. The cursor is empty if there are no more pages.
+func (s *SQLite) Select(query *Query) ([]*models.CryptoAsset, string, error) {
+	// Ensure the query can be run before building any SQL from it.
+	after, err := query.validate()
+	if err != nil {
+		return nil, emptyString, err
+	}
 
 	// Create and execute the select statement.
-	stmt := _createSelectStatement(names, symbols, fundingStatuses, coinTypes, startDate, endDate)
+	stmt := _createSelectStatement(query, after)
 	rows, err := s.connection.Query(stmt.sql, stmt.args...)
 	if err != nil {
-		return nil, err
+		return nil, emptyString, err
 	}
 	defer rows.Close()
 
-	// Instantiate a map from id to crypto asset and then loop through each row. The LEFT JOIN causes there to be a one
-	// row per team member for each asset so the first time we add the crypto asset to the map and then each subsequent
-	// time we append the team member to the list of team member's for that already created asset.
-	cryptoAssetMap := make(map[int]*models.CryptoAsset)
-	for rows.Next() {
-		// Retrieve the values from the current row.
-		var (
-			id                                                                       int
-			name, symbol, description, fundingStatus, foundedDate, coinType, website string
-			icoAmount, blockReward                                                   float64
-			fkID                                                                     *int
-			teamMember                                                               *string
-		)
-		err := rows.Scan(&id, &name, &symbol, &description, &icoAmount, &blockReward, &fundingStatus, &foundedDate,
-			&coinType, &website, &fkID, &teamMember)
-		if err != nil {
-			return nil, err
-		}
-
-		// Find the given crypto asset by id. If it cannot be found, create it and put it in the map. Then add the team
-		// member from that row to the asset's list of team members given the team member is not null.
-		cryptoAsset, found := cryptoAssetMap[id]
-		if !found {
-			idString := strconv.Itoa(id)
-			cryptoAsset = &models.CryptoAsset{
-				ID:            &idString,
-				Name:          &name,
-				Symbol:        &symbol,
-				Description:   &description,
-				Team:          []string{},
-				ICOAmount:     &icoAmount,
-				BlockReward:   &blockReward,
-				FundingStatus: &fundingStatus,
-				FoundedDate:   &foundedDate,
-				CoinType:      &coinType,
-				Website:       &website,
-			}
-			cryptoAssetMap[id] = cryptoAsset
-		}
-		if teamMember != nil {
-			cryptoAsset.Team = append(cryptoAsset.Team, *teamMember)
-		}
+	cryptoAssets, err := scanCryptoAssets(rows, query.columns(), query.includesTeam())
+	if err != nil {
+		return nil, emptyString, err
 	}
 
-	// Translate the crypto asset map to an array.
-	cryptoAssets := make([]*models.CryptoAsset, len(cryptoAssetMap))
-	idx := 0
-	for _, cryptoAsset := range cryptoAssetMap {
-		cryptoAssets[idx] = cryptoAsset
-		idx++
+	// The select statement asks for one more crypto asset than the limit. If it was returned there is another page, so
+	// drop it and point the cursor at the last crypto asset on this page.
+	if query.Limit > 0 && len(cryptoAssets) > query.Limit {
+		cryptoAssets = cryptoAssets[:query.Limit]
+		return cryptoAssets, query.nextCursor(cryptoAssets[query.Limit-1]), nil
 	}
 
-	// Return the array of crypto assets.
-	return cryptoAssets, nil
+	return cryptoAssets, emptyString, nil
+}
+
+// Revert replaces every field and the team of the crypto asset with the given id with those of the passed snapshot,
+// which is taken from one of its revisions and must have been normalized. The actor is recorded as having reverted the
+// crypto asset in its history. Deleted crypto assets cannot be reverted.
+func (s *SQLite) Revert(id int, cryptoAsset *models.CryptoAsset, actor string) error {
+	return s.update(id, cryptoAsset, actor, models.RevertAction)
 }
 
 // Update updates a crypto asset with the fields it contains. If the passed crypto asset has a team array then all of
-// the old team members are deleted from the team_member table and all of the new members are inserted.
-func (s *SQLite) Update(id int, cryptoAsset *models.CryptoAsset) error {
+// the old team members are deleted from the team_member table and all of the new members are inserted, with the people
+// who stay on the team keeping their roles and tenure. Deleted crypto assets cannot be updated. The actor is recorded
+// as having made the update in the crypto asset's history. If the passed crypto asset has a version, a
+// VersionMismatchError is returned unless the crypto asset is still at that version.
+func (s *SQLite) Update(id int, cryptoAsset *models.CryptoAsset, actor string) error {
+	return s.update(id, cryptoAsset, actor, models.UpdateAction)
+}
+
+// update updates a crypto asset as described by Update and records the change in its history with the given action.
+func (s *SQLite) update(id int, cryptoAsset *models.CryptoAsset, actor, action string) error {
+	// Begin a SQL transaction to guarantee all updates and inserts are executed or a rollback occurs.
+	transaction, err := s.connection.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err = updateCryptoAsset(transaction, id, cryptoAsset, actor, action); err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	// Commit the transaction and return.
+	return transaction.Commit()
+}
+
+// updateCryptoAsset updates a crypto asset as described by Update as part of a SQL transaction, which the caller must
+// roll back if an error is returned.
+func updateCryptoAsset(transaction *sql.Tx, id int, cryptoAsset *models.CryptoAsset, actor, action string) error {
 	// Create the update statement. If there is nothing to update given the passed asset, return an empty update error.
 	updateCryptoAssetStatement := _createUpdateStatement(id, cryptoAsset)
 	if updateCryptoAssetStatement == nil && cryptoAsset.Team == nil {
 		return NewEmptyUpdateError()
 	}
 
-	// Begin a SQL transaction to guarantee all updates and inserts are executed or a rollback occurs.
-	transaction, err := s.connection.Begin()
+	// Make sure there is a live crypto asset to update, at the expected version if there is one.
+	var version int
+	err := transaction.QueryRow("SELECT version FROM crypto_asset WHERE id = ? AND deletedAt IS NULL;", id).Scan(
+		&version)
+	if err == sql.ErrNoRows {
+		return NewUnknownIDError(id)
+	}
 	if err != nil {
 		return err
 	}
+	if cryptoAsset.Version != nil && *cryptoAsset.Version != version {
+		return NewVersionMismatchError(id, *cryptoAsset.Version, version)
+	}
 
 	if updateCryptoAssetStatement != nil {
 		// Execute the update statement.
 		result, err := transaction.Exec(updateCryptoAssetStatement.sql, updateCryptoAssetStatement.args...)
 		if err != nil {
-			transaction.Rollback()
+			// Changing the symbol to one that another crypto asset already holds breaks the unique constraint on the
+			// symbol column, and changing the funding status or coin type to a value that has since been renamed breaks
+			// a foreign key. Both are user errors the server package knows how to handle.
+			if sqliteErr, ok := err.(sqlite3.Error); ok {
+				switch sqliteErr.ExtendedCode {
+				case sqlite3.ErrConstraintUnique:
+					return NewUniqueConstraintError(*cryptoAsset.Symbol)
+				case sqlite3.ErrConstraintForeignKey:
+					return unknownVocabularyValue(transaction, cryptoAsset, err)
+				}
+			}
 			return err
 		}
 
@@ -218,86 +484,339 @@ func (s *SQLite) Update(id int, cryptoAsset *models.CryptoAsset) error {
 	}
 
 	if cryptoAsset.Team != nil {
-		// Do not check the rows affected here because it is possible an asset has no team members.
-		_, err = transaction.Exec("DELETE FROM team_member WHERE cryptoAssetId = ?;", id)
+		// Replace the team members in the team_member table.
+		err = replaceTeamMembers(transaction, id, cryptoAsset.Team)
 		if err != nil {
-			transaction.Rollback()
 			return err
 		}
+	}
 
-		// Insert the team members into the team_member table.
-		err = insertTeamMembers(transaction, id, cryptoAsset.Team)
-		if err != nil {
-			transaction.Rollback()
-			return err
-		}
+	// Refresh the crypto asset in the full-text search index.
+	if err = indexCryptoAsset(transaction, id); err != nil {
+		return err
 	}
 
-	// Commit the transaction and return.
-	err = transaction.Commit()
+	// Record the update in the crypto asset's history.
+	return recordRevision(transaction, id, actor, action)
+}
+
+// checkVersion makes sure that the crypto asset with the given id is at the given version as part of a SQL transaction.
+// Any version passes the check if none is given. An UnknownIDError is returned if there is no crypto asset with the
+// given id and a VersionMismatchError is returned if it is at another version.
+func checkVersion(transaction *sql.Tx, id int, version *int) error {
+	if version == nil {
+		return nil
+	}
+
+	var current int
+	err := transaction.QueryRow("SELECT version FROM crypto_asset WHERE id = ?;", id).Scan(&current)
+	if err == sql.ErrNoRows {
+		return NewUnknownIDError(id)
+	}
 	if err != nil {
 		return err
 	}
+	if current != *version {
+		return NewVersionMismatchError(id, *version, current)
+	}
 	return nil
 }
 
+// SchemaVersion returns the version of the last migration applied to the database.
+func (s *SQLite) SchemaVersion() (int, error) {
+	return schemaVersion(s.connection)
+}
+
 // Close closes the connection to the SQLite database.
 func (s *SQLite) Close() {
 	s.connection.Close()
 }
 
+// scanCryptoAssets reads every row returned by a select statement on the crypto_asset table, optionally joined with
+// the team_member table, and collapses the rows into crypto assets as described by scanEachCryptoAsset. The crypto
+// assets are returned in the order they appear in the rows.
+func scanCryptoAssets(rows *sql.Rows, columns []string, withTeam bool) ([]*models.CryptoAsset, error) {
+	// An empty result is an empty array rather than null.
+	cryptoAssets := []*models.CryptoAsset{}
+	err := scanEachCryptoAsset(rows, columns, withTeam, func(cryptoAsset *models.CryptoAsset) error {
+		cryptoAssets = append(cryptoAssets, cryptoAsset)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cryptoAssets, nil
+}
+
+// scanEachCryptoAsset reads every row returned by a select statement on the crypto_asset table, optionally joined with
+// the team_member table, and calls each with every crypto asset as soon as all of its rows have been read. The columns
+// are the crypto_asset columns that were selected, in order, and must include the id. If the team was selected its
+// name is the final column of each row. The rows of each crypto asset must be next to each other, as they are when the
+// rows are ordered by id or by sort keys that fall back on it. Reading stops at the first error returned by each.
+func scanEachCryptoAsset(rows *sql.Rows, columns []string, withTeam bool,
+	each func(cryptoAsset *models.CryptoAsset) error) error {
+	// The LEFT JOIN causes there to be one row per team member for each crypto asset, so the first row of a crypto asset
+	// starts it and each subsequent row appends its team member to the crypto asset's list of team members. A crypto
+	// asset is complete once the row of the next one is read.
+	var cryptoAsset *models.CryptoAsset
+	for rows.Next() {
+		// Retrieve the values from the current row. Scanning into a pointer to a field of the crypto asset allocates the
+		// field's value.
+		row := &models.CryptoAsset{}
+		var teamMember *string
+		destinations := make([]interface{}, 0, len(columns)+1)
+		for _, column := range columns {
+			destinations = append(destinations, scanDestination(row, column))
+		}
+		if withTeam {
+			destinations = append(destinations, &teamMember)
+		}
+		if err := rows.Scan(destinations...); err != nil {
+			return err
+		}
+
+		// Start a new crypto asset if the row is the first of it, passing on the one before. Then add the team member
+		// from the row to the crypto asset's list of team members given the team member is not null.
+		if cryptoAsset == nil || *cryptoAsset.ID != *row.ID {
+			if cryptoAsset != nil {
+				if err := each(cryptoAsset); err != nil {
+					return err
+				}
+			}
+			cryptoAsset = row
+			if withTeam {
+				cryptoAsset.Team = []string{}
+			}
+		}
+		if teamMember != nil {
+			cryptoAsset.Team = append(cryptoAsset.Team, *teamMember)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Pass on the last crypto asset.
+	if cryptoAsset != nil {
+		return each(cryptoAsset)
+	}
+	return nil
+}
+
+// scanSuggestions reads every row returned by a select statement on the id, name and symbol columns of the
+// crypto_asset table into suggestions and closes the rows.
+func scanSuggestions(rows *sql.Rows) ([]*models.Suggestion, error) {
+	defer rows.Close()
+
+	suggestions := []*models.Suggestion{}
+	for rows.Next() {
+		suggestion := &models.Suggestion{}
+		if err := rows.Scan(&suggestion.ID, &suggestion.Name, &suggestion.Symbol); err != nil {
+			return nil, err
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+
+	return suggestions, rows.Err()
+}
+
+// maxEditDistance returns the number of edits a crypto asset's name or symbol can be from starting with the prefix and
+// still be suggested. Short prefixes only match exactly because almost everything is a few edits away from them.
+func maxEditDistance(prefix string) int {
+	switch length := len([]rune(prefix)); {
+	case length < 3:
+		return 0
+	case length < 6:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// scanDestination returns a pointer to the field of the crypto asset that the given column is scanned into.
+func scanDestination(cryptoAsset *models.CryptoAsset, column string) interface{} {
+	switch column {
+	case "name":
+		return &cryptoAsset.Name
+	case "symbol":
+		return &cryptoAsset.Symbol
+	case "description":
+		return &cryptoAsset.Description
+	case "icoAmount":
+		return &cryptoAsset.ICOAmount
+	case "blockReward":
+		return &cryptoAsset.BlockReward
+	case "fundingStatus":
+		return &cryptoAsset.FundingStatus
+	case "foundedDate":
+		return &cryptoAsset.FoundedDate
+	case "coinType":
+		return &cryptoAsset.CoinType
+	case "website":
+		return &cryptoAsset.Website
+	case "relevance":
+		return &cryptoAsset.Relevance
+	case "snippet":
+		return &cryptoAsset.Snippet
+	case "deletedAt":
+		return &cryptoAsset.DeletedAt
+	case "deletedBy":
+		return &cryptoAsset.DeletedBy
+	case versionColumn:
+		return &cryptoAsset.Version
+	}
+
+	return &cryptoAsset.ID
+}
+
 // statement represents a SQL statement and its arguments.
 type statement struct {
 	sql  string
 	args []interface{}
 }
 
-// _createSelectStatement creates a select statement that joins the crypto_asset and team_member tables and is used to
-// search the database. Note that this function starts with an underscore because it is idiomatic in Go to write test
-// functions as TestFunctionName. However, since this is a private function. TestcreateSelectStatement looked awkward
-// so an underscore was introduced to make the function name clear.
-func _createSelectStatement(names, symbols, fundingStatuses, coinTypes []string, startDate, endDate string) *statement {
+// _createSelectStatement creates a select statement for the query that joins the crypto_asset and team_member tables
+// and is used to search the database. The crypto assets are filtered and paginated in a subquery before the join so
+// that the limit applies to crypto assets rather than to team members. Rows are ordered by the query's sort keys with
+// ties broken by id so the order is the same on every call. The team_member table is only joined if the query selects
+// the team. Note that this function starts with an underscore because it is idiomatic in Go to write test functions as
+// TestFunctionName. However, since this is a private function. TestcreateSelectStatement looked awkward so an
+// underscore was introduced to make the function name clear.
+func _createSelectStatement(query *Query, after *cursor) *statement {
 	var args []interface{}
 	isFirstClause := true
-	sqlBuffer := bytes.NewBufferString("SELECT * FROM crypto_asset ca LEFT JOIN team_member ON id = cryptoAssetId")
+	withTeam := query.includesTeam()
+	sqlBuffer := bytes.NewBufferString(fmt.Sprintf("SELECT %s FROM (SELECT * FROM ",
+		createColumnList(query.columns(), withTeam)))
+
+	// A full-text search selects from the crypto assets matching the search text, along with their relevance and a
+	// highlighted snippet, rather than from the whole crypto_asset table. The relevance is the negated BM25 score so
+	// that higher is better. A search as of a point in time selects from the crypto assets as they were then.
+	switch {
+	case query.isFullText():
+		sqlBuffer.WriteString("(SELECT ca.*, -bm25(crypto_asset_search) AS relevance, " +
+			"snippet(crypto_asset_search, -1, '<b>', '</b>', '...', 16) AS snippet FROM crypto_asset ca " +
+			"JOIN crypto_asset_search ON crypto_asset_search.rowid = ca.id WHERE crypto_asset_search MATCH ?)")
+		args = append(args, query.matchExpression())
+	case query.AsOf != emptyString:
+		sqlBuffer.WriteString(createHistoricalSource())
+		args = append(args, query.AsOf)
+	default:
+		sqlBuffer.WriteString("crypto_asset")
+	}
+	sqlBuffer.WriteString(" ca")
+
+	// Deleted crypto assets are left out unless they are asked for.
+	if !query.IncludeDeleted {
+		createClauseKeyword(sqlBuffer, &isFirstClause)
+		sqlBuffer.WriteString(" ca.deletedAt IS NULL")
+	}
 
-	if ok := createConditionalClause(sqlBuffer, &isFirstClause, len(names), "ca.name"); ok {
-		for _, name := range names {
+	if ok := createConditionalClause(sqlBuffer, &isFirstClause, len(query.Names), "ca.name"); ok {
+		for _, name := range query.Names {
 			args = append(args, name)
 		}
 	}
 
-	if ok := createConditionalClause(sqlBuffer, &isFirstClause, len(symbols), "symbol"); ok {
-		for _, symbol := range symbols {
+	if ok := createConditionalClause(sqlBuffer, &isFirstClause, len(query.Symbols), "symbol"); ok {
+		for _, symbol := range query.Symbols {
 			args = append(args, symbol)
 		}
 	}
 
-	if ok := createConditionalClause(sqlBuffer, &isFirstClause, len(fundingStatuses), "fundingStatus"); ok {
-		for _, fundingStatus := range fundingStatuses {
+	if ok := createConditionalClause(sqlBuffer, &isFirstClause, len(query.FundingStatuses), "fundingStatus"); ok {
+		for _, fundingStatus := range query.FundingStatuses {
 			args = append(args, fundingStatus)
 		}
 	}
 
-	if ok := createConditionalClause(sqlBuffer, &isFirstClause, len(coinTypes), "coinType"); ok {
-		for _, coinType := range coinTypes {
+	if ok := createConditionalClause(sqlBuffer, &isFirstClause, len(query.CoinTypes), "coinType"); ok {
+		for _, coinType := range query.CoinTypes {
 			args = append(args, coinType)
 		}
 	}
 
-	if ok := createDateClause(sqlBuffer, &isFirstClause, startDate, ">="); ok {
-		args = append(args, startDate)
+	if ok := createTeamMemberClause(sqlBuffer, &isFirstClause, len(query.TeamMembers), query.AsOf != emptyString); ok {
+		for _, teamMember := range query.TeamMembers {
+			args = append(args, teamMember)
+		}
+	}
+
+	if ok := createDateClause(sqlBuffer, &isFirstClause, query.StartDate, ">="); ok {
+		args = append(args, query.StartDate)
+	}
+
+	if ok := createDateClause(sqlBuffer, &isFirstClause, query.EndDate, "<="); ok {
+		args = append(args, query.EndDate)
 	}
 
-	if ok := createDateClause(sqlBuffer, &isFirstClause, endDate, "<="); ok {
-		args = append(args, endDate)
+	if ok := createRangeClause(sqlBuffer, &isFirstClause, query.MinICOAmount == nil, "icoAmount", ">="); ok {
+		args = append(args, *query.MinICOAmount)
 	}
 
-	sqlBuffer.WriteRune(';')
+	if ok := createRangeClause(sqlBuffer, &isFirstClause, query.MaxICOAmount == nil, "icoAmount", "<="); ok {
+		args = append(args, *query.MaxICOAmount)
+	}
+
+	if ok := createRangeClause(sqlBuffer, &isFirstClause, query.MinBlockReward == nil, "blockReward", ">="); ok {
+		args = append(args, *query.MinBlockReward)
+	}
+
+	if ok := createRangeClause(sqlBuffer, &isFirstClause, query.MaxBlockReward == nil, "blockReward", "<="); ok {
+		args = append(args, *query.MaxBlockReward)
+	}
+
+	// Block rewards cannot be negative, so a crypto asset without a block reward has a block reward of exactly zero.
+	if query.HasBlockReward != nil {
+		comparator := "="
+		if *query.HasBlockReward {
+			comparator = ">"
+		}
+		createRangeClause(sqlBuffer, &isFirstClause, false, "blockReward", comparator)
+		args = append(args, 0)
+	}
+
+	args = append(args, createCursorClause(sqlBuffer, &isFirstClause, query.sort(), after)...)
+
+	orderBy := createOrderBy(query.sort())
+	sqlBuffer.WriteString(fmt.Sprintf(" ORDER BY %s", orderBy))
+
+	// Ask for one more crypto asset than the limit to find out whether there is another page.
+	if query.Limit > 0 {
+		sqlBuffer.WriteString(" LIMIT ?")
+		args = append(args, query.Limit+1)
+	}
+
+	// The team of a crypto asset as of a point in time is the team of the revision it was selected from, and otherwise
+	// it is read from the team_member_name view. Either is aliased so that the team member's name is selected the same
+	// way either way.
+	sqlBuffer.WriteString(") ca")
+	if withTeam && query.AsOf != emptyString {
+		sqlBuffer.WriteString(" LEFT JOIN team_member_revision team_member ON ca.revisionId = team_member.revisionId")
+		orderBy += ", team_member.rowid"
+	} else if withTeam {
+		sqlBuffer.WriteString(" LEFT JOIN team_member_name team_member ON ca.id = cryptoAssetId")
+		orderBy += ", team_member.position"
+	}
+	sqlBuffer.WriteString(fmt.Sprintf(" ORDER BY %s;", orderBy))
 
 	return &statement{sql: sqlBuffer.String(), args: args}
 }
 
+// createColumnList creates the list of columns selected from the crypto_asset table, followed by the team member's
+// name if the team is selected.
+func createColumnList(columns []string, withTeam bool) string {
+	columnList := make([]string, 0, len(columns)+1)
+	for _, column := range columns {
+		columnList = append(columnList, fmt.Sprintf("ca.%s", column))
+	}
+	if withTeam {
+		columnList = append(columnList, "team_member.name")
+	}
+	return strings.Join(columnList, ", ")
+}
+
 // createClauseKeyword determines whether this is the first conditional clause in the select statement and writes
 // 'WHERE' if so and 'AND' if not.
 func createClauseKeyword(sqlBuffer *bytes.Buffer, isFirstClause *bool) {
@@ -332,6 +851,76 @@ func createConditionalClause(sqlBuffer *bytes.Buffer, isFirstClause *bool, numVa
 	return false
 }
 
+// createTeamMemberClause writes a conditional clause to the SQL statement that matches the crypto assets with any of
+// the team members, ignoring case, if there are team members to compare to and returns true, and returns false
+// otherwise. Only the crypto assets are filtered, so the whole team of each is still joined. The team of a crypto asset
+// as of a point in time is the team of the revision it was selected from.
+func createTeamMemberClause(sqlBuffer *bytes.Buffer, isFirstClause *bool, numValues int, historical bool) bool {
+	if numValues == 0 {
+		return false
+	}
+
+	createClauseKeyword(sqlBuffer, isFirstClause)
+	if historical {
+		sqlBuffer.WriteString(" ca.revisionId IN (SELECT revisionId FROM team_member_revision")
+	} else {
+		sqlBuffer.WriteString(" ca.id IN (SELECT cryptoAssetId FROM team_member_name")
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", numValues), ", ")
+	sqlBuffer.WriteString(fmt.Sprintf(" WHERE name COLLATE NOCASE IN (%s))", placeholders))
+
+	return true
+}
+
+// createCursorClause writes a conditional clause that skips every crypto asset up to and including the one the
+// cursor points to, and returns the clause's arguments. Nothing is written if there is no cursor. A crypto asset comes
+// after the cursor if it is past the cursor on the first sort key, or ties on the first sort key and is past the cursor
+// on the second, and so on, finishing with the id.
+func createCursorClause(sqlBuffer *bytes.Buffer, isFirstClause *bool, sort []SortKey, after *cursor) []interface{} {
+	if after == nil {
+		return nil
+	}
+
+	var (
+		args       []interface{}
+		conditions []string
+		ties       []string
+		tieArgs    []interface{}
+	)
+	for idx, key := range sort {
+		comparator := ">"
+		if key.Descending {
+			comparator = "<"
+		}
+
+		condition := strings.Join(append(ties, fmt.Sprintf("ca.%s %s ?", key.Field, comparator)), " AND ")
+		conditions = append(conditions, condition)
+		args = append(append(args, tieArgs...), after.Values[idx])
+
+		ties = append(ties, fmt.Sprintf("ca.%s = ?", key.Field))
+		tieArgs = append(tieArgs, after.Values[idx])
+	}
+	conditions = append(conditions, strings.Join(append(ties, "ca.id > ?"), " AND "))
+	args = append(append(args, tieArgs...), after.ID)
+
+	createClauseKeyword(sqlBuffer, isFirstClause)
+	sqlBuffer.WriteString(fmt.Sprintf(" ((%s))", strings.Join(conditions, ") OR (")))
+	return args
+}
+
+// createOrderBy creates the columns of an ORDER BY clause for the sort keys. Ties are broken by ascending id.
+func createOrderBy(sort []SortKey) string {
+	orderBy := make([]string, 0, len(sort)+1)
+	for _, key := range sort {
+		direction := "ASC"
+		if key.Descending {
+			direction = "DESC"
+		}
+		orderBy = append(orderBy, fmt.Sprintf("ca.%s %s", key.Field, direction))
+	}
+	return strings.Join(append(orderBy, "ca.id ASC"), ", ")
+}
+
 // createDateClause writes a conditional clause comparing dates to the SQL statement if there is a date passed with
 // non-zero length and returns true, and returns false otherwise.
 // TODO: will this comparator work??
@@ -346,6 +935,19 @@ func createDateClause(sqlBuffer *bytes.Buffer, isFirstClause *bool, date, compar
 	return false
 }
 
+// createRangeClause writes a conditional clause comparing a numeric column to a value to the SQL statement if the
+// value is non-nil and returns true, and returns false otherwise.
+func createRangeClause(sqlBuffer *bytes.Buffer, isFirstClause *bool, isNil bool, column, comparator string) bool {
+	if !isNil {
+		createClauseKeyword(sqlBuffer, isFirstClause)
+		sqlBuffer.WriteString(fmt.Sprintf(" %s %s ?", column, comparator))
+
+		return true
+	}
+
+	return false
+}
+
 // _createUpdateStatement creates an update statement that updates a crypto asset in the database by id. Note that this
 // function starts with an underscore because it is idiomatic in Go to write test functions as TestFunctionName.
 // However, since this is a private function. TestcreateUpdateStatement looked awkward so an underscore was introduced
@@ -420,9 +1022,11 @@ func createSetClause(sqlBuffer *bytes.Buffer, setClauseWritten *bool, isNil bool
 }
 
 // Insert team members inserts each team member from the array into the team_member table as part of a SQL transaction.
-func insertTeamMembers(transaction *sql.Tx, id int, team []string) error {
+// Each name is linked to a person by linkPerson. A person with a membership in the map is given its role and tenure.
+func insertTeamMembers(transaction *sql.Tx, id int, team []string, memberships map[int]*models.Membership) error {
 	// Prepare the insert into the relation table which will be used multiple times.
-	stmt, err := transaction.Prepare("INSERT INTO team_member(cryptoAssetId, name) VALUES(?, ?)")
+	stmt, err := transaction.Prepare("INSERT INTO team_member(cryptoAssetId, personId, role, startDate, endDate) " +
+		"VALUES(?, ?, ?, ?, ?)")
 	if err != nil {
 		return err
 	}
@@ -431,7 +1035,16 @@ func insertTeamMembers(transaction *sql.Tx, id int, team []string) error {
 	// Insert each team member into the relation table. If it is a foreign key constraint error an unknown id error is
 	// returned because the id could not be found in the crypto_asset table.
 	for _, teamMember := range team {
-		_, err := stmt.Exec(id, teamMember)
+		personID, err := linkPerson(transaction, teamMember)
+		if err != nil {
+			return err
+		}
+
+		membership, ok := memberships[personID]
+		if !ok {
+			membership = &models.Membership{}
+		}
+		_, err = stmt.Exec(id, personID, membership.Role, membership.StartDate, membership.EndDate)
 		if err != nil {
 			sqliteError := err.(sqlite3.Error)
 			if sqliteError.ExtendedCode == sqlite3.ErrConstraintForeignKey {
@@ -443,3 +1056,39 @@ func insertTeamMembers(transaction *sql.Tx, id int, team []string) error {
 
 	return nil
 }
+
+// replaceTeamMembers deletes every team member of the crypto asset with the given id from the team_member table and
+// inserts the new team in its place as part of a SQL transaction. The people who stay on the team keep their roles and
+// tenure.
+func replaceTeamMembers(transaction *sql.Tx, id int, team []string) error {
+	rows, err := transaction.Query("SELECT personId, role, startDate, endDate FROM team_member WHERE cryptoAssetId = ? "+
+		"ORDER BY rowid;", id)
+	if err != nil {
+		return err
+	}
+
+	memberships := map[int]*models.Membership{}
+	for rows.Next() {
+		var personID int
+		membership := &models.Membership{}
+		if err = rows.Scan(&personID, &membership.Role, &membership.StartDate, &membership.EndDate); err != nil {
+			rows.Close()
+			return err
+		}
+		if _, ok := memberships[personID]; !ok {
+			memberships[personID] = membership
+		}
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	// Do not check the rows affected here because it is possible an asset has no team members.
+	_, err = transaction.Exec("DELETE FROM team_member WHERE cryptoAssetId = ?;", id)
+	if err != nil {
+		return err
+	}
+
+	return insertTeamMembers(transaction, id, team, memberships)
+}
@@ -1,6 +1,8 @@
 package database
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/paddyquinn/messari/database/models"
@@ -11,13 +13,22 @@ func Test_createSelectStatement(t *testing.T) {
 		"Platform", "Storage", "2009-01-03", "2018-01-18"}
 	expectedLen := len(expectedArgs)
 
-	expectedSQLString := "SELECT * FROM crypto_asset ca LEFT JOIN team_member ON id = cryptoAssetId WHERE " +
-		"(ca.name = ? OR ca.name = ? OR ca.name = ?) AND (symbol = ? OR symbol = ? OR symbol = ?) AND " +
+	expectedSQLString := "SELECT ca.id, ca.name, ca.symbol, ca.description, ca.icoAmount, ca.blockReward, " +
+		"ca.fundingStatus, ca.foundedDate, ca.coinType, ca.website, ca.deletedAt, ca.deletedBy, team_member.name FROM " +
+		"(SELECT * FROM crypto_asset ca WHERE ca.deletedAt IS NULL AND (ca.name = ? OR ca.name = ? OR ca.name = ?) AND " +
+		"(symbol = ? OR symbol = ? OR symbol = ?) AND " +
 		"(fundingStatus = ? OR fundingStatus = ?) AND (coinType = ? OR coinType = ? OR coinType = ?) AND " +
-		"foundedDate >= ? AND foundedDate <= ?;"
+		"foundedDate >= ? AND foundedDate <= ? ORDER BY ca.id ASC) ca LEFT JOIN team_member_name team_member ON " +
+		"ca.id = cryptoAssetId ORDER BY ca.id ASC, team_member.position;"
 
-	stmt := _createSelectStatement(expectedArgs[0:3], expectedArgs[3:6], expectedArgs[6:8], expectedArgs[8:11],
-		expectedArgs[11], expectedArgs[12])
+	stmt := _createSelectStatement(&Query{
+		Names:           expectedArgs[0:3],
+		Symbols:         expectedArgs[3:6],
+		FundingStatuses: expectedArgs[6:8],
+		CoinTypes:       expectedArgs[8:11],
+		StartDate:       expectedArgs[11],
+		EndDate:         expectedArgs[12],
+	}, nil)
 
 	if stmt.sql != expectedSQLString {
 		t.Fatalf("unexpected SQL string\n\nexpected: %s\nactual: %s", expectedSQLString, stmt.sql)
@@ -40,6 +51,152 @@ func Test_createSelectStatement(t *testing.T) {
 	}
 }
 
+func Test_createSelectStatementPaginated(t *testing.T) {
+	// Create a query sorted by descending ICO amount and then name that starts after the crypto asset with id 4 and only
+	// returns symbols.
+	query := &Query{
+		Symbols: []string{"BTC"},
+		Sort:    []SortKey{{Field: "icoAmount", Descending: true}, {Field: "name"}},
+		Limit:   2,
+		Fields:  []string{"symbol"},
+	}
+	query.Cursor = query.nextCursor(&models.CryptoAsset{
+		ID:        stringPointer("4"),
+		Name:      stringPointer("bitcoin"),
+		ICOAmount: floatPointer(100),
+	})
+	after, err := query.validate()
+	if err != nil {
+		t.Fatalf("unexpected error validating query: %s", err.Error())
+	}
+
+	expectedSQLString := "SELECT ca.id, ca.name, ca.symbol, ca.icoAmount FROM (SELECT * FROM crypto_asset ca WHERE " +
+		"ca.deletedAt IS NULL AND (symbol = ?) AND ((ca.icoAmount < ?) OR (ca.icoAmount = ? AND ca.name > ?) OR " +
+		"(ca.icoAmount = ? AND ca.name = ? AND ca.id > ?)) ORDER BY ca.icoAmount DESC, ca.name ASC, ca.id ASC LIMIT ?) " +
+		"ca ORDER BY ca.icoAmount DESC, ca.name ASC, ca.id ASC;"
+	expectedArgs := []interface{}{"BTC", float64(100), float64(100), "bitcoin", float64(100), "bitcoin", 4, 3}
+	expectedLen := len(expectedArgs)
+
+	stmt := _createSelectStatement(query, after)
+
+	if stmt.sql != expectedSQLString {
+		t.Fatalf("unexpected SQL string\n\nexpected: %s\nactual: %s", expectedSQLString, stmt.sql)
+	}
+
+	argsLen := len(stmt.args)
+	if argsLen != expectedLen {
+		t.Fatalf("unexpected argument length\n\nexpected: %d\nactual: %d", expectedLen, argsLen)
+	}
+
+	for idx, expectedArg := range expectedArgs {
+		if stmt.args[idx] != expectedArg {
+			t.Fatalf("unexpected argument at index %d\n\nexpected: %v\nactual: %v", idx, expectedArg, stmt.args[idx])
+		}
+	}
+}
+
+func Test_createSelectStatementRanges(t *testing.T) {
+	hasBlockReward := false
+	query := &Query{
+		MinICOAmount:   floatPointer(1000000),
+		MaxICOAmount:   floatPointer(5000000),
+		MaxBlockReward: floatPointer(12.5),
+		HasBlockReward: &hasBlockReward,
+		IncludeDeleted: true,
+		Fields:         []string{"icoAmount"},
+	}
+
+	expectedSQLString := "SELECT ca.id, ca.icoAmount FROM (SELECT * FROM crypto_asset ca WHERE icoAmount >= ? AND " +
+		"icoAmount <= ? AND blockReward <= ? AND blockReward = ? ORDER BY ca.id ASC) ca ORDER BY ca.id ASC;"
+	expectedArgs := []interface{}{float64(1000000), float64(5000000), 12.5, 0}
+	expectedLen := len(expectedArgs)
+
+	stmt := _createSelectStatement(query, nil)
+
+	if stmt.sql != expectedSQLString {
+		t.Fatalf("unexpected SQL string\n\nexpected: %s\nactual: %s", expectedSQLString, stmt.sql)
+	}
+
+	argsLen := len(stmt.args)
+	if argsLen != expectedLen {
+		t.Fatalf("unexpected argument length\n\nexpected: %d\nactual: %d", expectedLen, argsLen)
+	}
+
+	for idx, expectedArg := range expectedArgs {
+		if stmt.args[idx] != expectedArg {
+			t.Fatalf("unexpected argument at index %d\n\nexpected: %v\nactual: %v", idx, expectedArg, stmt.args[idx])
+		}
+	}
+}
+
+func Test_createSelectStatementFullText(t *testing.T) {
+	query := &Query{Text: ` zero-knowledge  "proofs `, Fields: []string{"name", "snippet"}}
+
+	expectedSQLString := "SELECT ca.id, ca.name, ca.relevance, ca.snippet FROM (SELECT * FROM (SELECT ca.*, " +
+		"-bm25(crypto_asset_search) AS relevance, snippet(crypto_asset_search, -1, '<b>', '</b>', '...', 16) AS snippet " +
+		"FROM crypto_asset ca JOIN crypto_asset_search ON crypto_asset_search.rowid = ca.id WHERE crypto_asset_search " +
+		"MATCH ?) ca WHERE ca.deletedAt IS NULL ORDER BY ca.relevance DESC, ca.id ASC) ca ORDER BY ca.relevance DESC, " +
+		"ca.id ASC;"
+	expectedMatch := `"zero-knowledge" """proofs"`
+
+	stmt := _createSelectStatement(query, nil)
+
+	if stmt.sql != expectedSQLString {
+		t.Fatalf("unexpected SQL string\n\nexpected: %s\nactual: %s", expectedSQLString, stmt.sql)
+	}
+
+	if len(stmt.args) != 1 || stmt.args[0] != expectedMatch {
+		t.Fatalf("unexpected arguments\n\nexpected: [%s]\nactual: %v", expectedMatch, stmt.args)
+	}
+}
+
+func Test_createSelectStatementAsOf(t *testing.T) {
+	query := &Query{Symbols: []string{"btc"}, Fields: []string{"team"}, AsOf: "2018-06-01T12:00:00Z"}
+
+	expectedSQLString := "SELECT ca.id, team_member.name FROM (SELECT * FROM (SELECT r.id AS revisionId, " +
+		"r.cryptoAssetId AS id, r.name, r.symbol, r.description, r.icoAmount, r.blockReward, r.fundingStatus, " +
+		"r.foundedDate, r.coinType, r.website, r.deletedAt, r.deletedBy FROM crypto_asset_revision r WHERE r.revision = " +
+		"(SELECT max(revision) FROM crypto_asset_revision WHERE cryptoAssetId = r.cryptoAssetId AND createdAt <= ?)) ca " +
+		"WHERE ca.deletedAt IS NULL AND (symbol = ?) ORDER BY ca.id ASC) ca LEFT JOIN team_member_revision team_member " +
+		"ON ca.revisionId = team_member.revisionId ORDER BY ca.id ASC, team_member.rowid;"
+	expectedArgs := []interface{}{"2018-06-01T12:00:00Z", "btc"}
+
+	stmt := _createSelectStatement(query, nil)
+
+	if stmt.sql != expectedSQLString {
+		t.Fatalf("unexpected SQL string\n\nexpected: %s\nactual: %s", expectedSQLString, stmt.sql)
+	}
+
+	if len(stmt.args) != len(expectedArgs) {
+		t.Fatalf("unexpected argument length\n\nexpected: %d\nactual: %d", len(expectedArgs), len(stmt.args))
+	}
+
+	for idx, expectedArg := range expectedArgs {
+		if stmt.args[idx] != expectedArg {
+			t.Fatalf("unexpected argument at index %d\n\nexpected: %v\nactual: %v", idx, expectedArg, stmt.args[idx])
+		}
+	}
+}
+
+func Test_createSelectStatementTeamMembers(t *testing.T) {
+	query := &Query{TeamMembers: []string{"Gavin Wood", "vitalik buterin"}, Fields: []string{"team"}}
+
+	expectedSQLString := "SELECT ca.id, team_member.name FROM (SELECT * FROM crypto_asset ca WHERE " +
+		"ca.deletedAt IS NULL AND ca.id IN (SELECT cryptoAssetId FROM team_member_name WHERE name COLLATE NOCASE " +
+		"IN (?, ?)) ORDER BY ca.id ASC) ca LEFT JOIN team_member_name team_member ON ca.id = cryptoAssetId " +
+		"ORDER BY ca.id ASC, team_member.position;"
+
+	stmt := _createSelectStatement(query, nil)
+
+	if stmt.sql != expectedSQLString {
+		t.Fatalf("unexpected SQL string\n\nexpected: %s\nactual: %s", expectedSQLString, stmt.sql)
+	}
+
+	if len(stmt.args) != 2 || stmt.args[0] != "Gavin Wood" || stmt.args[1] != "vitalik buterin" {
+		t.Fatalf("unexpected arguments\n\nexpected: [Gavin Wood vitalik buterin]\nactual: %v", stmt.args)
+	}
+}
+
 func Test_createUpdateStatement(t *testing.T) {
 	id := 1
 	name := "Bitcoin"
@@ -87,3 +244,95 @@ func Test_createUpdateStatement(t *testing.T) {
 		}
 	}
 }
+
+func TestSQLite_Export(t *testing.T) {
+	db := newTestSQLite(t)
+	defer db.Close()
+
+	rows := newTestImportRows("btc", "eth", "ltc")
+	rows[0].CryptoAsset.Team = []string{"satoshi", "hal"}
+	rows[2].CryptoAsset.Team = []string{"charlie"}
+	if err := db.Import(rows, false, true, "alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each crypto asset is passed on once with its whole team, in the order of the query.
+	var symbols, teams []string
+	err := db.Export(&Query{Sort: []SortKey{{Field: "symbol", Descending: true}}},
+		func(cryptoAsset *models.CryptoAsset) error {
+			symbols = append(symbols, *cryptoAsset.Symbol)
+			teams = append(teams, strings.Join(cryptoAsset.Team, ";"))
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("unexpected error exporting: %s", err.Error())
+	}
+	if strings.Join(symbols, ",") != "ltc,eth,btc" || strings.Join(teams, ",") != "charlie,,satoshi;hal" {
+		t.Fatalf("unexpected export\n\nsymbols: %v\nteams: %v", symbols, teams)
+	}
+
+	// An export stops at the first error, and cannot be paginated.
+	stop := errors.New("stop")
+	count := 0
+	err = db.Export(&Query{}, func(cryptoAsset *models.CryptoAsset) error {
+		count++
+		return stop
+	})
+	if err != stop || count != 1 {
+		t.Fatalf("expected the export to stop after the first crypto asset, got %v after %d", err, count)
+	}
+	if _, ok := db.Export(&Query{Limit: 1}, nil).(*UnsupportedQueryError); !ok {
+		t.Fatal("expected an unsupported query error exporting a page")
+	}
+}
+
+func TestSQLite_SelectTeamMembers(t *testing.T) {
+	db := newTestSQLite(t)
+	defer db.Close()
+
+	insertTestCryptoAsset(t, db, "ethereum", "eth", []string{"Vitalik Buterin", "Gavin Wood"})
+	insertTestCryptoAsset(t, db, "polkadot", "dot", []string{"Gavin Wood", "Robert Habermeier"})
+	insertTestCryptoAsset(t, db, "cardano", "ada", []string{"Charles Hoskinson"})
+
+	// Backdate the revisions so far so that the teams can be searched as they were before the update below.
+	if _, err := db.connection.Exec("UPDATE crypto_asset_revision SET createdAt = '2018-01-01T00:00:00Z';"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Team members are matched exactly but ignoring case, and every crypto asset with any of them is returned with its
+	// whole team.
+	cryptoAssets, _, err := db.Select(&Query{TeamMembers: []string{"gavin wood", "Charles Hoskinson", "Gavin"},
+		Fields: []string{"team"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var teams []string
+	for _, cryptoAsset := range cryptoAssets {
+		teams = append(teams, strings.Join(cryptoAsset.Team, ";"))
+	}
+	if strings.Join(teams, ",") != "Vitalik Buterin;Gavin Wood,Gavin Wood;Robert Habermeier,Charles Hoskinson" {
+		t.Fatalf("unexpected teams: %v", teams)
+	}
+
+	// As of a point in time, the team members are matched against the team the crypto asset had then.
+	if err = db.Update(2, &models.CryptoAsset{Team: []string{"Robert Habermeier"}}, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	for asOf, expectedLen := range map[string]int{"": 1, "2018-06-01T00:00:00Z": 2} {
+		cryptoAssets, _, err = db.Select(&Query{TeamMembers: []string{"Gavin Wood"}, AsOf: asOf})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(cryptoAssets) != expectedLen {
+			t.Fatalf("expected %d crypto assets with Gavin Wood as of %q, got %d", expectedLen, asOf, len(cryptoAssets))
+		}
+	}
+}
+
+func floatPointer(f float64) *float64 {
+	return &f
+}
+
+func stringPointer(str string) *string {
+	return &str
+}
@@ -0,0 +1,295 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/paddyquinn/messari/database/models"
+)
+
+// Every controlled vocabulary has a table of its values, a table of the aliases of those values and a column of the
+// crypto_asset table holding the value of each crypto asset, which has a foreign key to the table of values.
+
+// vocabularyTables maps the name of each controlled vocabulary to its table of values. The table of aliases has the
+// same name with an _alias suffix, and the crypto_asset column has the name of the vocabulary.
+var vocabularyTables = map[string]string{
+	models.CoinTypeVocabulary:      "coin_type",
+	models.FundingStatusVocabulary: "funding_status",
+}
+
+// unknownVocabularyValue returns an UnknownVocabularyValueError for the funding status or coin type of the crypto asset
+// that its vocabulary does not have, as part of a SQL transaction. A write breaks the foreign key of the crypto_asset
+// table to a table of values if the value was renamed after the crypto asset was validated, and SQLite does not say
+// which foreign key was broken. The given error is returned if the vocabularies have both values.
+func unknownVocabularyValue(transaction *sql.Tx, cryptoAsset *models.CryptoAsset, err error) error {
+	values := map[string]*string{
+		models.FundingStatusVocabulary: cryptoAsset.FundingStatus,
+		models.CoinTypeVocabulary:      cryptoAsset.CoinType,
+	}
+	for _, vocabulary := range []string{models.FundingStatusVocabulary, models.CoinTypeVocabulary} {
+		value := values[vocabulary]
+		if value == nil {
+			continue
+		}
+
+		var exists bool
+		lookupErr := transaction.QueryRow(fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM %s WHERE value = ?);",
+			vocabularyTables[vocabulary]), *value).Scan(&exists)
+		if lookupErr != nil {
+			return lookupErr
+		}
+		if !exists {
+			return NewUnknownVocabularyValueError(vocabulary, *value)
+		}
+	}
+	return err
+}
+
+// AddVocabularyValue adds the value and its aliases to the controlled vocabulary, which must be normalized. An
+// UnknownVocabularyError is returned if there is no such vocabulary, a NullConstraintError is returned if the value or
+// an alias is empty and a DuplicateVocabularyValueError is returned if the value or an alias is already a value or an
+// alias of the vocabulary.
+func (s *SQLite) AddVocabularyValue(vocabulary string, value *models.VocabularyValue) error {
+	table, ok := vocabularyTables[vocabulary]
+	if !ok {
+		return NewUnknownVocabularyError(vocabulary)
+	}
+	if value.Value == emptyString {
+		return NewNullConstraintError("value")
+	}
+
+	// Begin a SQL transaction so that nothing is added unless the value and all of its aliases are.
+	transaction, err := s.connection.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err = checkVocabularyValueFree(transaction, vocabulary, value.Value); err != nil {
+		transaction.Rollback()
+		return err
+	}
+	if _, err = transaction.Exec(fmt.Sprintf("INSERT INTO %s(value) VALUES(?);", table), value.Value); err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	for _, alias := range value.Aliases {
+		if alias == emptyString {
+			transaction.Rollback()
+			return NewNullConstraintError("aliases")
+		}
+		if err = checkVocabularyValueFree(transaction, vocabulary, alias); err != nil {
+			transaction.Rollback()
+			return err
+		}
+		_, err = transaction.Exec(fmt.Sprintf("INSERT INTO %s_alias(alias, value) VALUES(?, ?);", table), alias,
+			value.Value)
+		if err != nil {
+			transaction.Rollback()
+			return err
+		}
+	}
+
+	// Commit the transaction and return.
+	return transaction.Commit()
+}
+
+// RenameVocabularyValue renames a value of the controlled vocabulary, which must be normalized. Every crypto asset with
+// the value is changed to the new one, which the actor is recorded as having done in its history, and the old value
+// becomes an alias of the new one so that it is still understood. An UnknownVocabularyError is returned if there is no
+// such vocabulary, an UnknownVocabularyValueError is returned if the vocabulary does not have the value and a
+// DuplicateVocabularyValueError is returned if the new value is already a value of the vocabulary or an alias of
+// another value.
+func (s *SQLite) RenameVocabularyValue(vocabulary, value, newValue, actor string) error {
+	table, ok := vocabularyTables[vocabulary]
+	if !ok {
+		return NewUnknownVocabularyError(vocabulary)
+	}
+	if newValue == emptyString {
+		return NewNullConstraintError("value")
+	}
+
+	// Begin a SQL transaction so that the value and every crypto asset that has it are renamed together.
+	transaction, err := s.connection.Begin()
+	if err != nil {
+		return err
+	}
+
+	var retiredAt *string
+	err = transaction.QueryRow(fmt.Sprintf("SELECT retiredAt FROM %s WHERE value = ?;", table), value).Scan(&retiredAt)
+	if err == sql.ErrNoRows {
+		err = NewUnknownVocabularyValueError(vocabulary, value)
+	}
+	if err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	// The new value may already be an alias of the value, in which case it stops being one.
+	_, err = transaction.Exec(fmt.Sprintf("DELETE FROM %s_alias WHERE alias = ? AND value = ?;", table), newValue, value)
+	if err == nil {
+		err = checkVocabularyValueFree(transaction, vocabulary, newValue)
+	}
+	if err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	// Find the crypto assets with the value before they are changed so that the change can be recorded in their history.
+	ids, err := selectIDs(transaction, fmt.Sprintf("SELECT id FROM crypto_asset WHERE %s = ? ORDER BY id;", vocabulary),
+		value)
+	if err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	// Add the new value, move the crypto assets and aliases over to it and replace the old value with an alias. The old
+	// value is only removed once nothing refers to it.
+	statements := []struct {
+		sql  string
+		args []interface{}
+	}{
+		{fmt.Sprintf("INSERT INTO %s(value, retiredAt) VALUES(?, ?);", table), []interface{}{newValue, retiredAt}},
+		{fmt.Sprintf("UPDATE crypto_asset SET %s = ? WHERE %s = ?;", vocabulary, vocabulary),
+			[]interface{}{newValue, value}},
+		{fmt.Sprintf("UPDATE %s_alias SET value = ? WHERE value = ?;", table), []interface{}{newValue, value}},
+		{fmt.Sprintf("INSERT INTO %s_alias(alias, value) VALUES(?, ?);", table), []interface{}{value, newValue}},
+		{fmt.Sprintf("DELETE FROM %s WHERE value = ?;", table), []interface{}{value}},
+	}
+	for _, statement := range statements {
+		if _, err = transaction.Exec(statement.sql, statement.args...); err != nil {
+			transaction.Rollback()
+			return err
+		}
+	}
+
+	for _, id := range ids {
+		if err = recordRevision(transaction, id, actor, models.RenameAction); err != nil {
+			transaction.Rollback()
+			return err
+		}
+	}
+
+	// Commit the transaction and return.
+	return transaction.Commit()
+}
+
+// RetireVocabularyValue retires a value of the controlled vocabulary so that it can no longer be given to a crypto
+// asset. Crypto assets that already have the value keep it. An UnknownVocabularyError is returned if there is no such
+// vocabulary and an UnknownVocabularyValueError is returned if the vocabulary does not have the value or it has already
+// been retired.
+func (s *SQLite) RetireVocabularyValue(vocabulary, value string) error {
+	table, ok := vocabularyTables[vocabulary]
+	if !ok {
+		return NewUnknownVocabularyError(vocabulary)
+	}
+
+	result, err := s.connection.Exec(fmt.Sprintf("UPDATE %s SET retiredAt = ? WHERE value = ? AND retiredAt IS NULL;",
+		table), time.Now().UTC().Format(time.RFC3339), value)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected != 1 {
+		return NewUnknownVocabularyValueError(vocabulary, value)
+	}
+
+	return nil
+}
+
+// Vocabularies returns every controlled vocabulary with its values and their aliases in alphabetical order. Retired
+// values are only included if includeRetired is true.
+func (s *SQLite) Vocabularies(includeRetired bool) (models.Vocabularies, error) {
+	return selectVocabularies(s.connection, includeRetired)
+}
+
+// selectVocabularies returns every controlled vocabulary as described by Vocabularies using either a SQL connection
+// or a SQL transaction.
+func selectVocabularies(q queryer, includeRetired bool) (models.Vocabularies, error) {
+	condition := emptyString
+	if !includeRetired {
+		condition = "WHERE v.retiredAt IS NULL "
+	}
+
+	vocabularies := models.Vocabularies{}
+	for vocabulary, table := range vocabularyTables {
+		rows, err := q.Query(fmt.Sprintf("SELECT v.value, v.retiredAt, a.alias FROM %s v LEFT JOIN %s_alias a ON "+
+			"a.value = v.value %sORDER BY v.value, a.alias;", table, table, condition))
+		if err != nil {
+			return nil, err
+		}
+
+		values := []*models.VocabularyValue{}
+		for rows.Next() {
+			var (
+				value     string
+				retiredAt *string
+				alias     *string
+			)
+			if err = rows.Scan(&value, &retiredAt, &alias); err != nil {
+				rows.Close()
+				return nil, err
+			}
+
+			// A value is repeated on a row for each of its aliases.
+			if len(values) == 0 || values[len(values)-1].Value != value {
+				values = append(values, &models.VocabularyValue{Value: value, Aliases: []string{}, RetiredAt: retiredAt})
+			}
+			if alias != nil {
+				lastValue := values[len(values)-1]
+				lastValue.Aliases = append(lastValue.Aliases, *alias)
+			}
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+
+		vocabularies[vocabulary] = values
+	}
+
+	return vocabularies, nil
+}
+
+// checkVocabularyValueFree makes sure that the string is neither a value nor an alias of the controlled vocabulary as
+// part of a SQL transaction. A DuplicateVocabularyValueError is returned if it is either.
+func checkVocabularyValueFree(transaction *sql.Tx, vocabulary, str string) error {
+	table := vocabularyTables[vocabulary]
+
+	var taken bool
+	err := transaction.QueryRow(fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM %s WHERE value = ?) OR "+
+		"EXISTS(SELECT 1 FROM %s_alias WHERE alias = ?);", table, table), str, str).Scan(&taken)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return NewDuplicateVocabularyValueError(vocabulary, str)
+	}
+
+	return nil
+}
+
+// selectIDs returns the ids selected by the query as part of a SQL transaction.
+func selectIDs(transaction *sql.Tx, query string, args ...interface{}) ([]int, error) {
+	rows, err := transaction.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
@@ -0,0 +1,137 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/paddyquinn/messari/database/models"
+)
+
+func TestSQLite_Vocabularies(t *testing.T) {
+	db := newTestSQLite(t)
+	defer db.Close()
+
+	// Adding a value that is already an alias of another value fails, as does adding a value with an unknown
+	// vocabulary.
+	meme := &models.VocabularyValue{Value: "meme", Aliases: []string{"coin"}}
+	err := db.AddVocabularyValue(models.CoinTypeVocabulary, meme)
+	if _, ok := err.(*DuplicateVocabularyValueError); !ok {
+		t.Fatalf("expected a duplicate vocabulary value error, got %v", err)
+	}
+	err = db.AddVocabularyValue("color", &models.VocabularyValue{Value: "red", Aliases: []string{}})
+	if _, ok := err.(*UnknownVocabularyError); !ok {
+		t.Fatalf("expected an unknown vocabulary error, got %v", err)
+	}
+
+	// A value is added along with its aliases, and nothing is added if it fails.
+	meme.Aliases = []string{"joke"}
+	if err = db.AddVocabularyValue(models.CoinTypeVocabulary, meme); err != nil {
+		t.Fatalf("unexpected error adding a value: %s", err.Error())
+	}
+
+	// A retired value is only listed when retired values are included.
+	if err = db.RetireVocabularyValue(models.CoinTypeVocabulary, "meme"); err != nil {
+		t.Fatalf("unexpected error retiring a value: %s", err.Error())
+	}
+	if _, ok := db.RetireVocabularyValue(models.CoinTypeVocabulary, "meme").(*UnknownVocabularyValueError); !ok {
+		t.Fatal("expected an unknown vocabulary value error retiring a value twice")
+	}
+	assertVocabularyValue(t, db, false, "meme", nil)
+	assertVocabularyValue(t, db, true, "meme", []string{"joke"})
+}
+
+func TestSQLite_RenameVocabularyValue(t *testing.T) {
+	db := newTestSQLite(t)
+	defer db.Close()
+
+	name, symbol, description, website := "bitcoin", "btc", "The original cryptocurrency", "https://bitcoin.org/en/"
+	var icoAmount float64
+	blockReward, fundingStatus, foundedDate, coinType := 12.5, "no-ico", "2009-01-03", "currency"
+	id, err := db.Insert(&models.CryptoAsset{Name: &name, Symbol: &symbol, Description: &description, Team: []string{},
+		ICOAmount: &icoAmount, BlockReward: &blockReward, FundingStatus: &fundingStatus, FoundedDate: &foundedDate,
+		CoinType: &coinType, Website: &website}, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A value cannot be renamed to another value.
+	err = db.RenameVocabularyValue(models.CoinTypeVocabulary, "currency", "token", "bob")
+	if _, ok := err.(*DuplicateVocabularyValueError); !ok {
+		t.Fatalf("expected a duplicate vocabulary value error, got %v", err)
+	}
+	err = db.RenameVocabularyValue(models.CoinTypeVocabulary, "money", "cash", "bob")
+	if _, ok := err.(*UnknownVocabularyValueError); !ok {
+		t.Fatalf("expected an unknown vocabulary value error, got %v", err)
+	}
+
+	// Renaming a value to one of its own aliases swaps the two, and the crypto assets with the value are changed.
+	if err = db.RenameVocabularyValue(models.CoinTypeVocabulary, "currency", "cryptocurrency", "bob"); err != nil {
+		t.Fatalf("unexpected error renaming a value: %s", err.Error())
+	}
+	assertVocabularyValue(t, db, true, "currency", nil)
+	assertVocabularyValue(t, db, true, "cryptocurrency", []string{"coin", "currency"})
+
+	cryptoAsset, err := db.Get(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *cryptoAsset.CoinType != "cryptocurrency" {
+		t.Fatalf("expected the coin type of crypto asset %s to be renamed, got %s", id, *cryptoAsset.CoinType)
+	}
+
+	// The rename is recorded in the history of the crypto asset.
+	revisions, err := db.History(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lastRevision := revisions[len(revisions)-1]
+	if lastRevision.Action != models.RenameAction || lastRevision.Actor != "bob" {
+		t.Fatalf("expected a rename by bob, got a %s by %s", lastRevision.Action, lastRevision.Actor)
+	}
+
+	// A write that was validated before the rename fails with the old value as an unknown vocabulary value.
+	err = db.Update(1, &models.CryptoAsset{CoinType: &coinType}, "alice")
+	if _, ok := err.(*UnknownVocabularyValueError); !ok {
+		t.Fatalf("expected an unknown vocabulary value error updating, got %v", err)
+	}
+	symbol = "ltc"
+	_, err = db.Insert(&models.CryptoAsset{Name: &name, Symbol: &symbol, Description: &description, Team: []string{},
+		ICOAmount: &icoAmount, BlockReward: &blockReward, FundingStatus: &fundingStatus, FoundedDate: &foundedDate,
+		CoinType: &coinType, Website: &website}, "alice")
+	if _, ok := err.(*UnknownVocabularyValueError); !ok {
+		t.Fatalf("expected an unknown vocabulary value error inserting, got %v", err)
+	}
+	if err.Error() != "coinType currency not found" {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+}
+
+// newTestSQLite opens a new in-memory database.
+func newTestSQLite(t *testing.T) *SQLite {
+	db, err := NewSQLite(InMemoryPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// assertVocabularyValue fails the test unless the coin type vocabulary has the value with the expected aliases. A nil
+// slice of aliases means the value is not expected at all.
+func assertVocabularyValue(t *testing.T, db *SQLite, includeRetired bool, value string, expectedAliases []string) {
+	vocabularies, err := db.Vocabularies(includeRetired)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, vocabularyValue := range vocabularies[models.CoinTypeVocabulary] {
+		if vocabularyValue.Value == value {
+			if !reflect.DeepEqual(expectedAliases, vocabularyValue.Aliases) {
+				t.Fatalf("expected %s to have the aliases %v, got %v", value, expectedAliases, vocabularyValue.Aliases)
+			}
+			return
+		}
+	}
+	if expectedAliases != nil {
+		t.Fatalf("expected coin type %s to be listed", value)
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/paddyquinn/messari/config"
+	"github.com/paddyquinn/messari/database"
+	"github.com/paddyquinn/messari/database/models"
+)
+
+// importUsage describes the import command.
+const importUsage = `usage: messari import [-db path] [-format format] [-mode mode] [-upsert] [-actor name] file
+
+Registers every crypto asset in a CSV or TSV file, JSON array or newline delimited JSON file, which is read from
+standard input if the file is -. The format is taken from the file extension unless -format is passed.
+
+flags:`
+
+// importExtensions maps the extensions of the files that can be imported to their formats.
+var importExtensions = map[string]string{
+	".csv":    models.CSVFormat,
+	".json":   models.JSONFormat,
+	".jsonl":  models.NDJSONFormat,
+	".ndjson": models.NDJSONFormat,
+	".tsv":    models.TSVFormat,
+}
+
+// runImport imports the crypto assets in a file directly into the database, reporting what became of each row. The
+// database is the one the server would use, unless the -db flag is passed. An error is returned if an atomic import
+// has a failed row, in which case nothing was imported.
+func runImport(args []string, out io.Writer) error {
+	cfg, err := config.Load(nil)
+	if err != nil {
+		return err
+	}
+
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, importUsage)
+		flags.PrintDefaults()
+	}
+	dbPath := flags.String("db", cfg.DBPath, "path to the SQLite database file")
+	format := flags.String("format", "", "format of the file: csv, tsv, json or ndjson")
+	mode := flags.String("mode", models.AtomicImport, "atomic to import nothing if any row fails, or bestEffort to "+
+		"import every row that does not")
+	upsert := flags.Bool("upsert", false, "replace the live crypto asset with the symbol of a row, if there is one")
+	actor := flags.String("actor", "anonymous", "who is recorded as having imported the crypto assets")
+	if err = flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return flag.ErrHelp
+	}
+	if *mode != models.AtomicImport && *mode != models.BestEffortImport {
+		return fmt.Errorf("unknown import mode: %s", *mode)
+	}
+
+	// Tell the format of the file from its extension if it is not passed.
+	path := flags.Arg(0)
+	if *format == "" {
+		*format = importExtensions[strings.ToLower(filepath.Ext(path))]
+	}
+	if !models.IsImportFormat(*format) {
+		return errors.New("cannot tell the format of the file: pass -format csv, tsv, json or ndjson")
+	}
+
+	// Parse the rows of the file.
+	file := os.Stdin
+	if path != "-" {
+		if file, err = os.Open(path); err != nil {
+			return err
+		}
+		defer file.Close()
+	}
+	rows, err := models.ParseImport(file, *format)
+	if err != nil {
+		return err
+	}
+
+	sqlite, err := database.NewSQLite(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer sqlite.Close()
+
+	return importRows(sqlite, rows, *upsert, *mode == models.AtomicImport, *actor, out)
+}
+
+// importRows imports the rows into the database and prints what became of each of them, followed by a summary.
+func importRows(db database.Interface, rows []*models.ImportRow, upsert, atomic bool, actor string,
+	out io.Writer) error {
+	if err := db.Import(rows, upsert, atomic, actor); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		fmt.Fprintf(out, "row %d: %s", row.Row, row.Status)
+		if row.ID != nil {
+			fmt.Fprintf(out, " %s", *row.ID)
+		}
+		if row.Symbol != nil {
+			fmt.Fprintf(out, " (%s)", *row.Symbol)
+		}
+		fmt.Fprintln(out)
+		for _, violation := range row.Errors {
+			fmt.Fprintf(out, "\t%s\n", violation.Detail)
+		}
+	}
+
+	report := models.NewImportReport(rows)
+	if atomic && report.Failed > 0 {
+		return fmt.Errorf("%d of %d rows failed, so nothing was imported", report.Failed, len(rows))
+	}
+	fmt.Fprintf(out, "%d created, %d updated, %d unchanged, %d failed\n", report.Created, report.Updated,
+		report.Unchanged, report.Failed)
+	return nil
+}
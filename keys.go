@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/paddyquinn/messari/config"
+	"github.com/paddyquinn/messari/database"
+	"github.com/paddyquinn/messari/database/models"
+)
+
+// keysUsage describes the keys command.
+const keysUsage = `usage: messari keys [-db path] <command>
+
+commands:
+  create -name name -scope scope   issue an API key with the read, propose, write, approve or admin scope
+  list                             list every API key that has been issued
+  revoke id                        revoke an API key`
+
+// runKeys issues, lists and revokes API keys directly in the database, which is how the first admin key is issued. The
+// database is the one the server would use, unless the -db flag is passed.
+func runKeys(args []string, out io.Writer) error {
+	cfg, err := config.Load(nil)
+	if err != nil {
+		return err
+	}
+
+	flags := flag.NewFlagSet("keys", flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprintln(os.Stderr, keysUsage) }
+	dbPath := flags.String("db", cfg.DBPath, "path to the SQLite database file")
+	if err = flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return flag.ErrHelp
+	}
+
+	sqlite, err := database.NewSQLite(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer sqlite.Close()
+
+	command, commandArgs := flags.Arg(0), flags.Args()[1:]
+	switch command {
+	case "create":
+		return createKey(sqlite, commandArgs, out)
+	case "list":
+		return listKeys(sqlite, out)
+	case "revoke":
+		return revokeKey(sqlite, commandArgs, out)
+	default:
+		return fmt.Errorf("unknown keys command: %s", command)
+	}
+}
+
+// createKey issues an API key with the name and scope given by the -name and -scope flags and prints it. The key
+// cannot be shown again.
+func createKey(db database.Interface, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("keys create", flag.ContinueOnError)
+	name := flags.String("name", "", "who or what the API key is for, which is recorded as the actor of its changes")
+	scope := flags.String("scope", models.ReadScope, "scope of the API key: read, propose, write, approve or admin")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	apiKey, err := db.CreateAPIKey(strings.TrimSpace(*name), strings.ToLower(strings.TrimSpace(*scope)))
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "issued %s API key %d for %s: %s\n", apiKey.Scope, apiKey.ID, apiKey.Name, apiKey.Key)
+	fmt.Fprintln(out, "store it now, it cannot be shown again")
+	return nil
+}
+
+// listKeys prints every API key that has been issued, one per line.
+func listKeys(db database.Interface, out io.Writer) error {
+	apiKeys, err := db.APIKeys()
+	if err != nil {
+		return err
+	}
+
+	for _, apiKey := range apiKeys {
+		status := "active"
+		if apiKey.RevokedAt != nil {
+			status = "revoked " + *apiKey.RevokedAt
+		}
+		fmt.Fprintf(out, "%d\t%s\t%s\tcreated %s\t%s\n", apiKey.ID, apiKey.Name, apiKey.Scope, apiKey.CreatedAt, status)
+	}
+	return nil
+}
+
+// revokeKey revokes the API key with the given id.
+func revokeKey(db database.Interface, args []string, out io.Writer) error {
+	if len(args) != 1 {
+		return errors.New("usage: messari keys revoke id")
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid id: %s", args[0])
+	}
+
+	if err = db.RevokeAPIKey(id); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "revoked API key %d\n", id)
+	return nil
+}
@@ -1,6 +1,10 @@
 package main
 
 import (
+	"flag"
+	"os"
+
+	"github.com/paddyquinn/messari/config"
 	"github.com/paddyquinn/messari/database"
 	"github.com/paddyquinn/messari/server"
 	log "github.com/sirupsen/logrus"
@@ -9,16 +13,46 @@ import (
 const errorKey = "error"
 
 func main() {
+	// The keys command manages API keys instead of running the server.
+	if len(os.Args) > 1 && os.Args[1] == "keys" {
+		err := runKeys(os.Args[2:], os.Stdout)
+		if err != nil && err != flag.ErrHelp {
+			log.WithField(errorKey, err.Error()).Fatal("keys command failed")
+		}
+		return
+	}
+
+	// The import command imports crypto assets from a file instead of running the server.
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		err := runImport(os.Args[2:], os.Stdout)
+		if err != nil && err != flag.ErrHelp {
+			log.WithField(errorKey, err.Error()).Fatal("import command failed")
+		}
+		return
+	}
+
+	// Load the config from the config file, environment variables and flags.
+	cfg, err := config.Load(os.Args[1:])
+	if err == flag.ErrHelp {
+		return
+	}
+	if err != nil {
+		log.WithField(errorKey, err.Error()).Fatal("could not load config")
+	}
+	log.SetLevel(cfg.LogLevel)
+
 	// Establish connection to SQLite database.
-	sqlite, err := database.NewSQLite()
+	sqlite, err := database.NewSQLite(cfg.DBPath)
 	if err != nil {
 		log.WithField(errorKey, err.Error()).Fatal("could not establish connection to sqlite")
 	}
-	defer sqlite.Close()
 
-	// Start the server.
-	srv := server.NewServer(sqlite)
-	if err = srv.Start(); err != nil {
-		log.WithField(errorKey, err.Error()).Fatal("server failed to start")
+	// Run the server until it is stopped. The database connection is closed before exiting, even if the server failed,
+	// so that no transaction is left half written.
+	srv := server.NewServer(sqlite, cfg)
+	err = srv.Start()
+	sqlite.Close()
+	if err != nil {
+		log.WithField(errorKey, err.Error()).Fatal("server failed")
 	}
 }
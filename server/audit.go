@@ -0,0 +1,159 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/paddyquinn/messari/database"
+	"github.com/paddyquinn/messari/database/models"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Request id constants. A request keeps the id passed in the X-Request-ID header, as long as it is not too long, and
+	// is given a random one otherwise. Either way, the id is sent back in the same header.
+	maxRequestIDLength  = 128
+	requestIDBytes      = 16
+	requestIDContextKey = "requestId"
+	requestIDHeader     = "X-Request-ID"
+
+	// Context keys the handlers store the crypto asset id and normalized payload of an audited request under.
+	auditCryptoAssetIDContextKey = "auditCryptoAssetId"
+	auditPayloadContextKey       = "auditPayload"
+
+	// Audit log query string parameter constants.
+	assetIDParam = "assetId"
+	sinceParam   = "since"
+
+	// Audit error string constants.
+	auditError    = "could not record the audit log entry"
+	auditLogError = "could not get the audit log"
+)
+
+// assignRequestID is middleware that gives every request an id, which is recorded in the audit log and sent back to
+// the client. The X-Request-ID header is stored as X-Request-Id, so it is looked up in canonical form rather than with
+// ctx.GetHeader(...), which does not canonicalize the key.
+func assignRequestID(ctx *gin.Context) {
+	requestID := strings.TrimSpace(ctx.Request.Header.Get(requestIDHeader))
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		requestID = newRequestID()
+	}
+
+	ctx.Set(requestIDContextKey, requestID)
+	ctx.Header(requestIDHeader, requestID)
+}
+
+// audit creates middleware that records the request in the audit log, with the given action, once the handler has
+// responded. Failed requests are recorded too, along with the status code they failed with, but dry runs are not.
+func (s *Server) audit(action string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Next()
+		if !ctx.GetBool(dryRunContextKey) {
+			s.recordAudit(ctx, action)
+		}
+	}
+}
+
+// recordAudit appends the request to the audit log, along with the crypto asset id and payload the handler stored in
+// the context. The response has already been sent by then, so an entry that cannot be recorded is only logged.
+func (s *Server) recordAudit(ctx *gin.Context, action string) {
+	entry := &models.AuditEntry{
+		RequestID: ctx.GetString(requestIDContextKey),
+		Actor:     getActor(ctx),
+		ClientIP:  ctx.ClientIP(),
+		Method:    ctx.Request.Method,
+		Path:      ctx.Request.URL.Path,
+		Action:    action,
+		Status:    ctx.Writer.Status(),
+	}
+	if cryptoAssetID := ctx.GetString(auditCryptoAssetIDContextKey); cryptoAssetID != "" {
+		entry.CryptoAssetID = &cryptoAssetID
+	}
+
+	logger := log.WithFields(log.Fields{endpoint: entry.Path, requestIDContextKey: entry.RequestID})
+	if payload, ok := ctx.Get(auditPayloadContextKey); ok {
+		payloadJSON, err := json.Marshal(payload)
+		if err != nil {
+			logger.WithField(errKey, err.Error()).Error(auditError)
+			return
+		}
+		entry.Payload = payloadJSON
+	}
+
+	if err := s.DB.Audit(entry); err != nil {
+		logger.WithField(errKey, err.Error()).Error(auditError)
+	}
+}
+
+// setAuditCryptoAssetID stores the id of the crypto asset a request changes so that it is recorded in the audit log.
+func setAuditCryptoAssetID(ctx *gin.Context, id int) {
+	ctx.Set(auditCryptoAssetIDContextKey, strconv.Itoa(id))
+}
+
+// setAuditPayload stores the normalized payload of a request so that it is recorded in the audit log.
+func setAuditPayload(ctx *gin.Context, payload interface{}) {
+	ctx.Set(auditPayloadContextKey, payload)
+}
+
+// auditLog returns the entries of the audit log, oldest first. The entries can be filtered by the "assetId" of the
+// crypto asset they changed, the "actor" that made them and the time "since" they were made, which is either an RFC
+// 3339 timestamp or an ISO-8601 date, meaning the start of that day in UTC.
+func (s *Server) auditLog(ctx *gin.Context) {
+	// Initialize the logger.
+	logger := log.WithField(endpoint, auditEndpoint)
+
+	// Parse the audit log query passed in via the query string.
+	query, err := parseAuditQuery(ctx)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(queryError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	// Get the entries from the database.
+	entries, err := s.DB.AuditLog(query)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(auditLogError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, entries)
+}
+
+// parseAuditQuery extracts an audit log query from the query string. An error is returned if the asset id is not a
+// number or if since is neither a timestamp nor a date.
+func parseAuditQuery(ctx *gin.Context) (*database.AuditQuery, error) {
+	query := &database.AuditQuery{Actor: strings.TrimSpace(ctx.Query(actorKey))}
+
+	if assetIDString, ok := ctx.GetQuery(assetIDParam); ok {
+		assetID, err := strconv.Atoi(strings.TrimSpace(assetIDString))
+		if err != nil {
+			return nil, newInvalidParameterError(assetIDParam, assetIDString)
+		}
+		query.CryptoAssetID = &assetID
+	}
+
+	if sinceString, ok := ctx.GetQuery(sinceParam); ok {
+		var err error
+		if query.Since, err = parseTimestamp(sinceParam, sinceString, false); err != nil {
+			return nil, err
+		}
+	}
+
+	return query, nil
+}
+
+// newRequestID generates a random, hex encoded request id.
+func newRequestID() string {
+	requestID := make([]byte, requestIDBytes)
+	if _, err := rand.Read(requestID); err != nil {
+		log.WithField(errKey, err.Error()).Error("could not generate a request id")
+	}
+	return hex.EncodeToString(requestID)
+}
@@ -0,0 +1,218 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/paddyquinn/messari/database"
+	"github.com/paddyquinn/messari/database/models"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAudit(t *testing.T) {
+	// Hide logs.
+	log.SetLevel(log.FatalLevel)
+
+	// Set up router for testing.
+	gin.SetMode(gin.TestMode)
+	mockDatabase := &database.Mock{}
+	mockRouter := setUpMockRouter(mockDatabase)
+	expectVocabularies(mockDatabase)
+
+	// Run tests.
+	testAuditUpdate(t, mockRouter, mockDatabase)
+	testAuditFailure(t, mockRouter, mockDatabase)
+	testAuditDryRun(t, mockRouter, mockDatabase)
+	testAuditClientRequestID(t, mockRouter, mockDatabase)
+}
+
+func testAuditUpdate(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database calls. The normalized update is recorded under a generated request id.
+	req := httptest.NewRequest("PATCH", "/assets/1", strings.NewReader("{\"symbol\": \" BTC \"}"))
+	req.Header.Set("X-Actor", "alice")
+	symbol := "btc"
+	id := "1"
+	mockDatabase.On("Update", 1, &models.CryptoAsset{ID: &id, Symbol: &symbol}, "alice").Return(nil)
+	mockDatabase.On("Get", 1).Return(newBitcoin(), nil)
+	var entry *models.AuditEntry
+	mockDatabase.On("Audit", mock.AnythingOfType("*models.AuditEntry")).Return(nil).Run(func(args mock.Arguments) {
+		entry = args.Get(0).(*models.AuditEntry)
+	}).Once()
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and audit log entry.
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+	requestID := recorder.Header().Get("X-Request-ID")
+	if len(requestID) != 32 || entry.RequestID != requestID {
+		t.Fatalf("unexpected request id\n\nheader: %s\nentry: %s", requestID, entry.RequestID)
+	}
+	if entry.Actor != "alice" || entry.Method != "PATCH" || entry.Path != "/assets/1" ||
+		entry.Action != models.UpdateAction || entry.CryptoAssetID == nil || *entry.CryptoAssetID != "1" ||
+		entry.Status != http.StatusOK || string(entry.Payload) != "{\"id\":\"1\",\"name\":null,\"symbol\":\"btc\","+
+		"\"description\":null,\"team\":null,\"icoAmount\":null,\"blockReward\":null,\"fundingStatus\":null,"+
+		"\"foundedDate\":null,\"coinType\":null,\"website\":null}" {
+		t.Fatalf("unexpected audit log entry: %+v", entry)
+	}
+}
+
+func testAuditFailure(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database calls. A failed write is recorded along with its status code.
+	req := httptest.NewRequest("DELETE", "/assets/7", nil)
+	mockDatabase.On("Delete", 7, (*int)(nil), "anonymous").Return(database.NewUnknownIDError(7))
+	mockDatabase.On("Audit", mock.MatchedBy(func(entry *models.AuditEntry) bool {
+		return entry.Action == models.DeleteAction && entry.CryptoAssetID != nil && *entry.CryptoAssetID == "7" &&
+			entry.Status == http.StatusNotFound && entry.Payload == nil
+	})).Return(errors.New("database is locked")).Once()
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body, which a failure to record the entry does not change.
+	assertResponseCode(t, http.StatusNotFound, recorder.Code)
+	assertProblem(t, cryptoAssetNotFoundCode, "", "crypto asset with id 7 not found", recorder)
+}
+
+func testAuditDryRun(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call. A dry run writes nothing, so it is not recorded.
+	req := httptest.NewRequest("POST", "/update?dryRun=true", strings.NewReader("{\"id\": \"1\", \"blockReward\": 6.25}"))
+	mockDatabase.On("DryRunUpdate", 1, mock.Anything, "anonymous").Return(newBitcoin(), nil).Once()
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made. Only the two earlier requests were recorded.
+	mockDatabase.AssertNumberOfCalls(t, "Audit", 2)
+
+	// Assert the expected HTTP response code.
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+}
+
+func testAuditClientRequestID(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database calls. The request id is sent in a different case than the server
+	// sends it back in, and is recorded as is.
+	req := httptest.NewRequest("DELETE", "/assets/1", nil)
+	req.Header.Set("x-request-id", " client-request-1 ")
+	mockDatabase.On("Delete", 1, (*int)(nil), "anonymous").Return(nil)
+	mockDatabase.On("Audit", mock.MatchedBy(func(entry *models.AuditEntry) bool {
+		return entry.RequestID == "client-request-1" && entry.Action == models.DeleteAction
+	})).Return(nil).Once()
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and header.
+	assertResponseCode(t, http.StatusNoContent, recorder.Code)
+	if requestID := recorder.Header().Get("X-Request-ID"); requestID != "client-request-1" {
+		t.Fatalf("unexpected X-Request-ID header\n\nexpected: client-request-1\nactual: %s", requestID)
+	}
+}
+
+func TestAuditLogEndpoint(t *testing.T) {
+	// Hide logs.
+	log.SetLevel(log.FatalLevel)
+
+	// Set up router for testing.
+	gin.SetMode(gin.TestMode)
+	mockDatabase := &database.Mock{}
+	mockRouter := setUpMockRouter(mockDatabase)
+
+	// Run tests.
+	testAuditLogInvalidQuery(t, mockRouter, "/audit?assetId=btc", "assetId", "invalid assetId: btc")
+	testAuditLogInvalidQuery(t, mockRouter, "/audit?since=yesterday", "since", "invalid since: yesterday")
+	testAuditLogDatabaseError(t, mockRouter, mockDatabase)
+	testAuditLogSuccess(t, mockRouter, mockDatabase)
+}
+
+func testAuditLogInvalidQuery(t *testing.T, mockRouter *gin.Engine, target, expectedField, expectedDetail string) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, httptest.NewRequest("GET", target, nil))
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusBadRequest, recorder.Code)
+	assertProblem(t, invalidParameterCode, expectedField, expectedDetail, recorder)
+}
+
+func testAuditLogDatabaseError(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call.
+	req := httptest.NewRequest("GET", "/audit?actor=bob", nil)
+	mockDatabase.On("AuditLog", &database.AuditQuery{Actor: "bob"}).Return(nil, errors.New("database is locked"))
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusInternalServerError, recorder.Code)
+	assertProblem(t, internalErrorCode, "", "internal server error", recorder)
+}
+
+func testAuditLogSuccess(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call. A date means the start of that day.
+	req := httptest.NewRequest("GET", "/audit?assetId=1&actor=alice&since=2018-06-01", nil)
+	assetID := 1
+	cryptoAssetID := "1"
+	mockDatabase.On("AuditLog", &database.AuditQuery{CryptoAssetID: &assetID, Actor: "alice",
+		Since: "2018-06-01T00:00:00Z"}).Return([]*models.AuditEntry{{
+		ID:            3,
+		CreatedAt:     "2018-06-02T10:00:00Z",
+		RequestID:     "request-1",
+		Actor:         "alice",
+		ClientIP:      "192.0.2.1",
+		Method:        "DELETE",
+		Path:          "/assets/1",
+		Action:        models.DeleteAction,
+		CryptoAssetID: &cryptoAssetID,
+		Status:        http.StatusNoContent,
+	}}, nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+	assertResponseBody(t, "[{\"id\":3,\"createdAt\":\"2018-06-02T10:00:00Z\",\"requestId\":\"request-1\","+
+		"\"actor\":\"alice\",\"clientIp\":\"192.0.2.1\",\"method\":\"DELETE\",\"path\":\"/assets/1\","+
+		"\"action\":\"delete\",\"cryptoAssetId\":\"1\",\"status\":204}]", recorder.Body.String())
+}
@@ -0,0 +1,167 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/paddyquinn/messari/database"
+	"github.com/paddyquinn/messari/database/models"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Header constants. An API key is passed either as a bearer token or in the X-API-Key header.
+	apiKeyHeader        = "X-API-Key"
+	authorizationHeader = "Authorization"
+	bearerPrefix        = "Bearer "
+
+	// apiKeyContextKey is the key the authenticated API key is stored under in the gin context.
+	apiKeyContextKey = "apiKey"
+
+	// Auth error string constants.
+	authenticateError = "could not authenticate the API key"
+	createKeyError    = "could not create the API key"
+	listKeysError     = "could not list the API keys"
+	missingKeyError   = "missing API key"
+	revokeKeyError    = "could not revoke the API key"
+)
+
+// authorize creates middleware that only lets a request through if it was made with an unrevoked API key that has the
+// given scope. Requests without an API key are let through to the endpoints that need the read scope if public reads
+// are allowed. Every request is let through if auth is off. Requests that are turned away are recorded in the audit
+// log.
+func (s *Server) authorize(scope string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !s.Config.Auth {
+			return
+		}
+
+		// Initialize the logger.
+		logger := log.WithFields(log.Fields{endpoint: ctx.Request.URL.Path, "scope": scope})
+
+		key := getAPIKey(ctx)
+		if key == "" {
+			if scope == models.ReadScope && s.Config.PublicRead {
+				return
+			}
+			logger.Error(missingKeyError)
+			ctx.Header("WWW-Authenticate", "Bearer")
+			s.deny(ctx, scope, http.StatusUnauthorized, missingAPIKeyCode, missingKeyError)
+			return
+		}
+
+		// Look up the API key in the database.
+		apiKey, err := s.DB.Authenticate(key)
+		if err != nil {
+			errString := err.Error()
+			logger.WithField(errKey, errString).Error(authenticateError)
+			if _, ok := err.(*database.InvalidAPIKeyError); ok {
+				ctx.Header("WWW-Authenticate", "Bearer")
+				s.deny(ctx, scope, http.StatusUnauthorized, invalidAPIKeyCode, errString)
+			} else {
+				s.deny(ctx, scope, http.StatusInternalServerError, internalErrorCode, internalServerError)
+			}
+			return
+		}
+
+		// Make sure the API key is allowed to use the endpoint.
+		if !apiKey.HasScope(scope) {
+			errString := fmt.Sprintf("API key does not have the %s scope", scope)
+			logger.WithField("apiKeyId", apiKey.ID).Error(errString)
+			ctx.Set(apiKeyContextKey, apiKey)
+			s.deny(ctx, scope, http.StatusForbidden, insufficientScopeCode, errString)
+			return
+		}
+
+		ctx.Set(apiKeyContextKey, apiKey)
+	}
+}
+
+// deny turns a request away with a problem with the given status code, code and error and records it in the audit
+// log, along with the scope it needed.
+func (s *Server) deny(ctx *gin.Context, scope string, status int, code, errString string) {
+	respondWithProblem(ctx, status, code, "", errString)
+	setAuditPayload(ctx, map[string]string{"scope": scope, errKey: errString})
+	s.recordAudit(ctx, models.DenyAction)
+}
+
+// createAPIKey issues a new API key with the name and scope passed in via the request body. The response is the only
+// time the key itself is shown.
+func (s *Server) createAPIKey(ctx *gin.Context) {
+	// Initialize the logger.
+	logger := log.WithField(endpoint, apiKeysEndpoint)
+
+	// Parse the API key passed in via the request body.
+	apiKey, err := models.NewAPIKey(ctx.Request.Body)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(parseError)
+		respondWithInvalidBody(ctx, err)
+		return
+	}
+
+	// The key itself is never recorded in the audit log.
+	setAuditPayload(ctx, map[string]string{"name": apiKey.Name, "scope": apiKey.Scope})
+
+	// Issue the API key.
+	createdAPIKey, err := s.DB.CreateAPIKey(apiKey.Name, apiKey.Scope)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(createKeyError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	// Return the API key back to the user.
+	ctx.JSON(http.StatusOK, createdAPIKey)
+}
+
+// listAPIKeys returns every API key that has been issued, without the keys themselves.
+func (s *Server) listAPIKeys(ctx *gin.Context) {
+	apiKeys, err := s.DB.APIKeys()
+	if err != nil {
+		log.WithFields(log.Fields{endpoint: apiKeysEndpoint, errKey: err.Error()}).Error(listKeysError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, apiKeys)
+}
+
+// revokeAPIKey revokes the API key with the id given in the path.
+func (s *Server) revokeAPIKey(ctx *gin.Context) {
+	// Initialize the logger.
+	logger := log.WithField(endpoint, apiKeyEndpoint)
+
+	// Parse the id from the path.
+	id, err := parseID(ctx)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(revokeKeyError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	setAuditPayload(ctx, map[string]int{idKey: id})
+
+	// Revoke the API key in the database.
+	if err = s.DB.RevokeAPIKey(id); err != nil {
+		logger.WithFields(log.Fields{idKey: id, errKey: err.Error()}).Error(revokeKeyError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	// There is nothing left to return to the user.
+	ctx.Status(http.StatusNoContent)
+}
+
+// getAPIKey returns the API key passed as a bearer token or in the X-API-Key header, or an empty string if there is
+// none. The X-API-Key header is stored as X-Api-Key, so it is looked up in canonical form rather than with
+// ctx.GetHeader(...), which does not canonicalize the key.
+func getAPIKey(ctx *gin.Context) string {
+	if authorization := strings.TrimSpace(ctx.GetHeader(authorizationHeader)); authorization != "" {
+		if len(authorization) > len(bearerPrefix) && strings.EqualFold(authorization[:len(bearerPrefix)], bearerPrefix) {
+			return strings.TrimSpace(authorization[len(bearerPrefix):])
+		}
+	}
+	return strings.TrimSpace(ctx.Request.Header.Get(apiKeyHeader))
+}
@@ -0,0 +1,320 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/paddyquinn/messari/config"
+	"github.com/paddyquinn/messari/database"
+	"github.com/paddyquinn/messari/database/models"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAuthorize(t *testing.T) {
+	// Hide logs.
+	log.SetLevel(log.FatalLevel)
+
+	// Set up router for testing.
+	gin.SetMode(gin.TestMode)
+	mockDatabase := &database.Mock{}
+	mockRouter := setUpMockAuthRouter(mockDatabase, true)
+
+	// Run tests. Every request that is turned away is recorded in the audit log.
+	testAuthorizePublicRead(t, mockRouter, mockDatabase)
+	testAuthorizeMissingKey(t, mockRouter, mockDatabase)
+	expectAudit(mockDatabase)
+	testAuthorizeInvalidKey(t, mockRouter, mockDatabase)
+	testAuthorizeMissingScope(t, mockRouter, mockDatabase)
+	testAuthorizeDatabaseError(t, mockRouter, mockDatabase)
+	testAuthorizeSuccess(t, mockRouter, mockDatabase)
+	testAuthorizePrivateRead(t)
+}
+
+func testAuthorizePublicRead(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call. No API key is needed to search.
+	req := httptest.NewRequest("GET", "/search?symbol=btc", nil)
+	mockDatabase.On("Select", &database.Query{Symbols: []string{"btc"}}).Return([]*models.CryptoAsset{}, "", nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+	assertResponseBody(t, "[]", recorder.Body.String())
+}
+
+func testAuthorizeMissingKey(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call.
+	req := httptest.NewRequest("DELETE", "/assets/1", nil)
+	req.Header.Set("X-Request-ID", "request-1")
+	mockDatabase.On("Audit", mock.MatchedBy(func(entry *models.AuditEntry) bool {
+		return entry.RequestID == "request-1" && entry.Actor == "anonymous" && entry.ClientIP == "192.0.2.1" &&
+			entry.Method == "DELETE" && entry.Path == "/assets/1" && entry.Action == models.DenyAction &&
+			entry.CryptoAssetID == nil && entry.Status == http.StatusUnauthorized &&
+			string(entry.Payload) == "{\"error\":\"missing API key\",\"scope\":\"write\"}"
+	})).Return(nil).Once()
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code, headers and body.
+	assertResponseCode(t, http.StatusUnauthorized, recorder.Code)
+	assertProblem(t, missingAPIKeyCode, "", "missing API key", recorder)
+	if challenge := recorder.Header().Get("WWW-Authenticate"); challenge != "Bearer" {
+		t.Fatalf("unexpected WWW-Authenticate header\n\nexpected: Bearer\nactual: %s", challenge)
+	}
+	if requestID := recorder.Header().Get("X-Request-ID"); requestID != "request-1" {
+		t.Fatalf("unexpected X-Request-ID header\n\nexpected: request-1\nactual: %s", requestID)
+	}
+}
+
+func testAuthorizeInvalidKey(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call.
+	req := httptest.NewRequest("DELETE", "/assets/1", nil)
+	req.Header.Set("Authorization", "Bearer revoked")
+	mockDatabase.On("Authenticate", "revoked").Return(nil, database.NewInvalidAPIKeyError())
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusUnauthorized, recorder.Code)
+	assertProblem(t, invalidAPIKeyCode, "", "invalid API key", recorder)
+}
+
+func testAuthorizeMissingScope(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call. A read key cannot delete.
+	req := httptest.NewRequest("DELETE", "/assets/1", nil)
+	req.Header.Set("X-API-Key", "reader")
+	mockDatabase.On("Authenticate", "reader").Return(&models.APIKey{ID: 1, Name: "dashboard",
+		Scope: models.ReadScope}, nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusForbidden, recorder.Code)
+	assertProblem(t, insufficientScopeCode, "", "API key does not have the write scope", recorder)
+}
+
+func testAuthorizeDatabaseError(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call.
+	req := httptest.NewRequest("DELETE", "/assets/1", nil)
+	req.Header.Set("X-API-Key", "unlucky")
+	mockDatabase.On("Authenticate", "unlucky").Return(nil, errors.New("mock database error"))
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusInternalServerError, recorder.Code)
+	assertProblem(t, internalErrorCode, "", "internal server error", recorder)
+}
+
+func testAuthorizeSuccess(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database calls. The name of the API key is recorded as the actor, whatever the
+	// X-Actor header says.
+	req := httptest.NewRequest("DELETE", "/assets/1", nil)
+	req.Header.Set("Authorization", "bearer writer")
+	req.Header.Set("X-Actor", "somebody else")
+	mockDatabase.On("Authenticate", "writer").Return(&models.APIKey{ID: 2, Name: "ci-bot",
+		Scope: models.WriteScope}, nil)
+	mockDatabase.On("Delete", 1, (*int)(nil), "ci-bot").Return(nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusNoContent, recorder.Code)
+	assertResponseBody(t, "", recorder.Body.String())
+}
+
+func testAuthorizePrivateRead(t *testing.T) {
+	// Create the response recorder and a router that does not allow public reads.
+	recorder := httptest.NewRecorder()
+	mockDatabase := &database.Mock{}
+	mockRouter := setUpMockAuthRouter(mockDatabase, false)
+	expectAudit(mockDatabase)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, httptest.NewRequest("GET", "/search?symbol=btc", nil))
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusUnauthorized, recorder.Code)
+	assertProblem(t, missingAPIKeyCode, "", "missing API key", recorder)
+}
+
+func TestAPIKeyEndpoints(t *testing.T) {
+	// Hide logs.
+	log.SetLevel(log.FatalLevel)
+
+	// Set up router for testing. Every request is made with an admin key.
+	gin.SetMode(gin.TestMode)
+	mockDatabase := &database.Mock{}
+	mockRouter := setUpMockAuthRouter(mockDatabase, true)
+	expectAudit(mockDatabase)
+	mockDatabase.On("Authenticate", "admin").Return(&models.APIKey{ID: 1, Name: "root", Scope: models.AdminScope},
+		nil)
+
+	// Run tests.
+	testCreateAPIKeyInvalidScope(t, mockRouter, mockDatabase)
+	testCreateAPIKeySuccess(t, mockRouter, mockDatabase)
+	testListAPIKeys(t, mockRouter, mockDatabase)
+	testRevokeAPIKeyUnknownID(t, mockRouter, mockDatabase)
+	testRevokeAPIKeySuccess(t, mockRouter, mockDatabase)
+}
+
+func testCreateAPIKeyInvalidScope(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call.
+	req := httptest.NewRequest("POST", "/keys", strings.NewReader("{\"name\":\"ci-bot\",\"scope\":\"root\"}"))
+	req.Header.Set("X-API-Key", "admin")
+	mockDatabase.On("CreateAPIKey", "ci-bot", "root").Return(nil, database.NewInvalidScopeError("root"))
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusBadRequest, recorder.Code)
+	assertProblem(t, invalidFieldCode, "scope", "invalid scope root: must be read, propose, write, approve or admin",
+		recorder)
+}
+
+func testCreateAPIKeySuccess(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call. The name and scope are trimmed and the scope is lower cased.
+	req := httptest.NewRequest("POST", "/keys", strings.NewReader("{\"name\":\" ci-bot \",\"scope\":\"Write\"}"))
+	req.Header.Set("X-API-Key", "admin")
+	mockDatabase.On("CreateAPIKey", "ci-bot", "write").Return(&models.APIKey{ID: 2, Name: "ci-bot",
+		Scope: "write", CreatedAt: "2018-06-01T12:00:00Z", Key: "0123abcd"}, nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+	assertResponseBody(t, "{\"id\":2,\"name\":\"ci-bot\",\"scope\":\"write\",\"createdAt\":\"2018-06-01T12:00:00Z\","+
+		"\"key\":\"0123abcd\"}", recorder.Body.String())
+}
+
+func testListAPIKeys(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call.
+	revokedAt := "2018-06-02T12:00:00Z"
+	req := httptest.NewRequest("GET", "/keys", nil)
+	req.Header.Set("X-API-Key", "admin")
+	mockDatabase.On("APIKeys").Return([]*models.APIKey{{ID: 2, Name: "ci-bot", Scope: "write",
+		CreatedAt: "2018-06-01T12:00:00Z", RevokedAt: &revokedAt}}, nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+	assertResponseBody(t, "[{\"id\":2,\"name\":\"ci-bot\",\"scope\":\"write\",\"createdAt\":\"2018-06-01T12:00:00Z\","+
+		"\"revokedAt\":\"2018-06-02T12:00:00Z\"}]", recorder.Body.String())
+}
+
+func testRevokeAPIKeyUnknownID(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call.
+	req := httptest.NewRequest("DELETE", "/keys/7", nil)
+	req.Header.Set("X-API-Key", "admin")
+	mockDatabase.On("RevokeAPIKey", 7).Return(database.NewUnknownAPIKeyError(7))
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusNotFound, recorder.Code)
+	assertProblem(t, apiKeyNotFoundCode, "", "API key with id 7 not found", recorder)
+}
+
+func testRevokeAPIKeySuccess(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call.
+	req := httptest.NewRequest("DELETE", "/keys/2", nil)
+	req.Header.Set("X-API-Key", "admin")
+	mockDatabase.On("RevokeAPIKey", 2).Return(nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusNoContent, recorder.Code)
+	assertResponseBody(t, "", recorder.Body.String())
+}
+
+// setUpMockAuthRouter sets up a router with auth on.
+func setUpMockAuthRouter(mockDatabase *database.Mock, publicRead bool) *gin.Engine {
+	cfg := config.Default()
+	cfg.PublicRead = publicRead
+	server := NewServer(mockDatabase, cfg)
+	return server.initializeRouter()
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// deadlineError is logged when a deadline of the connection a request was made on cannot be lifted.
+const deadlineError = "could not lift the deadline of the connection"
+
+// responseControllerKey is the key the response controller of a request is kept under in the context of the request.
+type responseControllerKey struct{}
+
+// withResponseController wraps the router so that a handler can lift the deadlines the read and write timeouts set on
+// its connection. The version of gin in use does not let its response writer be unwrapped, which
+// http.ResponseController needs, so the controller of the underlying response writer is kept in the context of the
+// request instead.
+func withResponseController(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), responseControllerKey{}, http.NewResponseController(w))
+		handler.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// liftWriteDeadline lets the response to the request take as long as it needs to be written, however long the write
+// timeout is. Nothing is done for a request that was not made through withResponseController, as in tests.
+func liftWriteDeadline(ctx *gin.Context) error {
+	controller, ok := ctx.Request.Context().Value(responseControllerKey{}).(*http.ResponseController)
+	if !ok {
+		return nil
+	}
+	return controller.SetWriteDeadline(time.Time{})
+}
+
+// liftDeadlines is middleware that lets the request take as long as it needs to be read and answered, however long
+// the read and write timeouts are, so that a large file can be uploaded over a slow connection. The write deadline
+// is lifted too because it is set when the request starts to be read. It must come before anything that reads the
+// request body, such as idempotent. Nothing is done for a request that was not made through withResponseController.
+func liftDeadlines(ctx *gin.Context) {
+	controller, ok := ctx.Request.Context().Value(responseControllerKey{}).(*http.ResponseController)
+	if !ok {
+		return
+	}
+	err := controller.SetReadDeadline(time.Time{})
+	if err == nil {
+		err = controller.SetWriteDeadline(time.Time{})
+	}
+	if err != nil {
+		log.WithFields(log.Fields{endpoint: ctx.Request.URL.Path, errKey: err.Error()}).Error(deadlineError)
+	}
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/paddyquinn/messari/database/models"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// dryRunParam asks for a write to be validated without being made.
+	dryRunParam = "dryRun"
+
+	// dryRunContextKey is the context key a dry run is flagged under. Nothing is written by a dry run, so it is left out
+	// of the audit log.
+	dryRunContextKey = "dryRun"
+)
+
+// parseDryRun parses the "dryRun" query string parameter and flags a dry run in the context.
+func parseDryRun(ctx *gin.Context) (bool, error) {
+	dryRun, err := parseBoolean(ctx, dryRunParam)
+	if err != nil || dryRun == nil || !*dryRun {
+		return false, err
+	}
+
+	ctx.Set(dryRunContextKey, true)
+	return true, nil
+}
+
+// respondWithDryRunInsert responds to a dry run of a registration with the crypto asset as it would be registered,
+// without an id and without registering it. The insert is made and rolled back, so the dry run fails whenever the real
+// registration would.
+func (s *Server) respondWithDryRunInsert(ctx *gin.Context, logger *log.Entry, cryptoAsset *models.CryptoAsset) {
+	insertedCryptoAsset, err := s.DB.DryRunInsert(cryptoAsset, getActor(ctx))
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(insertError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	insertedCryptoAsset.ID = nil
+	insertedCryptoAsset.Format()
+	ctx.JSON(http.StatusOK, insertedCryptoAsset)
+}
+
+// respondWithDryRunUpdate responds to a dry run of an update with the crypto asset with the given id as it would be
+// after the update, along with the version the update would give it as its ETag, without making it. The update is made
+// and rolled back, so the dry run fails whenever the real update would.
+func (s *Server) respondWithDryRunUpdate(ctx *gin.Context, logger *log.Entry, id int, update *models.CryptoAsset) {
+	updatedCryptoAsset, err := s.DB.DryRunUpdate(id, update, getActor(ctx))
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(updateError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	setETag(ctx, updatedCryptoAsset)
+	updatedCryptoAsset.Format()
+	ctx.JSON(http.StatusOK, updatedCryptoAsset)
+}
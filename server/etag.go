@@ -0,0 +1,73 @@
+package server
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/paddyquinn/messari/database/models"
+)
+
+const (
+	// Conditional request header constants. The ETag of a crypto asset is its version in quotes, e.g. "3".
+	anyETag           = "*"
+	etagHeader        = "ETag"
+	ifMatchHeader     = "If-Match"
+	ifNoneMatchHeader = "If-None-Match"
+	weakETagPrefix    = "W/"
+
+	// ifMatchError is logged when the If-Match header cannot be parsed.
+	ifMatchError = "unable to parse the If-Match header"
+)
+
+// setETag sends the version of the crypto asset back to the user as its ETag, if the version is known.
+func setETag(ctx *gin.Context, cryptoAsset *models.CryptoAsset) {
+	if cryptoAsset.Version != nil {
+		ctx.Header(etagHeader, formatETag(*cryptoAsset.Version))
+	}
+}
+
+// formatETag formats a version as an ETag.
+func formatETag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// parseIfMatch extracts the version a write expects the crypto asset to be at from the If-Match header. Nil is returned
+// if the header is missing or is "*", which any version matches. An error is returned if the header is not a single
+// ETag of a crypto asset.
+func parseIfMatch(ctx *gin.Context) (*int, error) {
+	ifMatch := strings.TrimSpace(ctx.GetHeader(ifMatchHeader))
+	if ifMatch == "" || ifMatch == anyETag {
+		return nil, nil
+	}
+
+	versionString, err := strconv.Unquote(ifMatch)
+	if err != nil || !strings.HasPrefix(ifMatch, "\"") {
+		return nil, newInvalidParameterError(ifMatchHeader, ifMatch)
+	}
+	version, err := strconv.Atoi(versionString)
+	if err != nil {
+		return nil, newInvalidParameterError(ifMatchHeader, ifMatch)
+	}
+	return &version, nil
+}
+
+// isNotModified determines whether the If-None-Match header matches the ETag of the crypto asset, in which case the
+// user already has the crypto asset as it is. The header can list several ETags, and weak ETags match too.
+func isNotModified(ctx *gin.Context, cryptoAsset *models.CryptoAsset) bool {
+	ifNoneMatch := strings.TrimSpace(ctx.GetHeader(ifNoneMatchHeader))
+	if ifNoneMatch == "" || cryptoAsset.Version == nil {
+		return false
+	}
+	if ifNoneMatch == anyETag {
+		return true
+	}
+
+	etag := formatETag(*cryptoAsset.Version)
+	for _, candidate := range strings.Split(ifNoneMatch, comma) {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), weakETagPrefix) == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/paddyquinn/messari/database/models"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// schemaVersionHeader holds the version of the database schema an export was made from.
+	schemaVersionHeader = "X-Schema-Version"
+
+	// schemaVersionError is logged when the version of the database schema cannot be found.
+	schemaVersionError = "could not get the schema version of the database"
+)
+
+// exportContentTypes maps the formats crypto assets can be exported in to their content types.
+var exportContentTypes = map[string]string{
+	models.CSVFormat:    "text/csv; charset=utf-8",
+	models.JSONFormat:   "application/json; charset=utf-8",
+	models.NDJSONFormat: "application/x-ndjson",
+	models.TSVFormat:    "text/tab-separated-values; charset=utf-8",
+}
+
+// exportAssets streams every crypto asset matching the search parameters passed in via the query string to the user
+// as a file, writing each crypto asset as soon as it is read from the database. The format is taken from the "format"
+// query string parameter, which is one of csv, tsv, json and ndjson, and is json if it is not passed. An export cannot
+// be paginated. The version of the database schema is written at the top of the file and in the X-Schema-Version
+// header. An export can take longer to write than the write timeout allows, so it lifts the timeout. See
+// models.NewExporter.
+func (s *Server) exportAssets(ctx *gin.Context) {
+	// Initialize the logger.
+	logger := log.WithField(endpoint, exportEndpoint)
+
+	// Parse the format and the query passed in via the query string.
+	format := models.JSONFormat
+	if formatString, ok := ctx.GetQuery(formatParam); ok {
+		format = strings.ToLower(strings.TrimSpace(formatString))
+		if !models.IsExportFormat(format) {
+			err := newInvalidParameterError(formatParam, format)
+			logger.WithField(errKey, err.Error()).Error(queryError)
+			respondWithError(ctx, err)
+			return
+		}
+	}
+	query, err := parseQueryString(ctx)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(queryError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	// Search for the values that the funding statuses and coin types passed in are aliases of.
+	if err = s.canonicalize(query); err != nil {
+		logger.WithField(errKey, err.Error()).Error(vocabulariesError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	// Reading and writing every crypto asset can take longer than the write timeout, which would cut the file short.
+	if err = liftWriteDeadline(ctx); err != nil {
+		logger.WithField(errKey, err.Error()).Error(deadlineError)
+	}
+
+	schemaVersion, err := s.DB.SchemaVersion()
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(schemaVersionError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	// The file is only started once the first crypto asset has been read, so that an error in the query can still be
+	// returned as a problem.
+	var exporter models.Exporter
+	start := func() error {
+		ctx.Header("Content-Type", exportContentTypes[format])
+		ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"crypto-assets.%s\"", format))
+		ctx.Header(schemaVersionHeader, strconv.Itoa(schemaVersion))
+		ctx.Status(http.StatusOK)
+		exporter, err = models.NewExporter(ctx.Writer, format,
+			models.ExportColumns(query.Fields, query.IncludeDeleted), query.Fields, schemaVersion)
+		return err
+	}
+
+	// Format each crypto asset and write it to the file as it is read from the database.
+	err = s.DB.Export(query, func(cryptoAsset *models.CryptoAsset) error {
+		if exporter == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		cryptoAsset.Format()
+		return exporter.Write(cryptoAsset)
+	})
+	if err == nil && exporter == nil {
+		err = start()
+	}
+	if err == nil {
+		err = exporter.Close()
+	}
+
+	// Once part of the file has been written, the response can only be cut short.
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(exportError)
+		if ctx.Writer.Written() {
+			ctx.Abort()
+			return
+		}
+		respondWithError(ctx, err)
+	}
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/paddyquinn/messari/database"
+	"github.com/paddyquinn/messari/database/models"
+	log "github.com/sirupsen/logrus"
+)
+
+func TestExport(t *testing.T) {
+	// Hide logs.
+	log.SetLevel(log.FatalLevel)
+
+	// Set up router for testing.
+	gin.SetMode(gin.TestMode)
+	mockDatabase := &database.Mock{}
+	mockRouter := setUpMockRouter(mockDatabase)
+
+	// Run tests.
+	testExportInvalidFormat(t, mockRouter, mockDatabase)
+	mockDatabase.On("SchemaVersion").Return(11, nil)
+	testExportPaginated(t, mockRouter, mockDatabase)
+	testExportCSV(t, mockRouter, mockDatabase)
+	testExportPastWriteTimeout(t, mockDatabase)
+}
+
+func testExportInvalidFormat(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request.
+	req := httptest.NewRequest("GET", "/export?format=xml", nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusBadRequest, recorder.Code)
+	assertProblem(t, invalidParameterCode, formatParam, "invalid format: xml", recorder)
+}
+
+func testExportPaginated(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call. Nothing has been written when the query fails, so the error is
+	// returned as a problem.
+	req := httptest.NewRequest("GET", "/export?limit=5", nil)
+	mockDatabase.On("Export", &database.Query{Limit: 5}).
+		Return(nil, database.NewUnsupportedQueryError("an export cannot be paginated"))
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusBadRequest, recorder.Code)
+	assertProblem(t, unsupportedQueryCode, "", "an export cannot be paginated", recorder)
+}
+
+func testExportCSV(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database calls. The funding status is searched for by the value it is an alias
+	// of, and each crypto asset is formatted.
+	expectVocabularies(mockDatabase)
+	bitcoinID, bitcoinName, rippleID, rippleName := "1", "bitcoin", "2", "ripple"
+	req := httptest.NewRequest("GET", "/export?format=csv&fundingStatus=presale&fields=name,team", nil)
+	mockDatabase.On("Export", &database.Query{FundingStatuses: []string{"pre-ico"}, Fields: []string{"name", "team"}}).
+		Return([]*models.CryptoAsset{
+			{ID: &bitcoinID, Name: &bitcoinName, Team: []string{"Satoshi Nakamoto", "Hal Finney"}},
+			{ID: &rippleID, Name: &rippleName, Team: []string{}},
+		}, nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code, headers and body.
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "text/csv; charset=utf-8" {
+		t.Fatalf("unexpected content type: %s", contentType)
+	}
+	if schemaVersion := recorder.Header().Get(schemaVersionHeader); schemaVersion != "11" {
+		t.Fatalf("unexpected schema version: %s", schemaVersion)
+	}
+	assertResponseBody(t, "# schemaVersion 11\nid,name,team\n1,Bitcoin,Satoshi Nakamoto;Hal Finney\n2,Ripple,\n",
+		recorder.Body.String())
+}
+
+func testExportPastWriteTimeout(t *testing.T, mockDatabase *database.Mock) {
+	// Serve the router with a write timeout that is up before the export is written.
+	httpServer := startTestServer(mockDatabase, 0, 50*time.Millisecond)
+	defer httpServer.Close()
+
+	// Prepare the mock database call. Reading the crypto assets takes longer than the write timeout.
+	bitcoinID, bitcoinName := "1", "bitcoin"
+	mockDatabase.On("Export", &database.Query{Fields: []string{"name"}}).
+		Return([]*models.CryptoAsset{{ID: &bitcoinID, Name: &bitcoinName}}, nil).After(100 * time.Millisecond)
+
+	// Make the request.
+	resp, err := http.Get(httpServer.URL + "/export?format=csv&fields=name")
+	if err != nil {
+		t.Fatalf("unexpected error exporting past the write timeout: %s", err.Error())
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("unexpected error reading an export past the write timeout: %s", err.Error())
+	}
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusOK, resp.StatusCode)
+	assertResponseBody(t, "# schemaVersion 11\nid,name\n1,Bitcoin\n", string(body))
+}
@@ -0,0 +1,178 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+	"github.com/paddyquinn/messari/database/models"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Response format constants. JSON and CSV are named the same as the export formats.
+	msgpackFormat = "msgpack"
+	yamlFormat    = "yaml"
+
+	// Content negotiation header constants. The cursor to the next page of a paginated search returned as CSV, which
+	// has nowhere else to put it, is returned in the X-Next-Cursor header.
+	acceptHeader     = "Accept"
+	nextCursorHeader = "X-Next-Cursor"
+	varyHeader       = "Vary"
+
+	// Content negotiation error string constants.
+	negotiateError = "unable to negotiate the format of the response"
+	writeCSVError  = "could not write the crypto assets as CSV"
+)
+
+// acceptedFormats maps the media types of the Accept header to the formats crypto assets can be returned in.
+var acceptedFormats = map[string]string{
+	"*/*":                   models.JSONFormat,
+	"application/*":         models.JSONFormat,
+	"application/json":      models.JSONFormat,
+	"application/msgpack":   msgpackFormat,
+	"application/x-msgpack": msgpackFormat,
+	"application/x-yaml":    yamlFormat,
+	"application/yaml":      yamlFormat,
+	"text/*":                models.CSVFormat,
+	"text/csv":              models.CSVFormat,
+	"text/x-yaml":           yamlFormat,
+	"text/yaml":             yamlFormat,
+}
+
+// notAcceptableError represents an error when none of the media types in the Accept header is a format crypto assets
+// can be returned in.
+type notAcceptableError struct {
+	accept string
+}
+
+// newNotAcceptableError creates a new not acceptable error with the Accept header.
+func newNotAcceptableError(accept string) *notAcceptableError {
+	return &notAcceptableError{accept: accept}
+}
+
+// Error makes notAcceptableError adhere to the error interface. The Accept header is returned in the string.
+func (n *notAcceptableError) Error() string {
+	return fmt.Sprintf("cannot respond with any of %s: accept application/json, text/csv, application/x-yaml or "+
+		"application/msgpack, or pass format=json, csv, yaml or msgpack", n.accept)
+}
+
+// isResponseFormat determines whether the given string is a format crypto assets can be returned in.
+func isResponseFormat(format string) bool {
+	return format == models.JSONFormat || format == models.CSVFormat || format == yamlFormat || format == msgpackFormat
+}
+
+// negotiateFormat picks the format to return crypto assets in. The "format" query string parameter wins if it is
+// passed. Otherwise the media types of the Accept header are tried from the highest quality to the lowest, and JSON is
+// returned if there is no Accept header. An error is returned if the format is not one crypto assets can be returned
+// in, or if none of the accepted media types is.
+func negotiateFormat(ctx *gin.Context) (string, error) {
+	if format, ok := ctx.GetQuery(formatParam); ok {
+		format = strings.ToLower(strings.TrimSpace(format))
+		if !isResponseFormat(format) {
+			return "", newInvalidParameterError(formatParam, format)
+		}
+		return format, nil
+	}
+
+	accept := strings.TrimSpace(ctx.GetHeader(acceptHeader))
+	if accept == "" {
+		return models.JSONFormat, nil
+	}
+
+	// Each media type can have a quality, e.g. "text/csv;q=0.5", which is 1 if it is missing. A media type with a
+	// quality of 0 is not acceptable. Media types of the same quality are tried in the order they are listed.
+	type mediaRange struct {
+		mediaType string
+		quality   float64
+	}
+	var mediaRanges []mediaRange
+	for _, part := range strings.Split(accept, comma) {
+		params := strings.Split(part, ";")
+		mediaRange := mediaRange{mediaType: strings.ToLower(strings.TrimSpace(params[0])), quality: 1}
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if quality, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil {
+					mediaRange.quality = quality
+				}
+			}
+		}
+		if mediaRange.quality > 0 {
+			mediaRanges = append(mediaRanges, mediaRange)
+		}
+	}
+	sort.SliceStable(mediaRanges, func(i, j int) bool {
+		return mediaRanges[i].quality > mediaRanges[j].quality
+	})
+
+	for _, mediaRange := range mediaRanges {
+		if format, ok := acceptedFormats[mediaRange.mediaType]; ok {
+			return format, nil
+		}
+	}
+	return "", newNotAcceptableError(accept)
+}
+
+// respondWithCryptoAssets is the pipeline every read of crypto assets responds through. Each crypto asset is formatted
+// and projected onto the fields, if there are any, and the results are returned in the given format. As JSON, YAML or
+// MessagePack the results are wrapped by wrap, unless it is nil. As CSV each crypto asset is a row, with the columns
+// of an export. The response varies with the Accept header.
+func respondWithCryptoAssets(ctx *gin.Context, format string, cryptoAssets []*models.CryptoAsset, fields []string,
+	includeDeleted bool, wrap func(results interface{}) interface{}) {
+
+	ctx.Header(varyHeader, acceptHeader)
+	for _, cryptoAsset := range cryptoAssets {
+		cryptoAsset.Format()
+	}
+
+	// A CSV file holds the projection in its columns.
+	if format == models.CSVFormat {
+		ctx.Header("Content-Type", "text/csv; charset=utf-8")
+		ctx.Status(http.StatusOK)
+		exporter, err := models.NewTabularExporter(ctx.Writer, false, models.ExportColumns(fields, includeDeleted))
+		for idx := 0; err == nil && idx < len(cryptoAssets); idx++ {
+			err = exporter.Write(cryptoAssets[idx])
+		}
+		if err == nil {
+			err = exporter.Close()
+		}
+		if err != nil {
+			log.WithField(errKey, err.Error()).Error(writeCSVError)
+		}
+		return
+	}
+
+	var results interface{} = cryptoAssets
+	if len(fields) > 0 {
+		projectedCryptoAssets := make([]map[string]interface{}, len(cryptoAssets))
+		for idx, cryptoAsset := range cryptoAssets {
+			projectedCryptoAssets[idx] = cryptoAsset.Project(fields)
+		}
+		results = projectedCryptoAssets
+	}
+	if wrap != nil {
+		results = wrap(results)
+	}
+
+	switch format {
+	case msgpackFormat:
+		ctx.Render(http.StatusOK, render.MsgPack{Data: results})
+	case yamlFormat:
+		ctx.YAML(http.StatusOK, results)
+	default:
+		ctx.JSON(http.StatusOK, results)
+	}
+}
+
+// respondWithCryptoAsset responds with a single crypto asset in the given format. See respondWithCryptoAssets.
+func respondWithCryptoAsset(ctx *gin.Context, format string, cryptoAsset *models.CryptoAsset) {
+	respondWithCryptoAssets(ctx, format, []*models.CryptoAsset{cryptoAsset}, nil, cryptoAsset.DeletedAt != nil,
+		func(results interface{}) interface{} {
+			return cryptoAsset
+		})
+}
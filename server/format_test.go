@@ -0,0 +1,130 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/paddyquinn/messari/database"
+	"github.com/paddyquinn/messari/database/models"
+	log "github.com/sirupsen/logrus"
+)
+
+func Test_negotiateFormat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	negotiateFormatTests := []struct {
+		url            string
+		accept         string
+		expectedFormat string
+		expectedErr    bool
+	}{
+		{"/assets", "", models.JSONFormat, false},
+		{"/assets", "text/csv", models.CSVFormat, false},
+		{"/assets", "application/json;q=0.5, application/x-yaml", yamlFormat, false},
+		{"/assets", "application/xml, application/msgpack;q=0.1", msgpackFormat, false},
+		{"/assets", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", models.JSONFormat, false},
+		{"/assets", "text/csv;q=0, application/xml", "", true},
+		{"/assets?format=YAML", "text/csv", yamlFormat, false},
+		{"/assets?format=pdf", "", "", true},
+	}
+	for _, negotiateFormatTest := range negotiateFormatTests {
+		// Create a context for the request.
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Request = httptest.NewRequest("GET", negotiateFormatTest.url, nil)
+		ctx.Request.Header.Set("Accept", negotiateFormatTest.accept)
+
+		// Assert the expected format is negotiated.
+		format, err := negotiateFormat(ctx)
+		if (err != nil) != negotiateFormatTest.expectedErr || format != negotiateFormatTest.expectedFormat {
+			t.Fatalf("unexpected format negotiated for %s with Accept %q: %s, %v", negotiateFormatTest.url,
+				negotiateFormatTest.accept, format, err)
+		}
+	}
+}
+
+func TestContentNegotiation(t *testing.T) {
+	// Hide logs.
+	log.SetLevel(log.FatalLevel)
+
+	// Set up router for testing.
+	gin.SetMode(gin.TestMode)
+	mockDatabase := &database.Mock{}
+	mockRouter := setUpMockRouter(mockDatabase)
+
+	// Run tests.
+	testGetAssetYAML(t, mockRouter, mockDatabase)
+	testSearchCSV(t, mockRouter, mockDatabase)
+	testSearchNotAcceptable(t, mockRouter, mockDatabase)
+}
+
+func testGetAssetYAML(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call.
+	req := httptest.NewRequest("GET", "/assets/1", nil)
+	req.Header.Set("Accept", "application/x-yaml")
+	mockDatabase.On("Get", 1).Return(newBitcoin(), nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body. The YAML keys are the same as the JSON keys.
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "application/x-yaml; charset=utf-8" {
+		t.Fatalf("unexpected content type: %s", contentType)
+	}
+	assertResponseBody(t, "id: \"1\"\nname: Bitcoin\nsymbol: BTC\ndescription: The original cryptocurrency\n"+
+		"team:\n- Satoshi Nakomoto\nicoAmount: 0\nblockReward: 12.5\nfundingStatus: NO-ICO\n"+
+		"foundedDate: \"2009-01-03\"\ncoinType: Currency\nwebsite: https://bitcoin.org/en/\n", recorder.Body.String())
+}
+
+func testSearchCSV(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call. CSV has nowhere to put the cursor to the next page but a header.
+	req := httptest.NewRequest("GET", "/search?format=csv&limit=1&fields=symbol,team", nil)
+	mockDatabase.On("Select", &database.Query{Limit: 1, Fields: []string{"symbol", "team"}}).
+		Return([]*models.CryptoAsset{newBitcoin()}, "next", nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code, headers and body.
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "text/csv; charset=utf-8" {
+		t.Fatalf("unexpected content type: %s", contentType)
+	}
+	if nextCursor := recorder.Header().Get(nextCursorHeader); nextCursor != "next" {
+		t.Fatalf("unexpected next cursor: %s", nextCursor)
+	}
+	assertResponseBody(t, "id,symbol,team\n1,BTC,Satoshi Nakomoto\n", recorder.Body.String())
+}
+
+func testSearchNotAcceptable(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request. Nothing is searched for if the results cannot be returned.
+	req := httptest.NewRequest("GET", "/search", nil)
+	req.Header.Set("Accept", "application/xml")
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusNotAcceptable, recorder.Code)
+	assertProblem(t, notAcceptableCode, acceptHeader, newNotAcceptableError("application/xml").Error(), recorder)
+}
@@ -0,0 +1,170 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/paddyquinn/messari/database/models"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Idempotency header constants. A retry of a request made with an Idempotency-Key header gets the response to the
+	// original request, marked by the Idempotent-Replayed header, instead of being handled again.
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	contentTypeHeader         = "Content-Type"
+	idempotencyKeyLengthError = "Idempotency-Key cannot be longer than 255 characters"
+
+	// Idempotency error string constants.
+	idempotencyError           = "could not handle the Idempotency-Key"
+	idempotencyInProgressError = "a request with this Idempotency-Key is still being handled"
+	idempotencyMismatchError   = "Idempotency-Key has already been used for a different request"
+	readBodyError              = "unable to read the request body"
+	saveResponseError          = "could not store the response to the request with the Idempotency-Key"
+)
+
+// replayedHeaders lists the response headers that are stored along with the response body and replayed to retries.
+var replayedHeaders = []string{contentTypeHeader, etagHeader}
+
+// bodyRecorder is a response writer that keeps a copy of the response body.
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+// Write writes the data to the response and keeps a copy of it.
+func (recorder *bodyRecorder) Write(data []byte) (int, error) {
+	recorder.body.Write(data)
+	return recorder.ResponseWriter.Write(data)
+}
+
+// WriteString writes the string to the response and keeps a copy of it.
+func (recorder *bodyRecorder) WriteString(s string) (int, error) {
+	recorder.body.WriteString(s)
+	return recorder.ResponseWriter.WriteString(s)
+}
+
+// idempotent is middleware that makes a request with an Idempotency-Key header safe to retry. The response to the
+// first request with a key is stored for the configured TTL and replayed to every retry with the same key and the same
+// method, path, query string and body. A retry with a different request gets a 422 and a retry while the first request
+// is still being handled gets a 409. Responses with a 5xx status code are not stored, and neither are requests whose
+// handler panics, so those requests can be retried for real. Keys belong to the actor that used them.
+func (s *Server) idempotent(ctx *gin.Context) {
+	key := strings.TrimSpace(ctx.GetHeader(idempotencyKeyHeader))
+	if key == "" {
+		return
+	}
+
+	// Initialize the logger.
+	logger := log.WithFields(log.Fields{endpoint: ctx.Request.URL.Path, idempotencyKeyHeader: key})
+
+	if len(key) > maxIdempotencyKeyLength {
+		logger.Error(idempotencyKeyLengthError)
+		respondWithError(ctx, newParameterError(idempotencyKeyHeader, idempotencyKeyLengthError))
+		return
+	}
+
+	// Read the request body to hash it, then put it back for the handler to read.
+	body, err := ioutil.ReadAll(ctx.Request.Body)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(readBodyError)
+		respondWithInvalidBody(ctx, err)
+		return
+	}
+	ctx.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	// Reserve the key for this request, unless it has already been used.
+	now := time.Now().UTC()
+	idempotencyKey := &models.IdempotencyKey{
+		Key:         key,
+		Actor:       getActor(ctx),
+		RequestHash: hashRequest(ctx.Request, body),
+		CreatedAt:   now.Format(time.RFC3339),
+		ExpiresAt:   now.Add(s.Config.IdempotencyTTL).Format(time.RFC3339),
+	}
+	storedKey, err := s.DB.ReserveIdempotencyKey(idempotencyKey)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(idempotencyError)
+		respondWithError(ctx, err)
+		return
+	}
+	if storedKey != nil {
+		replayIdempotentResponse(ctx, logger, idempotencyKey, storedKey)
+		return
+	}
+
+	// Give up the key if the request fails in a way that retrying might fix. That includes a panic in the handler, which
+	// the recovery middleware only turns into a 500 once this middleware has unwound, so the key is released on the way.
+	release := true
+	defer func() {
+		if !release {
+			return
+		}
+		if err := s.DB.ReleaseIdempotencyKey(idempotencyKey.Key, idempotencyKey.Actor); err != nil {
+			logger.WithField(errKey, err.Error()).Error(saveResponseError)
+		}
+	}()
+
+	// Handle the request, keeping a copy of the response.
+	recorder := &bodyRecorder{ResponseWriter: ctx.Writer, body: &bytes.Buffer{}}
+	ctx.Writer = recorder
+	ctx.Next()
+
+	// Store the response, unless the request failed in a way that retrying might fix.
+	if ctx.Writer.Status() >= http.StatusInternalServerError {
+		return
+	}
+	release = false
+	idempotencyKey.Status = ctx.Writer.Status()
+	idempotencyKey.Header = make(map[string]string)
+	for _, header := range replayedHeaders {
+		if value := recorder.Header().Get(header); value != "" {
+			idempotencyKey.Header[header] = value
+		}
+	}
+	idempotencyKey.Body = recorder.body.Bytes()
+	if err = s.DB.SaveIdempotentResponse(idempotencyKey); err != nil {
+		logger.WithField(errKey, err.Error()).Error(saveResponseError)
+	}
+}
+
+// replayIdempotentResponse responds to a request whose idempotency key has already been used with the response to the
+// request it was first used for, provided that the two requests are the same and the first has been responded to.
+func replayIdempotentResponse(ctx *gin.Context, logger *log.Entry, idempotencyKey,
+	storedKey *models.IdempotencyKey) {
+
+	switch {
+	case storedKey.RequestHash != idempotencyKey.RequestHash:
+		logger.Error(idempotencyMismatchError)
+		respondWithProblem(ctx, http.StatusUnprocessableEntity, idempotencyKeyReusedCode, idempotencyKeyHeader,
+			idempotencyMismatchError)
+	case !storedKey.IsComplete():
+		logger.Error(idempotencyInProgressError)
+		respondWithProblem(ctx, http.StatusConflict, idempotencyKeyInUseCode, idempotencyKeyHeader,
+			idempotencyInProgressError)
+	default:
+		for header, value := range storedKey.Header {
+			ctx.Header(header, value)
+		}
+		ctx.Header(idempotentReplayedHeader, "true")
+		ctx.Data(storedKey.Status, storedKey.Header[contentTypeHeader], storedKey.Body)
+		ctx.Abort()
+	}
+}
+
+// hashRequest hashes the method, path, query string and body of a request.
+func hashRequest(request *http.Request, body []byte) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s\n", request.Method, request.URL.RequestURI())
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
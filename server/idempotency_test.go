@@ -0,0 +1,222 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/paddyquinn/messari/database"
+	"github.com/paddyquinn/messari/database/models"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestIdempotency(t *testing.T) {
+	// Hide logs.
+	log.SetLevel(log.FatalLevel)
+
+	// Set up router for testing.
+	gin.SetMode(gin.TestMode)
+	mockDatabase := &database.Mock{}
+	mockRouter := setUpMockRouter(mockDatabase)
+	expectVocabularies(mockDatabase)
+	expectAudit(mockDatabase)
+
+	// Run tests.
+	testIdempotencyKeyTooLong(t, mockRouter)
+	testIdempotencySaveResponse(t, mockRouter, mockDatabase)
+	testIdempotencyReplay(t, mockRouter, mockDatabase)
+	testIdempotencyMismatch(t, mockRouter, mockDatabase)
+	testIdempotencyInProgress(t, mockRouter, mockDatabase)
+	testIdempotencyReleaseKey(t, mockRouter, mockDatabase)
+	testIdempotencyReleaseKeyOnPanic(t, mockRouter, mockDatabase)
+}
+
+func testIdempotencyKeyTooLong(t *testing.T, mockRouter *gin.Engine) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request.
+	req := httptest.NewRequest("DELETE", "/assets/1", nil)
+	req.Header.Set("Idempotency-Key", strings.Repeat("k", 256))
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusBadRequest, recorder.Code)
+	assertProblem(t, invalidParameterCode, "Idempotency-Key", "Idempotency-Key cannot be longer than 255 characters",
+		recorder)
+}
+
+func testIdempotencySaveResponse(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database calls. The key is reserved for the actor before the request is handled
+	// and the response is stored afterwards.
+	req := httptest.NewRequest("PATCH", "/assets/1", strings.NewReader("{\"symbol\": \"btc\"}"))
+	req.Header.Set("Idempotency-Key", "save")
+	req.Header.Set("X-Actor", "alice")
+	mockDatabase.On("ReserveIdempotencyKey", mock.MatchedBy(func(key *models.IdempotencyKey) bool {
+		return key.Key == "save" && key.Actor == "alice" && len(key.RequestHash) == 64 && key.Status == 0 &&
+			key.ExpiresAt > key.CreatedAt
+	})).Return(nil, nil).Once()
+	symbol := "btc"
+	id := "1"
+	mockDatabase.On("Update", 1, &models.CryptoAsset{ID: &id, Symbol: &symbol}, "alice").Return(nil).Once()
+	mockDatabase.On("Get", 1).Return(newBitcoin(), nil).Twice()
+	var savedKey *models.IdempotencyKey
+	mockDatabase.On("SaveIdempotentResponse", mock.AnythingOfType("*models.IdempotencyKey")).Return(nil).
+		Run(func(args mock.Arguments) {
+			savedKey = args.Get(0).(*models.IdempotencyKey)
+		}).Once()
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and stored response.
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+	if savedKey.Status != http.StatusOK || string(savedKey.Body) != recorder.Body.String() ||
+		savedKey.Header["Content-Type"] != "application/json; charset=utf-8" {
+		t.Fatalf("unexpected stored response: %+v", savedKey)
+	}
+}
+
+func testIdempotencyReplay(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call. The stored response is replayed without handling the request.
+	body := "{\"name\": \"ripple\"}"
+	req := httptest.NewRequest("POST", "/assets", strings.NewReader(body))
+	req.Header.Set("Idempotency-Key", "replay")
+	mockDatabase.On("ReserveIdempotencyKey", mock.MatchedBy(func(key *models.IdempotencyKey) bool {
+		return key.Key == "replay"
+	})).Return(&models.IdempotencyKey{
+		Key:         "replay",
+		Actor:       "anonymous",
+		RequestHash: hashRequest(req, []byte(body)),
+		Status:      http.StatusCreated,
+		Header:      map[string]string{"Content-Type": "application/json; charset=utf-8"},
+		Body:        []byte("{\"id\":\"4\"}"),
+	}, nil).Once()
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code, headers and body.
+	assertResponseCode(t, http.StatusCreated, recorder.Code)
+	assertResponseBody(t, "{\"id\":\"4\"}", recorder.Body.String())
+	if recorder.Header().Get("Idempotent-Replayed") != "true" ||
+		recorder.Header().Get("Content-Type") != "application/json; charset=utf-8" {
+		t.Fatalf("unexpected headers: %v", recorder.Header())
+	}
+}
+
+func testIdempotencyMismatch(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call. The key was used for a request with a different body.
+	req := httptest.NewRequest("POST", "/assets", strings.NewReader("{\"name\": \"litecoin\"}"))
+	req.Header.Set("Idempotency-Key", "mismatch")
+	mockDatabase.On("ReserveIdempotencyKey", mock.MatchedBy(func(key *models.IdempotencyKey) bool {
+		return key.Key == "mismatch"
+	})).Return(&models.IdempotencyKey{Key: "mismatch", Actor: "anonymous", RequestHash: "other",
+		Status: http.StatusCreated}, nil).Once()
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusUnprocessableEntity, recorder.Code)
+	assertProblem(t, idempotencyKeyReusedCode, "Idempotency-Key",
+		"Idempotency-Key has already been used for a different request", recorder)
+}
+
+func testIdempotencyInProgress(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call. The first request with the key has not been responded to yet.
+	req := httptest.NewRequest("DELETE", "/assets/2", nil)
+	req.Header.Set("Idempotency-Key", "in-progress")
+	mockDatabase.On("ReserveIdempotencyKey", mock.MatchedBy(func(key *models.IdempotencyKey) bool {
+		return key.Key == "in-progress"
+	})).Return(&models.IdempotencyKey{Key: "in-progress", Actor: "anonymous",
+		RequestHash: hashRequest(req, nil)}, nil).Once()
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusConflict, recorder.Code)
+	assertProblem(t, idempotencyKeyInUseCode, "Idempotency-Key",
+		"a request with this Idempotency-Key is still being handled", recorder)
+}
+
+func testIdempotencyReleaseKey(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database calls. A request that fails with an internal error gives up its key so
+	// that it can be retried.
+	req := httptest.NewRequest("DELETE", "/assets/3", nil)
+	req.Header.Set("Idempotency-Key", "release")
+	mockDatabase.On("ReserveIdempotencyKey", mock.MatchedBy(func(key *models.IdempotencyKey) bool {
+		return key.Key == "release"
+	})).Return(nil, nil).Once()
+	mockDatabase.On("Delete", 3, (*int)(nil), "anonymous").Return(errors.New("database is locked")).Once()
+	mockDatabase.On("ReleaseIdempotencyKey", "release", "anonymous").Return(nil).Once()
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code.
+	assertResponseCode(t, http.StatusInternalServerError, recorder.Code)
+}
+
+func testIdempotencyReleaseKeyOnPanic(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database calls. A request whose handler panics gives up its key too, even though
+	// the 500 is only written by the recovery middleware.
+	req := httptest.NewRequest("DELETE", "/assets/4", nil)
+	req.Header.Set("Idempotency-Key", "panic")
+	mockDatabase.On("ReserveIdempotencyKey", mock.MatchedBy(func(key *models.IdempotencyKey) bool {
+		return key.Key == "panic"
+	})).Return(nil, nil).Once()
+	mockDatabase.On("Delete", 4, (*int)(nil), "anonymous").Return(nil).Run(func(mock.Arguments) {
+		panic("database connection closed")
+	}).Once()
+	mockDatabase.On("ReleaseIdempotencyKey", "panic", "anonymous").Return(nil).Once()
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code.
+	assertResponseCode(t, http.StatusInternalServerError, recorder.Code)
+}
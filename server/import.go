@@ -0,0 +1,111 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/paddyquinn/messari/database/models"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Import query string parameter constants.
+	formatParam = "format"
+	modeParam   = "mode"
+	upsertParam = "upsert"
+
+	// Import error string constants.
+	importError = "could not import the crypto assets"
+)
+
+// importContentTypes maps the content types of the files that can be imported to their formats.
+var importContentTypes = map[string]string{
+	"application/json":          models.JSONFormat,
+	"application/ndjson":        models.NDJSONFormat,
+	"application/x-ndjson":      models.NDJSONFormat,
+	"text/csv":                  models.CSVFormat,
+	"text/tab-separated-values": models.TSVFormat,
+}
+
+// importAssets registers every crypto asset in a CSV or TSV file, JSON array or newline delimited JSON passed in via
+// the request body and reports what became of each row. The format is taken from the "format" query string parameter,
+// or from the Content-Type header if it is not passed. Passing "mode=bestEffort" imports every row that can be
+// imported rather than nothing if any row fails, and passing "upsert=true" replaces the live crypto asset with the
+// symbol of a row, if there is one, so that a file can be imported again. The read and write timeouts are lifted by
+// liftDeadlines before the request body is read.
+func (s *Server) importAssets(ctx *gin.Context) {
+	// Initialize the logger.
+	logger := log.WithField(endpoint, importEndpoint)
+
+	// Parse the format, mode and whether to upsert from the query string.
+	format, atomic, upsert, err := parseImportQuery(ctx)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(queryError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	// Parse the rows of the file passed in via the request body.
+	rows, err := models.ParseImport(ctx.Request.Body, format)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(parseError)
+		respondWithInvalidBody(ctx, err)
+		return
+	}
+	setAuditPayload(ctx, map[string]interface{}{formatParam: format, "atomic": atomic, upsertParam: upsert,
+		"rows": len(rows)})
+
+	// Import the rows into the database.
+	if err = s.DB.Import(rows, upsert, atomic, getActor(ctx)); err != nil {
+		logger.WithField(errKey, err.Error()).Error(importError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	// An atomic import with a failed row imported nothing, which is an error. Otherwise, report every row back to the
+	// user.
+	report := models.NewImportReport(rows)
+	if atomic && report.Failed > 0 {
+		detail := fmt.Sprintf("%d of %d rows failed, so nothing was imported", report.Failed, len(rows))
+		logger.Error(detail)
+		importProblem := newProblem(ctx, http.StatusBadRequest, importFailedCode, "", detail)
+		importProblem.Rows = rows
+		writeProblem(ctx, importProblem)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
+
+// parseImportQuery parses the format, mode and whether to upsert from the query string. The format is taken from the
+// Content-Type header if it is not passed, and the mode is atomic unless it is passed. An error is returned if the
+// format cannot be told or is not one that can be imported, if the mode is unknown or if upsert is not a boolean.
+func parseImportQuery(ctx *gin.Context) (string, bool, bool, error) {
+	format, ok := ctx.GetQuery(formatParam)
+	if ok {
+		format = strings.ToLower(strings.TrimSpace(format))
+		if !models.IsImportFormat(format) {
+			return "", false, false, newInvalidParameterError(formatParam, format)
+		}
+	} else if format, ok = importContentTypes[ctx.ContentType()]; !ok {
+		return "", false, false, newParameterError(formatParam,
+			"cannot tell the format of the file: pass format=csv, tsv, json or ndjson")
+	}
+
+	mode := models.AtomicImport
+	if modeString, ok := ctx.GetQuery(modeParam); ok {
+		mode = strings.TrimSpace(modeString)
+		if mode != models.AtomicImport && mode != models.BestEffortImport {
+			return "", false, false, newInvalidParameterError(modeParam, mode)
+		}
+	}
+
+	upsert, err := parseBoolean(ctx, upsertParam)
+	if err != nil {
+		return "", false, false, err
+	}
+
+	return format, mode == models.AtomicImport, upsert != nil && *upsert, nil
+}
@@ -0,0 +1,164 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/paddyquinn/messari/database"
+	"github.com/paddyquinn/messari/database/models"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestImport(t *testing.T) {
+	// Hide logs.
+	log.SetLevel(log.FatalLevel)
+
+	// Set up router for testing.
+	gin.SetMode(gin.TestMode)
+	mockDatabase := &database.Mock{}
+	mockRouter := setUpMockRouter(mockDatabase)
+	expectAudit(mockDatabase)
+
+	// Run tests.
+	testImportUnknownFormat(t, mockRouter, mockDatabase)
+	testImportAtomicFailure(t, mockRouter, mockDatabase)
+	testImportBestEffort(t, mockRouter, mockDatabase)
+	testImportPastReadTimeout(t, mockDatabase)
+}
+
+func testImportUnknownFormat(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request. The format cannot be told without a query string parameter or a known content type.
+	req := httptest.NewRequest("POST", "/import", strings.NewReader("name,symbol\n"))
+	req.Header.Set("Content-Type", "text/plain")
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusBadRequest, recorder.Code)
+	assertProblem(t, invalidParameterCode, formatParam,
+		"cannot tell the format of the file: pass format=csv, tsv, json or ndjson", recorder)
+}
+
+func testImportAtomicFailure(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call. The second row fails, so the first is skipped.
+	req := httptest.NewRequest("POST", "/import?format=ndjson",
+		strings.NewReader("{\"symbol\":\"btc\"}\n{\"symbol\":\"eth\"}\n"))
+	mockDatabase.On("Import", mock.AnythingOfType("[]*models.ImportRow"), false, true, "anonymous").Return(nil).
+		Run(func(args mock.Arguments) {
+			rows := args.Get(0).([]*models.ImportRow)
+			rows[0].Status, rows[0].Symbol = models.SkippedStatus, rows[0].CryptoAsset.Symbol
+			rows[1].Symbol = rows[1].CryptoAsset.Symbol
+			rows[1].Fail(&models.Violation{Field: "name", Code: models.RequiredViolation, Detail: "name cannot be null"})
+		}).Once()
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusBadRequest, recorder.Code)
+	assertProblem(t, importFailedCode, "", "1 of 2 rows failed, so nothing was imported", recorder)
+	importProblem := &problem{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), importProblem); err != nil {
+		t.Fatalf("unexpected response body: %s", recorder.Body.String())
+	}
+	rows, err := json.Marshal(importProblem.Rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertResponseBody(t, "[{\"row\":1,\"status\":\"skipped\",\"symbol\":\"btc\"},{\"row\":2,\"status\":\"failed\","+
+		"\"symbol\":\"eth\",\"errors\":[{\"field\":\"name\",\"code\":\"required\",\"detail\":\"name cannot be null\"}]}]",
+		string(rows))
+}
+
+func testImportBestEffort(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call. The format is taken from the content type.
+	req := httptest.NewRequest("POST", "/import?mode=bestEffort&upsert=true", strings.NewReader("symbol\nbtc\neth\n"))
+	req.Header.Set("Content-Type", "text/csv")
+	mockDatabase.On("Import", mock.AnythingOfType("[]*models.ImportRow"), true, false, "anonymous").Return(nil).
+		Run(func(args mock.Arguments) {
+			rows := args.Get(0).([]*models.ImportRow)
+			created, updated := "1", "2"
+			rows[0].Status, rows[0].ID = models.CreatedStatus, &created
+			rows[1].Status, rows[1].ID = models.UpdatedStatus, &updated
+		}).Once()
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+	report := &models.ImportReport{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), report); err != nil {
+		t.Fatalf("unexpected response body: %s", recorder.Body.String())
+	}
+	if report.Created != 1 || report.Updated != 1 || report.Failed != 0 || len(report.Rows) != 2 {
+		t.Fatalf("unexpected import report: %s", recorder.Body.String())
+	}
+}
+
+func testImportPastReadTimeout(t *testing.T, mockDatabase *database.Mock) {
+	// Serve the router with read and write timeouts that are up before the file is uploaded.
+	httpServer := startTestServer(mockDatabase, 50*time.Millisecond, 50*time.Millisecond)
+	defer httpServer.Close()
+
+	// Prepare the mock database call. The file is uploaded slower than the read timeout allows.
+	body, writer := io.Pipe()
+	go func() {
+		writer.Write([]byte("symbol\n"))
+		time.Sleep(100 * time.Millisecond)
+		writer.Write([]byte("btc\n"))
+		writer.Close()
+	}()
+	mockDatabase.On("Import", mock.AnythingOfType("[]*models.ImportRow"), false, false, "anonymous").Return(nil).
+		Run(func(args mock.Arguments) {
+			rows := args.Get(0).([]*models.ImportRow)
+			created := "1"
+			rows[0].Status, rows[0].ID = models.CreatedStatus, &created
+		}).Once()
+
+	// Make the request.
+	resp, err := http.Post(httpServer.URL+"/import?mode=bestEffort", "text/csv", body)
+	if err != nil {
+		t.Fatalf("unexpected error importing past the read timeout: %s", err.Error())
+	}
+	defer resp.Body.Close()
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusOK, resp.StatusCode)
+	report := &models.ImportReport{}
+	if err = json.NewDecoder(resp.Body).Decode(report); err != nil {
+		t.Fatalf("unexpected response body: %s", err.Error())
+	}
+	if report.Created != 1 || len(report.Rows) != 1 {
+		t.Fatalf("unexpected import report: %+v", report)
+	}
+}
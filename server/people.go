@@ -0,0 +1,198 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/paddyquinn/messari/database/models"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Person query parameter constants.
+	nameParam = "name"
+
+	// Person error string constants.
+	invalidMembershipError = "the membership is invalid"
+	membershipError        = "could not update the membership of the person"
+	peopleError            = "could not get the people"
+	personError            = "could not get the person"
+	personAssetsError      = "could not get the crypto assets of the person"
+	updatePersonError      = "could not update the person"
+)
+
+// listPeople returns every person with the name passed in via the query string, either as their name or as one of their
+// aliases, so that the people on the team of a crypto asset, which lists their names, can be looked up by id.
+func (s *Server) listPeople(ctx *gin.Context) {
+	// Initialize the logger.
+	logger := log.WithField(endpoint, peopleEndpoint)
+
+	// Parse the name from the query string. Like team member names, it is only trimmed.
+	name := strings.TrimSpace(ctx.Query(nameParam))
+	if name == "" {
+		err := newParameterError(nameParam, "name cannot be empty")
+		logger.WithField(errKey, err.Error()).Error(queryError)
+		respondWithError(ctx, err)
+		return
+	}
+	logger = logger.WithField(nameParam, name)
+
+	// Get the people from the database and return them back to the user.
+	people, err := s.DB.People(name)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(peopleError)
+		respondWithError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, people)
+}
+
+// getPerson returns the person with the id given in the path along with their aliases and social handles.
+func (s *Server) getPerson(ctx *gin.Context) {
+	// Initialize the logger.
+	logger := log.WithField(endpoint, personEndpoint)
+
+	// Parse the id from the path.
+	id, err := parseID(ctx)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(normalizeError)
+		respondWithError(ctx, err)
+		return
+	}
+	logger = logger.WithField(idKey, id)
+
+	// Get the person from the database and return them back to the user.
+	person, err := s.DB.Person(id)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(personError)
+		respondWithError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, person)
+}
+
+// personAssets returns the role and tenure of the person with the id given in the path on the team of every live
+// crypto asset they are on.
+func (s *Server) personAssets(ctx *gin.Context) {
+	// Initialize the logger.
+	logger := log.WithField(endpoint, personAssetsEndpoint)
+
+	// Parse the id from the path.
+	id, err := parseID(ctx)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(normalizeError)
+		respondWithError(ctx, err)
+		return
+	}
+	logger = logger.WithField(idKey, id)
+
+	// Get the memberships from the database.
+	memberships, err := s.DB.PersonAssets(id)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(personAssetsError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	// Format each membership and return them back to the user.
+	for _, membership := range memberships {
+		membership.Format()
+	}
+	ctx.JSON(http.StatusOK, memberships)
+}
+
+// patchPerson merges the name, aliases and social handles passed in the request body into the person with the id given
+// in the path and returns the updated person. Renaming a person renames them on the team of every crypto asset they are
+// on.
+func (s *Server) patchPerson(ctx *gin.Context) {
+	// Initialize the logger.
+	logger := log.WithField(endpoint, personEndpoint)
+
+	// Parse the id from the path.
+	id, err := parseID(ctx)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(normalizeError)
+		respondWithError(ctx, err)
+		return
+	}
+	actor := getActor(ctx)
+	logger = logger.WithFields(log.Fields{idKey: id, actorKey: actor})
+
+	// Parse the person passed in via the request body.
+	person, err := models.NewPerson(ctx.Request.Body)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(parseError)
+		respondWithInvalidBody(ctx, err)
+		return
+	}
+	setAuditPayload(ctx, person)
+
+	// Update the person in the database.
+	if err = s.DB.UpdatePerson(id, person, actor); err != nil {
+		logger.WithField(errKey, err.Error()).Error(updatePersonError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	// Get the updated person from the database and return them back to the user.
+	updatedPerson, err := s.DB.Person(id)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(personError)
+		respondWithError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, updatedPerson)
+}
+
+// updateMembership replaces the role and tenure of the person with the id given in the path on the team of the crypto
+// asset with the asset id given in the path with the role, start date and end date passed in the request body. A null
+// role or date is unknown. A change is recorded in the history of the crypto asset, which gives it a new version.
+func (s *Server) updateMembership(ctx *gin.Context) {
+	// Initialize the logger.
+	logger := log.WithField(endpoint, membershipEndpoint)
+
+	// Parse the ids of the person and the crypto asset from the path.
+	id, err := parseID(ctx)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(normalizeError)
+		respondWithError(ctx, err)
+		return
+	}
+	assetIDString := ctx.Param(assetIDParam)
+	assetID, err := strconv.Atoi(assetIDString)
+	if err != nil {
+		err = newInvalidParameterError(assetIDParam, assetIDString)
+		logger.WithField(errKey, err.Error()).Error(normalizeError)
+		respondWithError(ctx, err)
+		return
+	}
+	setAuditCryptoAssetID(ctx, assetID)
+	actor := getActor(ctx)
+	logger = logger.WithFields(log.Fields{idKey: id, assetIDParam: assetID, actorKey: actor})
+
+	// Parse and validate the membership passed in via the request body.
+	membership, err := models.NewMembership(ctx.Request.Body)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(parseError)
+		respondWithInvalidBody(ctx, err)
+		return
+	}
+	setAuditPayload(ctx, membership)
+	if err = membership.Validate(); err != nil {
+		logger.WithField(errKey, err.Error()).Error(invalidMembershipError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	// Update the membership in the database.
+	if err = s.DB.UpdateMembership(id, assetID, membership, actor); err != nil {
+		logger.WithField(errKey, err.Error()).Error(membershipError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	// There is nothing left to return to the user.
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,222 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/paddyquinn/messari/database"
+	"github.com/paddyquinn/messari/database/models"
+	log "github.com/sirupsen/logrus"
+)
+
+func TestPeopleEndpoints(t *testing.T) {
+	// Hide logs.
+	log.SetLevel(log.FatalLevel)
+
+	// Set up router for testing.
+	gin.SetMode(gin.TestMode)
+	mockDatabase := &database.Mock{}
+	mockRouter := setUpMockRouter(mockDatabase)
+
+	// Run tests. The writes are recorded in the audit log.
+	testListPeopleEmptyName(t, mockRouter)
+	testListPeopleSuccess(t, mockRouter, mockDatabase)
+	testInvalidPathIDEndpoint(t, mockRouter, "GET", "/people/a")
+	testGetPersonUnknownID(t, mockRouter, mockDatabase)
+	testGetPersonSuccess(t, mockRouter, mockDatabase)
+	testPersonAssetsSuccess(t, mockRouter, mockDatabase)
+	expectAudit(mockDatabase)
+	testPatchPersonSuccess(t, mockRouter, mockDatabase)
+	testUpdateMembershipInvalidDates(t, mockRouter)
+	testUpdateMembershipNotOnTeam(t, mockRouter, mockDatabase)
+	testUpdateMembershipSuccess(t, mockRouter, mockDatabase)
+}
+
+func testListPeopleEmptyName(t *testing.T, mockRouter *gin.Engine) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request.
+	req := httptest.NewRequest("GET", "/people?name=%20", nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusBadRequest, recorder.Code)
+	assertProblem(t, invalidParameterCode, nameParam, "name cannot be empty", recorder)
+}
+
+func testListPeopleSuccess(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call. The name is trimmed.
+	req := httptest.NewRequest("GET", "/people?name=%20Satoshi%20", nil)
+	mockDatabase.On("People", "Satoshi").Return([]*models.Person{newSatoshi()}, nil).Once()
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+	assertResponseBody(t, "[{\"id\":1,\"name\":\"Satoshi Nakomoto\",\"aliases\":[\"Satoshi\"],"+
+		"\"socialHandles\":{\"bitcointalk\":\"satoshi\"}}]", recorder.Body.String())
+}
+
+func testGetPersonUnknownID(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call.
+	req := httptest.NewRequest("GET", "/people/2", nil)
+	mockDatabase.On("Person", 2).Return(nil, database.NewUnknownPersonError(2))
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusNotFound, recorder.Code)
+	assertProblem(t, personNotFoundCode, "", "person with id 2 not found", recorder)
+}
+
+func testGetPersonSuccess(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call.
+	req := httptest.NewRequest("GET", "/people/1", nil)
+	mockDatabase.On("Person", 1).Return(newSatoshi(), nil).Once()
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+	assertResponseBody(t, "{\"id\":1,\"name\":\"Satoshi Nakomoto\",\"aliases\":[\"Satoshi\"],"+
+		"\"socialHandles\":{\"bitcointalk\":\"satoshi\"}}", recorder.Body.String())
+}
+
+func testPersonAssetsSuccess(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call. The crypto asset of each membership is formatted.
+	symbol, name, role, startDate, endDate := "btc", "bitcoin", "founder", "2008-10-31", "2010-12-12"
+	req := httptest.NewRequest("GET", "/people/1/assets", nil)
+	mockDatabase.On("PersonAssets", 1).Return([]*models.Membership{{CryptoAssetID: "1", Symbol: &symbol, Name: &name,
+		Role: &role, StartDate: &startDate, EndDate: &endDate}}, nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+	assertResponseBody(t, "[{\"cryptoAssetId\":\"1\",\"symbol\":\"BTC\",\"name\":\"Bitcoin\",\"role\":\"founder\","+
+		"\"startDate\":\"2008-10-31\",\"endDate\":\"2010-12-12\"}]", recorder.Body.String())
+}
+
+func testPatchPersonSuccess(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database calls. The aliases are trimmed and the networks are normalized, while
+	// the name is left untouched.
+	req := httptest.NewRequest("PATCH", "/people/1",
+		strings.NewReader("{\"aliases\":[\" Satoshi \"],\"socialHandles\":{\"BitcoinTalk\":\"satoshi\"}}"))
+	mockDatabase.On("UpdatePerson", 1, &models.Person{Aliases: []string{"Satoshi"},
+		SocialHandles: map[string]string{"bitcointalk": "satoshi"}}, "anonymous").Return(nil)
+	mockDatabase.On("Person", 1).Return(newSatoshi(), nil).Once()
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+	assertResponseBody(t, "{\"id\":1,\"name\":\"Satoshi Nakomoto\",\"aliases\":[\"Satoshi\"],"+
+		"\"socialHandles\":{\"bitcointalk\":\"satoshi\"}}", recorder.Body.String())
+}
+
+func testUpdateMembershipInvalidDates(t *testing.T, mockRouter *gin.Engine) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request with an end date before the start date.
+	req := httptest.NewRequest("PUT", "/people/1/assets/1",
+		strings.NewReader("{\"role\":\"founder\",\"startDate\":\"2010-12-12\",\"endDate\":\"2008-10-31\"}"))
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusBadRequest, recorder.Code)
+	assertProblem(t, invalidFieldCode, "endDate", "end date cannot be before the start date", recorder)
+}
+
+func testUpdateMembershipNotOnTeam(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call.
+	role := "advisor"
+	req := httptest.NewRequest("PUT", "/people/1/assets/2", strings.NewReader("{\"role\":\"Advisor\"}"))
+	mockDatabase.On("UpdateMembership", 1, 2, &models.Membership{Role: &role}, "anonymous").
+		Return(database.NewUnknownMembershipError(1, 2))
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusNotFound, recorder.Code)
+	assertProblem(t, membershipNotFoundCode, "assetId", "person with id 1 is not on the team of crypto asset with id 2",
+		recorder)
+}
+
+func testUpdateMembershipSuccess(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call. The role is normalized and the dates are trimmed.
+	role, startDate := "founder", "2008-10-31"
+	req := httptest.NewRequest("PUT", "/people/1/assets/1",
+		strings.NewReader("{\"role\":\" Founder\",\"startDate\":\"2008-10-31 \"}"))
+	mockDatabase.On("UpdateMembership", 1, 1, &models.Membership{Role: &role, StartDate: &startDate}, "anonymous").
+		Return(nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code.
+	assertResponseCode(t, http.StatusNoContent, recorder.Code)
+}
+
+// newSatoshi creates a person that can be used in tests.
+func newSatoshi() *models.Person {
+	name := "Satoshi Nakomoto"
+	return &models.Person{ID: 1, Name: &name, Aliases: []string{"Satoshi"},
+		SocialHandles: map[string]string{"bitcointalk": "satoshi"}}
+}
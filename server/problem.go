@@ -0,0 +1,208 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/paddyquinn/messari/database"
+	"github.com/paddyquinn/messari/database/models"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Problem constants. Every error is returned as an RFC 7807 problem. Problems have no type beyond their status code,
+	// so what went wrong is told apart by the code instead.
+	blankProblemType   = "about:blank"
+	problemContentType = "application/problem+json"
+
+	// Problem code constants. Clients rely on these, so they must never change once released.
+	apiKeyNotFoundCode       = "api_key_not_found"
+	cryptoAssetNotFoundCode  = "crypto_asset_not_found"
+	duplicateSymbolCode      = "duplicate_symbol"
+	duplicateValueCode       = "duplicate_vocabulary_value"
+	emptyUpdateCode          = "empty_update"
+	idMismatchCode           = "id_mismatch"
+	idempotencyKeyInUseCode  = "idempotency_key_in_use"
+	idempotencyKeyReusedCode = "idempotency_key_reused"
+	importFailedCode         = "import_failed"
+	insufficientScopeCode    = "insufficient_scope"
+	internalErrorCode        = "internal_error"
+	invalidAPIKeyCode        = "invalid_api_key"
+	invalidBodyCode          = "invalid_body"
+	invalidFieldCode         = "invalid_field"
+	invalidParameterCode     = "invalid_parameter"
+	membershipNotFoundCode   = "membership_not_found"
+	missingAPIKeyCode        = "missing_api_key"
+	notAcceptableCode        = "not_acceptable"
+	nullFieldCode            = "null_field"
+	personNotFoundCode       = "person_not_found"
+	proposalNotFoundCode     = "proposal_not_found"
+	proposalReviewedCode     = "proposal_reviewed"
+	revisionNotFoundCode     = "revision_not_found"
+	unsupportedQueryCode     = "unsupported_query"
+	valueNotFoundCode        = "vocabulary_value_not_found"
+	versionMismatchCode      = "version_mismatch"
+	vocabularyNotFoundCode   = "vocabulary_not_found"
+
+	// Parameters and fields that database errors are about.
+	cursorField = "cursor"
+	scopeField  = "scope"
+	symbolField = "symbol"
+)
+
+// problem is an RFC 7807 problem detail. The code says what went wrong, the field is the body field, path parameter,
+// query string parameter or header that is to blame, if any, and the request id is the id in the X-Request-ID header.
+// A crypto asset that fails validation lists every violation in the errors, and an import that fails lists what became
+// of every row in the rows.
+type problem struct {
+	Type      string              `json:"type"`
+	Title     string              `json:"title"`
+	Status    int                 `json:"status"`
+	Detail    string              `json:"detail"`
+	Instance  string              `json:"instance"`
+	Code      string              `json:"code"`
+	Field     string              `json:"field,omitempty"`
+	RequestID string              `json:"requestId,omitempty"`
+	Errors    []*models.Violation `json:"errors,omitempty"`
+	Rows      []*models.ImportRow `json:"rows,omitempty"`
+}
+
+// invalidParameterError represents an error when a path parameter, query string parameter or header cannot be parsed.
+type invalidParameterError struct {
+	param  string
+	detail string
+}
+
+// newInvalidParameterError creates a new invalid parameter error with the parameter and its offending value.
+func newInvalidParameterError(param, value string) *invalidParameterError {
+	return &invalidParameterError{param: param, detail: fmt.Sprintf("invalid %s: %s", param, value)}
+}
+
+// newParameterError creates a new invalid parameter error with the parameter and a detail of why it is invalid.
+func newParameterError(param, detail string) *invalidParameterError {
+	return &invalidParameterError{param: param, detail: detail}
+}
+
+// Error makes invalidParameterError adhere to the error interface. The detail is returned in the string.
+func (i *invalidParameterError) Error() string {
+	return i.detail
+}
+
+// newProblem creates a new problem for the request being handled.
+func newProblem(ctx *gin.Context, status int, code, field, detail string) *problem {
+	return &problem{
+		Type:      blankProblemType,
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  ctx.Request.URL.Path,
+		Code:      code,
+		Field:     field,
+		RequestID: ctx.GetString(requestIDContextKey),
+	}
+}
+
+// respondWithProblem aborts the request and responds to the user with a problem.
+func respondWithProblem(ctx *gin.Context, status int, code, field, detail string) {
+	writeProblem(ctx, newProblem(ctx, status, code, field, detail))
+}
+
+// respondWithValidationError responds to the user with a problem listing every violation of a crypto asset that failed
+// validation. The problem is only blamed on a field if that field is the only one that is invalid.
+func respondWithValidationError(ctx *gin.Context, err *models.ValidationError) {
+	var field string
+	violations := err.Violations()
+	if len(violations) == 1 {
+		field = violations[0].Field
+	}
+
+	validationProblem := newProblem(ctx, http.StatusBadRequest, invalidFieldCode, field, err.Error())
+	validationProblem.Errors = violations
+	writeProblem(ctx, validationProblem)
+}
+
+// writeProblem aborts the request and writes the problem as the response.
+func writeProblem(ctx *gin.Context, p *problem) {
+	body, err := json.Marshal(p)
+	if err != nil {
+		log.WithField(errKey, err.Error()).Error("could not marshal the problem")
+		ctx.AbortWithStatus(p.Status)
+		return
+	}
+
+	ctx.Data(p.Status, problemContentType, body)
+	ctx.Abort()
+}
+
+// respondWithInvalidBody responds to the user with a problem for a request body that cannot be parsed. A value of the
+// wrong type is blamed on its field.
+func respondWithInvalidBody(ctx *gin.Context, err error) {
+	var field string
+	if typeErr, ok := err.(*json.UnmarshalTypeError); ok {
+		field = typeErr.Field
+	}
+	respondWithProblem(ctx, http.StatusBadRequest, invalidBodyCode, field, err.Error())
+}
+
+// respondWithError responds to the user with the problem and status code for an error returned while handling the
+// request. Note that the actual error string is only exposed to the user if a user error occurred. An internal error
+// is hidden behind a generic error message.
+func respondWithError(ctx *gin.Context, err error) {
+	status, code, field := http.StatusBadRequest, "", ""
+	switch e := err.(type) {
+	case *invalidParameterError:
+		code, field = invalidParameterCode, e.param
+	case *models.ValidationError:
+		respondWithValidationError(ctx, e)
+		return
+	case *database.EmptyUpdateError:
+		code = emptyUpdateCode
+	case *database.InvalidCursorError:
+		code, field = invalidParameterCode, cursorField
+	case *database.InvalidLimitError:
+		code, field = invalidParameterCode, limitParam
+	case *database.InvalidScopeError:
+		code, field = invalidFieldCode, scopeField
+	case *database.NullConstraintError:
+		code, field = nullFieldCode, e.Field()
+	case *database.UniqueConstraintError:
+		code, field = duplicateSymbolCode, symbolField
+	case *database.DuplicateVocabularyValueError:
+		code, field = duplicateValueCode, valueParam
+	case *database.UnknownFieldError:
+		code, field = invalidParameterCode, fieldsParam
+	case *database.UnknownSortFieldError:
+		code, field = invalidParameterCode, sortParam
+	case *database.UnsupportedQueryError:
+		code = unsupportedQueryCode
+	case *database.UnknownAPIKeyError:
+		status, code = http.StatusNotFound, apiKeyNotFoundCode
+	case *database.UnknownIDError:
+		status, code = http.StatusNotFound, cryptoAssetNotFoundCode
+	case *database.UnknownMembershipError:
+		status, code, field = http.StatusNotFound, membershipNotFoundCode, assetIDParam
+	case *database.UnknownPersonError:
+		status, code = http.StatusNotFound, personNotFoundCode
+	case *database.UnknownProposalError:
+		status, code = http.StatusNotFound, proposalNotFoundCode
+	case *database.UnknownRevisionError:
+		status, code, field = http.StatusNotFound, revisionNotFoundCode, revisionParam
+	case *database.UnknownVocabularyError:
+		status, code = http.StatusNotFound, vocabularyNotFoundCode
+	case *database.UnknownVocabularyValueError:
+		status, code = http.StatusNotFound, valueNotFoundCode
+	case *notAcceptableError:
+		status, code, field = http.StatusNotAcceptable, notAcceptableCode, acceptHeader
+	case *database.ProposalReviewedError:
+		status, code = http.StatusConflict, proposalReviewedCode
+	case *database.VersionMismatchError:
+		status, code = http.StatusPreconditionFailed, versionMismatchCode
+	default:
+		respondWithProblem(ctx, http.StatusInternalServerError, internalErrorCode, "", internalServerError)
+		return
+	}
+
+	respondWithProblem(ctx, status, code, field, err.Error())
+}
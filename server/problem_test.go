@@ -0,0 +1,100 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/paddyquinn/messari/database"
+	"github.com/paddyquinn/messari/database/models"
+)
+
+func TestRespondWithError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	respondWithErrorTests := []struct {
+		err            error
+		expectedStatus int
+		expectedCode   string
+		expectedField  string
+		expectedDetail string
+	}{
+		{newInvalidParameterError(limitParam, "a"), http.StatusBadRequest, invalidParameterCode, "limit",
+			"invalid limit: a"},
+		{models.NewValidationError([]*models.Violation{{Field: "icoAmount", Code: models.RangeViolation,
+			Detail: "ICO amount cannot be negative"}}), http.StatusBadRequest, invalidFieldCode, "icoAmount",
+			"ICO amount cannot be negative"},
+		{models.NewValidationError([]*models.Violation{{Field: "icoAmount", Code: models.RangeViolation,
+			Detail: "ICO amount cannot be negative"}, {Field: "website", Code: models.FormatViolation,
+			Detail: "website must be an http or https URL"}}), http.StatusBadRequest, invalidFieldCode, "",
+			"ICO amount cannot be negative; website must be an http or https URL"},
+		{database.NewEmptyUpdateError(), http.StatusBadRequest, emptyUpdateCode, "", "nothing to update"},
+		{database.NewInvalidCursorError(), http.StatusBadRequest, invalidParameterCode, "cursor", "invalid cursor"},
+		{database.NewNullConstraintError("website"), http.StatusBadRequest, nullFieldCode, "website",
+			"website cannot be null"},
+		{database.NewUniqueConstraintError("btc"), http.StatusBadRequest, duplicateSymbolCode, "symbol",
+			"symbol btc already exists"},
+		{database.NewDuplicateVocabularyValueError("coinType", "coin"), http.StatusBadRequest, duplicateValueCode, "value",
+			"coinType coin already exists"},
+		{database.NewUnknownFieldError("price"), http.StatusBadRequest, invalidParameterCode, "fields",
+			"unknown field price"},
+		{database.NewUnsupportedQueryError("cannot sort a full-text search"), http.StatusBadRequest,
+			unsupportedQueryCode, "", "cannot sort a full-text search"},
+		{database.NewUnknownIDError(4), http.StatusNotFound, cryptoAssetNotFoundCode, "",
+			"crypto asset with id 4 not found"},
+		{database.NewUnknownVocabularyValueError("fundingStatus", "airdrop"), http.StatusNotFound, valueNotFoundCode, "",
+			"fundingStatus airdrop not found"},
+		{newNotAcceptableError("application/xml"), http.StatusNotAcceptable, notAcceptableCode, "Accept",
+			"cannot respond with any of application/xml: accept application/json, text/csv, application/x-yaml or " +
+				"application/msgpack, or pass format=json, csv, yaml or msgpack"},
+		{database.NewProposalReviewedError(2, "approved"), http.StatusConflict, proposalReviewedCode, "",
+			"proposal 2 has already been approved"},
+		{database.NewVersionMismatchError(4, 1, 2), http.StatusPreconditionFailed, versionMismatchCode, "",
+			"crypto asset with id 4 is at version 2, not 1"},
+		{errors.New("database is locked"), http.StatusInternalServerError, internalErrorCode, "",
+			"internal server error"},
+	}
+	for _, respondWithErrorTest := range respondWithErrorTests {
+		// Create the response recorder and a context for it.
+		recorder := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(recorder)
+		ctx.Request = httptest.NewRequest("GET", "/assets", nil)
+		ctx.Set(requestIDContextKey, "request-1")
+		ctx.Header("X-Request-ID", "request-1")
+
+		// Respond with the error.
+		respondWithError(ctx, respondWithErrorTest.err)
+
+		// Assert the expected HTTP response code and problem.
+		assertResponseCode(t, respondWithErrorTest.expectedStatus, recorder.Code)
+		assertProblem(t, respondWithErrorTest.expectedCode, respondWithErrorTest.expectedField,
+			respondWithErrorTest.expectedDetail, recorder)
+	}
+}
+
+func TestRespondWithProblem(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Create the response recorder and a context for it.
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest("PATCH", "/assets/1?fields=name", nil)
+	ctx.Set(requestIDContextKey, "request-1")
+
+	// Respond with a problem.
+	respondWithProblem(ctx, http.StatusBadRequest, idMismatchCode, idKey, idMismatchError)
+
+	// Assert the expected HTTP response code, content type and body.
+	assertResponseCode(t, http.StatusBadRequest, recorder.Code)
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "application/problem+json" {
+		t.Fatalf("unexpected content type: %s", contentType)
+	}
+	assertResponseBody(t, "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,"+
+		"\"detail\":\"id in request body does not match the id in the path\",\"instance\":\"/assets/1\","+
+		"\"code\":\"id_mismatch\",\"field\":\"id\",\"requestId\":\"request-1\"}", recorder.Body.String())
+	if !ctx.IsAborted() {
+		t.Fatal("expected the request to be aborted")
+	}
+}
@@ -0,0 +1,181 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/paddyquinn/messari/database"
+	"github.com/paddyquinn/messari/database/models"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// statusParam filters the listed proposals by status.
+	statusParam = "status"
+
+	// Proposal error string constants.
+	commentError   = "could not comment on the proposal"
+	proposalError  = "could not get the proposal"
+	proposalsError = "could not list the proposals"
+	proposeError   = "could not propose the change"
+	reviewError    = "could not review the proposal"
+)
+
+// propose stores the update passed in via the request body, which has the same form as the body of /update, as a
+// proposal for a reviewer to approve rather than updating the crypto asset straight away. The proposal is returned.
+func (s *Server) propose(ctx *gin.Context) {
+	// Initialize the logger.
+	logger := log.WithField(endpoint, proposalsEndpoint)
+
+	// Parse the crypto asset passed in via the request body.
+	cryptoAsset, err := models.NewCryptoAsset(ctx.Request.Body)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(parseError)
+		respondWithInvalidBody(ctx, err)
+		return
+	}
+
+	// Ensure that the passed crypto asset has an id.
+	if cryptoAsset.ID == nil {
+		err = database.NewNullConstraintError(idKey)
+		logger.Error(err.Error())
+		respondWithError(ctx, err)
+		return
+	}
+
+	// Normalize and validate all of the fields in the crypto asset struct.
+	id, err := s.normalize(cryptoAsset, false)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(normalizeError)
+		respondWithError(ctx, err)
+		return
+	}
+	setAuditCryptoAssetID(ctx, id)
+	setAuditPayload(ctx, cryptoAsset)
+	actor := getActor(ctx)
+	logger = logger.WithFields(log.Fields{idKey: id, actorKey: actor})
+
+	// Store the proposal in the database.
+	proposalID, err := s.DB.Propose(id, cryptoAsset, actor)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(proposeError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	s.respondWithProposal(ctx, logger, proposalID)
+}
+
+// getProposal returns the proposal with the id given in the path.
+func (s *Server) getProposal(ctx *gin.Context) {
+	// Initialize the logger.
+	logger := log.WithField(endpoint, proposalEndpoint)
+
+	// Parse the id from the path.
+	id, err := parseID(ctx)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(proposalError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	s.respondWithProposal(ctx, logger.WithField(idKey, id), id)
+}
+
+// listProposals returns every proposal, oldest first. The proposals can be filtered by passing a "status" of pending,
+// approved or rejected in the query string.
+func (s *Server) listProposals(ctx *gin.Context) {
+	// Initialize the logger.
+	logger := log.WithField(endpoint, proposalsEndpoint)
+
+	// Parse the status passed in via the query string.
+	status := strings.ToLower(strings.TrimSpace(ctx.Query(statusParam)))
+	if status != "" && !models.IsStatus(status) {
+		err := newInvalidParameterError(statusParam, status)
+		logger.WithField(errKey, err.Error()).Error(queryError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	// Get the proposals from the database.
+	proposals, err := s.DB.Proposals(status)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(proposalsError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	// Format the changes of each proposal and return the proposals back to the user.
+	for _, proposal := range proposals {
+		proposal.Changes.Format()
+	}
+	ctx.JSON(http.StatusOK, proposals)
+}
+
+// approveProposal approves the proposal with the id given in the path, which applies its changes, and returns it.
+func (s *Server) approveProposal(ctx *gin.Context) {
+	s.reviewProposal(ctx, approveProposalEndpoint, s.DB.ApproveProposal)
+}
+
+// commentOnProposal records the comment passed in via the request body on the proposal with the id given in the path
+// and returns the proposal.
+func (s *Server) commentOnProposal(ctx *gin.Context) {
+	s.reviewProposal(ctx, proposalCommentsEndpoint, s.DB.CommentOnProposal)
+}
+
+// rejectProposal rejects the proposal with the id given in the path and returns it.
+func (s *Server) rejectProposal(ctx *gin.Context) {
+	s.reviewProposal(ctx, rejectProposalEndpoint, s.DB.RejectProposal)
+}
+
+// reviewProposal parses the id of a proposal from the path and an optional comment from the request body, passes them
+// to the given review along with the actor and returns the reviewed proposal.
+func (s *Server) reviewProposal(ctx *gin.Context, reviewEndpoint string, review func(int, string, string) error) {
+	// Initialize the logger.
+	logger := log.WithField(endpoint, reviewEndpoint)
+
+	// Parse the id from the path.
+	id, err := parseID(ctx)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(reviewError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	// Parse the review passed in via the request body.
+	parsedReview, err := models.NewReview(ctx.Request.Body)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(parseError)
+		respondWithInvalidBody(ctx, err)
+		return
+	}
+	setAuditPayload(ctx, parsedReview)
+	actor := getActor(ctx)
+	logger = logger.WithFields(log.Fields{idKey: id, actorKey: actor})
+
+	// Review the proposal in the database.
+	if err = review(id, actor, parsedReview.Comment); err != nil {
+		logger.WithField(errKey, err.Error()).Error(reviewError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	s.respondWithProposal(ctx, logger, id)
+}
+
+// respondWithProposal gets the proposal with the given id from the database, formats its changes and returns it back
+// to the user.
+func (s *Server) respondWithProposal(ctx *gin.Context, logger *log.Entry, id int) {
+	proposal, err := s.DB.Proposal(id)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(proposalError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	// The crypto asset the proposal changes is recorded in the audit log of a review.
+	ctx.Set(auditCryptoAssetIDContextKey, proposal.CryptoAssetID)
+	proposal.Changes.Format()
+	ctx.JSON(http.StatusOK, proposal)
+}
@@ -0,0 +1,257 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/paddyquinn/messari/database"
+	"github.com/paddyquinn/messari/database/models"
+	log "github.com/sirupsen/logrus"
+)
+
+func TestProposeEndpoint(t *testing.T) {
+	// Hide logs.
+	log.SetLevel(log.FatalLevel)
+
+	// Set up router for testing.
+	gin.SetMode(gin.TestMode)
+	mockDatabase := &database.Mock{}
+	mockRouter := setUpMockRouter(mockDatabase)
+	expectVocabularies(mockDatabase)
+	expectAudit(mockDatabase)
+
+	// Run tests.
+	testInvalidJSONMethod(t, mockRouter, "POST", "/proposals")
+	testProposeNullID(t, mockRouter)
+	testProposeEmptyUpdate(t, mockRouter, mockDatabase)
+	testProposeSuccess(t, mockRouter, mockDatabase)
+}
+
+func testProposeNullID(t *testing.T, mockRouter *gin.Engine) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, httptest.NewRequest("POST", "/proposals", strings.NewReader("{\"blockReward\":6.25}")))
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusBadRequest, recorder.Code)
+	assertProblem(t, nullFieldCode, "id", "id cannot be null", recorder)
+}
+
+func testProposeEmptyUpdate(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call. The proposal would not change anything.
+	id := "2"
+	blockReward := 3.0
+	cryptoAsset := &models.CryptoAsset{ID: &id, BlockReward: &blockReward}
+	req := httptest.NewRequest("POST", "/proposals", strings.NewReader("{\"id\":\"2\",\"blockReward\":3}"))
+	mockDatabase.On("Propose", 2, cryptoAsset, "anonymous").Return(-1, database.NewEmptyUpdateError())
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusBadRequest, recorder.Code)
+	assertProblem(t, emptyUpdateCode, "", "nothing to update", recorder)
+}
+
+func testProposeSuccess(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database calls.
+	id := "1"
+	blockReward := 6.25
+	cryptoAsset := &models.CryptoAsset{ID: &id, BlockReward: &blockReward}
+	req := httptest.NewRequest("POST", "/proposals", strings.NewReader("{\"id\":\"1\",\"blockReward\":6.25}"))
+	req.Header.Set("X-Actor", "bob")
+	mockDatabase.On("Propose", 1, cryptoAsset, "bob").Return(4, nil)
+	mockDatabase.On("Proposal", 4).Return(newProposal(models.PendingStatus), nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+	assertResponseBody(t, formattedProposal, recorder.Body.String())
+}
+
+func TestListProposalsEndpoint(t *testing.T) {
+	// Hide logs.
+	log.SetLevel(log.FatalLevel)
+
+	// Set up router for testing.
+	gin.SetMode(gin.TestMode)
+	mockDatabase := &database.Mock{}
+	mockRouter := setUpMockRouter(mockDatabase)
+
+	// Run tests.
+	testListProposalsInvalidStatus(t, mockRouter)
+	testListProposalsSuccess(t, mockRouter, mockDatabase)
+}
+
+func testListProposalsInvalidStatus(t *testing.T, mockRouter *gin.Engine) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, httptest.NewRequest("GET", "/proposals?status=open", nil))
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusBadRequest, recorder.Code)
+	assertProblem(t, invalidParameterCode, "status", "invalid status: open", recorder)
+}
+
+func testListProposalsSuccess(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call.
+	req := httptest.NewRequest("GET", "/proposals?status=Pending", nil)
+	mockDatabase.On("Proposals", "pending").Return([]*models.Proposal{newProposal(models.PendingStatus)}, nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+	assertResponseBody(t, "["+formattedProposal+"]", recorder.Body.String())
+}
+
+func TestReviewProposalEndpoints(t *testing.T) {
+	// Hide logs.
+	log.SetLevel(log.FatalLevel)
+
+	// Set up router for testing.
+	gin.SetMode(gin.TestMode)
+	mockDatabase := &database.Mock{}
+	mockRouter := setUpMockRouter(mockDatabase)
+	expectAudit(mockDatabase)
+
+	// Run tests.
+	testInvalidPathIDEndpoint(t, mockRouter, "POST", "/proposals/a/approve")
+	testGetProposalUnknownID(t, mockRouter, mockDatabase)
+	testApproveProposalReviewed(t, mockRouter, mockDatabase)
+	testCommentOnProposalEmpty(t, mockRouter, mockDatabase)
+	testRejectProposalSuccess(t, mockRouter, mockDatabase)
+}
+
+func testGetProposalUnknownID(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call.
+	req := httptest.NewRequest("GET", "/proposals/7", nil)
+	mockDatabase.On("Proposal", 7).Return(nil, database.NewUnknownProposalError(7))
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusNotFound, recorder.Code)
+	assertProblem(t, proposalNotFoundCode, "", "proposal with id 7 not found", recorder)
+}
+
+func testApproveProposalReviewed(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call. The request body is optional.
+	req := httptest.NewRequest("POST", "/proposals/3/approve", nil)
+	mockDatabase.On("ApproveProposal", 3, "anonymous", "").Return(
+		database.NewProposalReviewedError(3, models.RejectedStatus))
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusConflict, recorder.Code)
+	assertProblem(t, proposalReviewedCode, "", "proposal 3 has already been rejected", recorder)
+}
+
+func testCommentOnProposalEmpty(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call.
+	req := httptest.NewRequest("POST", "/proposals/4/comments", strings.NewReader("{\"comment\":\"  \"}"))
+	mockDatabase.On("CommentOnProposal", 4, "anonymous", "").Return(database.NewNullConstraintError("comment"))
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusBadRequest, recorder.Code)
+	assertProblem(t, nullFieldCode, "comment", "comment cannot be null", recorder)
+}
+
+func testRejectProposalSuccess(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database calls.
+	req := httptest.NewRequest("POST", "/proposals/4/reject", strings.NewReader("{\"comment\":\"no source\"}"))
+	req.Header.Set("X-Actor", "carol")
+	mockDatabase.On("RejectProposal", 4, "carol", "no source").Return(nil)
+	mockDatabase.On("Proposal", 4).Return(newProposal(models.RejectedStatus), nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+	assertResponseBody(t, strings.Replace(formattedProposal, "pending", "rejected", 1), recorder.Body.String())
+}
+
+const formattedProposal = "{\"id\":4,\"cryptoAssetId\":\"1\",\"status\":\"pending\",\"changes\":{\"id\":\"1\"," +
+	"\"name\":\"Bitcoin\",\"symbol\":null,\"description\":null,\"team\":null,\"icoAmount\":null,\"blockReward\":6.25," +
+	"\"fundingStatus\":null,\"foundedDate\":null,\"coinType\":null,\"website\":null},\"diff\":{\"blockReward\":" +
+	"{\"from\":12.5,\"to\":6.25}},\"proposedBy\":\"bob\",\"proposedAt\":\"2018-06-01T12:00:00Z\",\"events\":[" +
+	"{\"actor\":\"bob\",\"event\":\"proposed\",\"createdAt\":\"2018-06-01T12:00:00Z\"}]}"
+
+// newProposal creates a proposal to change the block reward of bitcoin that can be used in tests. The name in its
+// changes is normalized so that it can be seen to be formatted.
+func newProposal(status string) *models.Proposal {
+	id := "1"
+	name := "bitcoin"
+	blockReward := 6.25
+	return &models.Proposal{
+		ID:            4,
+		CryptoAssetID: id,
+		Status:        status,
+		Changes:       &models.CryptoAsset{ID: &id, Name: &name, BlockReward: &blockReward},
+		Diff:          map[string]*models.Change{"blockReward": {From: 12.5, To: 6.25}},
+		ProposedBy:    "bob",
+		ProposedAt:    "2018-06-01T12:00:00Z",
+		Events: []*models.ProposalEvent{
+			{Actor: "bob", Event: models.ProposedEvent, CreatedAt: "2018-06-01T12:00:00Z"},
+		},
+	}
+}
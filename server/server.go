@@ -1,145 +1,658 @@
 package server
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/paddyquinn/messari/config"
 	"github.com/paddyquinn/messari/database"
 	"github.com/paddyquinn/messari/database/models"
 	"github.com/paddyquinn/messari/util"
 	log "github.com/sirupsen/logrus"
-	"time"
 )
 
 const (
 	// Miscellaneous constants.
+	actorKey = "actor"
 	comma    = ","
 	endpoint = "endpoint"
 	errKey   = "error"
+	idKey    = "id"
+
+	// Actor constants. The actor is whoever is making a change, which is the name of their API key or, if auth is off,
+	// is given by the X-Actor header.
+	actorHeader  = "X-Actor"
+	defaultActor = "anonymous"
+
+	// Query string parameter constants.
+	asOfParam           = "asOf"
+	cursorParam         = "cursor"
+	fieldsParam         = "fields"
+	includeDeletedParam = "includeDeleted"
+	limitParam          = "limit"
+	prefixParam         = "prefix"
+	revisionParam       = "revision"
+	sortParam           = "sort"
+
+	// Autocomplete constants.
+	defaultSuggestions = 10
+	maxSuggestions     = 50
 
 	// Error string constants.
+	autocompleteError   = "could not autocomplete the prefix"
+	deleteError         = "could not delete the crypto asset"
+	exportError         = "could not export the crypto assets"
+	getError            = "could not get the crypto asset"
+	historyError        = "could not get the history of the crypto asset"
+	idMismatchError     = "id in request body does not match the id in the path"
 	insertError         = "could not insert the crypto asset into the database"
 	internalServerError = "internal server error"
 	normalizeError      = "crypto asset normalization failed"
-	nullTeamError       = "team cannot be null"
+	queryError          = "unable to parse the query string"
+	restoreError        = "could not restore the crypto asset"
+	revertError         = "could not revert the crypto asset"
+	revisionError       = "could not get the revision of the crypto asset"
 	parseError          = "unable to parse given crypto asset"
 	selectError         = "error performing select query on the database"
 	updateError         = "could not update the crypto asset"
 
 	// Endpoint constants.
-	registerEndpoint = "/register"
-	searchEndpoint   = "/search"
-	updateEndpoint   = "/update"
+	apiKeyEndpoint           = "/keys/:id"
+	apiKeysEndpoint          = "/keys"
+	approveProposalEndpoint  = "/proposals/:id/approve"
+	assetEndpoint            = "/assets/:id"
+	assetsEndpoint           = "/assets"
+	auditEndpoint            = "/audit"
+	autocompleteEndpoint     = "/autocomplete"
+	exportEndpoint           = "/export"
+	historyEndpoint          = "/assets/:id/history"
+	importEndpoint           = "/import"
+	membershipEndpoint       = "/people/:id/assets/:assetId"
+	peopleEndpoint           = "/people"
+	personAssetsEndpoint     = "/people/:id/assets"
+	personEndpoint           = "/people/:id"
+	proposalCommentsEndpoint = "/proposals/:id/comments"
+	proposalEndpoint         = "/proposals/:id"
+	proposalsEndpoint        = "/proposals"
+	registerEndpoint         = "/register"
+	rejectProposalEndpoint   = "/proposals/:id/reject"
+	renameValueEndpoint      = "/vocabularies/:vocabulary/:value/rename"
+	restoreEndpoint          = "/assets/:id/restore"
+	revertEndpoint           = "/assets/:id/revert"
+	searchEndpoint           = "/search"
+	updateEndpoint           = "/update"
+	vocabulariesEndpoint     = "/vocabularies"
+	vocabularyEndpoint       = "/vocabularies/:vocabulary"
+	vocabularyValueEndpoint  = "/vocabularies/:vocabulary/:value"
 )
 
+// searchPage is the envelope a paginated search is returned in. The next cursor is null on the last page.
+type searchPage struct {
+	Results    interface{} `json:"results" yaml:"results"`
+	NextCursor *string     `json:"nextCursor" yaml:"nextCursor"`
+}
+
+// newSearchPage creates a new search page envelope. An empty next cursor means there are no more pages.
+func newSearchPage(results interface{}, nextCursor string) *searchPage {
+	page := &searchPage{Results: results}
+	if nextCursor != "" {
+		page.NextCursor = &nextCursor
+	}
+	return page
+}
+
 // Server is the main struct that responds to HTTP requests with responses from the database.
 type Server struct {
-	DB database.Interface
+	DB     database.Interface
+	Config *config.Config
 }
 
-// NewServer creates a new server with the given database driver.
-func NewServer(db database.Interface) *Server {
-	return &Server{DB: db}
+// NewServer creates a new server with the given database driver and config.
+func NewServer(db database.Interface, cfg *config.Config) *Server {
+	return &Server{DB: db, Config: cfg}
 }
 
-// Start runs the server. This function will loop infinitely if no error occurs.
+// Start runs the server until it receives SIGINT or SIGTERM. It then stops accepting new connections and waits for the
+// in-flight requests to finish, for up to the configured shutdown timeout, before returning. Once Start returns, no
+// more requests are being handled and the database can be closed.
 func (s *Server) Start() error {
-	// Initialize router.
-	router := s.initializeRouter()
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	return s.serve(stop)
+}
+
+// serve runs the server until it fails or a signal is received on the stop channel, in which case it shuts down
+// gracefully.
+func (s *Server) serve(stop <-chan os.Signal) error {
+	// Initialize the HTTP server with the router and the configured address and timeouts. Exports lift the write
+	// timeout, which would otherwise cut them off, and imports lift both timeouts so that large files can be uploaded.
+	httpServer := &http.Server{
+		Addr:         s.Config.Address,
+		Handler:      withResponseController(s.initializeRouter()),
+		ReadTimeout:  s.Config.ReadTimeout,
+		WriteTimeout: s.Config.WriteTimeout,
+		IdleTimeout:  s.Config.IdleTimeout,
+	}
+
+	// Run the HTTP server in the background.
+	errs := make(chan error, 1)
+	go func() {
+		log.WithField("address", s.Config.Address).Info("listening for requests")
+		errs <- httpServer.ListenAndServe()
+	}()
 
-	// Run the router.
-	if err := router.Run(); err != nil {
+	// Wait for the HTTP server to fail or to be told to stop.
+	select {
+	case err := <-errs:
 		return err
+	case sig := <-stop:
+		log.WithField("signal", sig.String()).Info("shutting down")
 	}
 
-	// Unreachable because router.Run() either errors out or loops forever.
+	// Stop accepting connections and wait for the in-flight requests to finish. If they take too long, close their
+	// connections instead.
+	ctx, cancel := context.WithTimeout(context.Background(), s.Config.ShutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(ctx); err != nil {
+		httpServer.Close()
+		return err
+	}
 	return nil
 }
 
 // initializeRouter registers the endpoints to route to the correct methods.
 func (s *Server) initializeRouter() *gin.Engine {
 	router := gin.Default()
-	router.POST(registerEndpoint, s.register)
-	router.GET(searchEndpoint, s.search)
-	router.POST(updateEndpoint, s.update)
+	router.Use(assignRequestID)
+
+	// Reading crypto assets needs an API key with the read scope, proposing changes to them needs the propose scope,
+	// changing them needs the write scope, reviewing proposals needs the approve scope and managing API keys and
+	// vocabularies needs the admin scope.
+	read := s.authorize(models.ReadScope)
+	propose := s.authorize(models.ProposeScope)
+	write := s.authorize(models.WriteScope)
+	approve := s.authorize(models.ApproveScope)
+	admin := s.authorize(models.AdminScope)
+
+	// Every write, successful or not, is recorded in the audit log once it has been authorized. Writes other than API key
+	// management can be retried safely with an Idempotency-Key header; the response to creating an API key is never
+	// stored because it holds the key itself.
+	auditCreate := s.audit(models.CreateAction)
+	auditUpdate := s.audit(models.UpdateAction)
+	idempotent := s.idempotent
+
+	// The crypto asset resource.
+	router.POST(assetsEndpoint, write, auditCreate, idempotent, s.register)
+	router.GET(assetsEndpoint, read, s.search)
+	router.GET(assetEndpoint, read, s.getAsset)
+	router.PUT(assetEndpoint, write, auditUpdate, idempotent, s.replaceAsset)
+	router.PATCH(assetEndpoint, write, auditUpdate, idempotent, s.patchAsset)
+	router.DELETE(assetEndpoint, write, s.audit(models.DeleteAction), idempotent, s.deleteAsset)
+	router.POST(restoreEndpoint, write, s.audit(models.RestoreAction), idempotent, s.restoreAsset)
+	router.GET(historyEndpoint, read, s.history)
+	router.POST(revertEndpoint, write, s.audit(models.RevertAction), idempotent, s.revertAsset)
+
+	// Registering many crypto assets at once from a file, and exporting them to one. An import is not bound by the read
+	// and write timeouts, as a large file can take a while to upload.
+	router.POST(importEndpoint, write, liftDeadlines, s.audit(models.ImportAction), idempotent, s.importAssets)
+	router.GET(exportEndpoint, read, s.exportAssets)
+
+	// The people on the teams of crypto assets, and their roles and tenure on each team.
+	router.GET(peopleEndpoint, read, s.listPeople)
+	router.GET(personEndpoint, read, s.getPerson)
+	router.PATCH(personEndpoint, write, s.audit(models.UpdatePersonAction), idempotent, s.patchPerson)
+	router.GET(personAssetsEndpoint, read, s.personAssets)
+	router.PUT(membershipEndpoint, write, s.audit(models.UpdateMembershipAction), idempotent, s.updateMembership)
+
+	// Suggestions for a partially typed name or symbol.
+	router.GET(autocompleteEndpoint, read, s.autocomplete)
+
+	// Aliases kept so that existing clients continue to work.
+	router.POST(registerEndpoint, write, auditCreate, idempotent, s.register)
+	router.GET(searchEndpoint, read, s.search)
+	router.POST(updateEndpoint, write, auditUpdate, idempotent, s.update)
+
+	// Proposed changes waiting for review.
+	router.POST(proposalsEndpoint, propose, s.audit(models.ProposeAction), idempotent, s.propose)
+	router.GET(proposalsEndpoint, read, s.listProposals)
+	router.GET(proposalEndpoint, read, s.getProposal)
+	router.POST(proposalCommentsEndpoint, propose, s.audit(models.CommentAction), idempotent, s.commentOnProposal)
+	router.POST(approveProposalEndpoint, approve, s.audit(models.ApproveAction), idempotent, s.approveProposal)
+	router.POST(rejectProposalEndpoint, approve, s.audit(models.RejectAction), idempotent, s.rejectProposal)
+
+	// The controlled vocabularies of funding statuses and coin types.
+	router.GET(vocabulariesEndpoint, read, s.listVocabularies)
+	router.POST(vocabularyEndpoint, admin, s.audit(models.AddValueAction), idempotent, s.addVocabularyValue)
+	router.POST(renameValueEndpoint, admin, s.audit(models.RenameAction), idempotent, s.renameVocabularyValue)
+	router.DELETE(vocabularyValueEndpoint, admin, s.audit(models.RetireValueAction), idempotent, s.retireVocabularyValue)
+
+	// API key management and the audit log.
+	router.POST(apiKeysEndpoint, admin, s.audit(models.CreateKeyAction), s.createAPIKey)
+	router.GET(apiKeysEndpoint, admin, s.listAPIKeys)
+	router.DELETE(apiKeyEndpoint, admin, s.audit(models.RevokeKeyAction), s.revokeAPIKey)
+	router.GET(auditEndpoint, admin, s.auditLog)
+
 	return router
 }
 
-// register creates an entry in the database for the given crypto asset.
+// autocomplete suggests crypto assets whose name or symbol starts with, or nearly starts with, the prefix passed in via
+// the query string.
+func (s *Server) autocomplete(ctx *gin.Context) {
+	// Initialize the logger.
+	logger := log.WithField(endpoint, autocompleteEndpoint)
+
+	// Parse the prefix and the maximum number of suggestions passed in via the query string.
+	prefix, limit, err := parseAutocompleteQuery(ctx)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(queryError)
+		respondWithError(ctx, err)
+		return
+	}
+	logger = logger.WithField(prefixParam, prefix)
+
+	// Get the suggestions from the database.
+	suggestions, err := s.DB.Autocomplete(prefix, limit)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(autocompleteError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	// Format each suggestion and return them back to the user.
+	for _, suggestion := range suggestions {
+		suggestion.Format()
+	}
+	ctx.JSON(http.StatusOK, suggestions)
+}
+
+// deleteAsset soft deletes the crypto asset with the id given in the path. It can be brought back with restoreAsset. If
+// the If-Match header is passed, the crypto asset is only deleted if it is still at that version.
+func (s *Server) deleteAsset(ctx *gin.Context) {
+	// Initialize the logger.
+	logger := log.WithField(endpoint, assetEndpoint)
+
+	// Parse the id from the path.
+	id, err := parseID(ctx)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(normalizeError)
+		respondWithError(ctx, err)
+		return
+	}
+	setAuditCryptoAssetID(ctx, id)
+	actor := getActor(ctx)
+	logger = logger.WithFields(log.Fields{idKey: id, actorKey: actor})
+
+	// Parse the version the crypto asset is expected to be at from the If-Match header.
+	version, err := parseIfMatch(ctx)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(ifMatchError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	// Soft delete the crypto asset in the database.
+	if err = s.DB.Delete(id, version, actor); err != nil {
+		logger.WithField(errKey, err.Error()).Error(deleteError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	// There is nothing left to return to the user.
+	ctx.Status(http.StatusNoContent)
+}
+
+// getAsset returns the crypto asset with the id given in the path, along with its version as the ETag, in the format
+// negotiated by negotiateFormat. Deleted crypto assets are only returned if "includeDeleted" is true. If the
+// If-None-Match header matches the ETag, nothing is returned but a 304.
+func (s *Server) getAsset(ctx *gin.Context) {
+	// Initialize the logger.
+	logger := log.WithField(endpoint, assetEndpoint)
+
+	// Parse the id from the path.
+	id, err := parseID(ctx)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(normalizeError)
+		respondWithError(ctx, err)
+		return
+	}
+	logger = logger.WithField(idKey, id)
+
+	// Negotiate the format to return the crypto asset in.
+	format, err := negotiateFormat(ctx)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(negotiateError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	// Parse whether to return the crypto asset if it has been deleted.
+	includeDeleted, err := parseBoolean(ctx, includeDeletedParam)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(queryError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	// Get the crypto asset from the database. A deleted crypto asset is not found unless it was asked for.
+	cryptoAsset, err := s.DB.Get(id)
+	if err == nil && cryptoAsset.DeletedAt != nil && (includeDeleted == nil || !*includeDeleted) {
+		err = database.NewUnknownIDError(id)
+	}
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(getError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	// Tell the user if the crypto asset has not changed since they last got it.
+	setETag(ctx, cryptoAsset)
+	if isNotModified(ctx, cryptoAsset) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
+	// Format the crypto asset and return it back to the user in the format they asked for.
+	respondWithCryptoAsset(ctx, format, cryptoAsset)
+}
+
+// patchAsset merges the fields passed in the request body into the crypto asset with the id given in the path. Null
+// fields are left untouched.
+func (s *Server) patchAsset(ctx *gin.Context) {
+	s.modifyAsset(ctx, false)
+}
+
+// replaceAsset replaces every field of the crypto asset with the id given in the path. Unlike patchAsset, every field
+// must be passed in the request body.
+func (s *Server) replaceAsset(ctx *gin.Context) {
+	s.modifyAsset(ctx, true)
+}
+
+// history returns every revision of the crypto asset with the id given in the path, oldest first.
+func (s *Server) history(ctx *gin.Context) {
+	// Initialize the logger.
+	logger := log.WithField(endpoint, historyEndpoint)
+
+	// Parse the id from the path.
+	id, err := parseID(ctx)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(normalizeError)
+		respondWithError(ctx, err)
+		return
+	}
+	logger = logger.WithField(idKey, id)
+
+	// Get the revisions from the database.
+	revisions, err := s.DB.History(id)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(historyError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	// Format the snapshot of each revision and return the revisions back to the user.
+	for _, revision := range revisions {
+		revision.Snapshot.Format()
+	}
+	ctx.JSON(http.StatusOK, revisions)
+}
+
+// modifyAsset updates the crypto asset with the id given in the path as described by updateAsset. If replace is true,
+// the update is a full replacement.
+func (s *Server) modifyAsset(ctx *gin.Context, replace bool) {
+	// Initialize the logger.
+	logger := log.WithField(endpoint, assetEndpoint)
+
+	// Parse the crypto asset passed in via the request body.
+	cryptoAsset, err := models.NewCryptoAsset(ctx.Request.Body)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(parseError)
+		respondWithInvalidBody(ctx, err)
+		return
+	}
+
+	// The id is taken from the path. An id in the request body is allowed only if it refers to the same crypto asset.
+	pathID := ctx.Param(idKey)
+	if cryptoAsset.ID != nil && strings.TrimSpace(*cryptoAsset.ID) != pathID {
+		logger.Error(idMismatchError)
+		respondWithProblem(ctx, http.StatusBadRequest, idMismatchCode, idKey, idMismatchError)
+		return
+	}
+	cryptoAsset.ID = &pathID
+
+	s.updateAsset(ctx, logger, cryptoAsset, replace)
+}
+
+// register creates an entry in the database for the given crypto asset. Passing "dryRun=true" in the query string
+// validates the crypto asset without creating it.
 func (s *Server) register(ctx *gin.Context) {
 	// Initialize the logger.
 	logger := log.WithField(endpoint, registerEndpoint)
 
+	// Parse whether this is a dry run from the query string.
+	dryRun, err := parseDryRun(ctx)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(queryError)
+		respondWithError(ctx, err)
+		return
+	}
+
 	// Parse the crypto asset passed in via the POST request.
 	cryptoAsset, err := models.NewCryptoAsset(ctx.Request.Body)
 	if err != nil {
-		errString := err.Error()
-		logger.WithField(errKey, errString).Error(parseError)
-		ctx.JSON(http.StatusBadRequest, map[string]string{errKey: errString})
+		logger.WithField(errKey, err.Error()).Error(parseError)
+		respondWithInvalidBody(ctx, err)
 		return
 	}
 
-	// Ensure that a list of team members was passed in via the POST request. It is important to note that a null team is
-	// different from an empty team. The register endpoint will reject JSON with no "team" key but will accept JSON of the
-	// form {"team": []}. This allows for empty teams but forces the user to explicitly intend to pass in an empty team.
-	if cryptoAsset.Team == nil {
-		logger.Error(nullTeamError)
-		ctx.JSON(http.StatusBadRequest, map[string]string{errKey: nullTeamError})
+	// Normalize and validate all of the fields in the newly created crypto asset struct, every one of which is required.
+	// It is important to note that a null team is different from an empty team. The register endpoint will reject JSON
+	// with no "team" key but will accept JSON of the form {"team": []}. This allows for empty teams but forces the user
+	// to explicitly intend to pass in an empty team.
+	if _, err = s.normalize(cryptoAsset, true); err != nil {
+		logger.WithField(errKey, err.Error()).Error(normalizeError)
+		respondWithError(ctx, err)
 		return
 	}
+	setAuditPayload(ctx, cryptoAsset)
 
-	// Normalize all of the fields in the newly created crypto asset struct. This will only fail if the date is not in
-	// ISO-8601 format, the ICO amount or block reward are less than 0, or the id cannot be converted to an int, which is
-	// irrelevant in this context.
-	_, err = cryptoAsset.Normalize()
-	if err != nil {
-		errString := err.Error()
-		logger.WithField(errKey, errString).Error(normalizeError)
-		ctx.JSON(http.StatusBadRequest, map[string]string{errKey: errString})
+	// A dry run returns the crypto asset as it would be registered, without an id.
+	if dryRun {
+		s.respondWithDryRunInsert(ctx, logger, cryptoAsset)
 		return
 	}
 
-	// Insert the crypto asset into the database. Note that the actual error string is only exposed to the user if a
-	// user error that occurred. An internal database error is hidden behind a generic error message.
-	id, err := s.DB.Insert(cryptoAsset)
+	// Insert the crypto asset into the database.
+	id, err := s.DB.Insert(cryptoAsset, getActor(ctx))
 	if err != nil {
-		errString := err.Error()
-		log.WithField(errKey, errString).Error(insertError)
-		switch err.(type) {
-		case *database.NullConstraintError, *database.UniqueConstraintError:
-			ctx.JSON(http.StatusBadRequest, map[string]string{errKey: errString})
-		default:
-			ctx.JSON(http.StatusInternalServerError, map[string]string{errKey: internalServerError})
-		}
+		logger.WithField(errKey, err.Error()).Error(insertError)
+		respondWithError(ctx, err)
 		return
 	}
 
 	// Return the id back to the user.
+	ctx.Set(auditCryptoAssetIDContextKey, id)
 	ctx.JSON(http.StatusOK, map[string]string{"id": id})
 }
 
-// search performs a search for crypto assets given the parameters passed in via the query string.
+// restoreAsset restores the deleted crypto asset with the id given in the path and returns it. If the If-Match header
+// is passed, the crypto asset is only restored if it is still at that version.
+func (s *Server) restoreAsset(ctx *gin.Context) {
+	// Initialize the logger.
+	logger := log.WithField(endpoint, restoreEndpoint)
+
+	// Parse the id from the path.
+	id, err := parseID(ctx)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(normalizeError)
+		respondWithError(ctx, err)
+		return
+	}
+	setAuditCryptoAssetID(ctx, id)
+	actor := getActor(ctx)
+	logger = logger.WithFields(log.Fields{idKey: id, actorKey: actor})
+
+	// Parse the version the crypto asset is expected to be at from the If-Match header.
+	version, err := parseIfMatch(ctx)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(ifMatchError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	// Restore the crypto asset in the database.
+	if err = s.DB.Restore(id, version, actor); err != nil {
+		logger.WithField(errKey, err.Error()).Error(restoreError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	// Get the restored crypto asset from the database.
+	cryptoAsset, err := s.DB.Get(id)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(getError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	// Format the crypto asset and return it back to the user along with its new version.
+	setETag(ctx, cryptoAsset)
+	cryptoAsset.Format()
+	ctx.JSON(http.StatusOK, cryptoAsset)
+}
+
+// revertAsset reverts the crypto asset with the id given in the path to the revision passed in via the query string
+// and returns it. The fields and team of the revision go through the same normalization and checks as an update,
+// including the If-Match header.
+func (s *Server) revertAsset(ctx *gin.Context) {
+	// Initialize the logger.
+	logger := log.WithField(endpoint, revertEndpoint)
+
+	// Parse the id from the path and the revision from the query string.
+	id, err := parseID(ctx)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(normalizeError)
+		respondWithError(ctx, err)
+		return
+	}
+	revisionNumber, err := parseRevision(ctx)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(queryError)
+		respondWithError(ctx, err)
+		return
+	}
+	setAuditCryptoAssetID(ctx, id)
+	setAuditPayload(ctx, map[string]int{revisionParam: revisionNumber})
+	actor := getActor(ctx)
+	logger = logger.WithFields(log.Fields{idKey: id, revisionParam: revisionNumber, actorKey: actor})
+
+	// Get the revision to revert to from the database.
+	revision, err := s.DB.Revision(id, revisionNumber)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(revisionError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	// Normalize and validate the snapshot of the revision as if it had been passed in as a full replacement. Every value
+	// of the snapshot was stored once, so none of them is held to a format rule added since.
+	cryptoAsset := revision.Snapshot
+	stored := *cryptoAsset
+	if _, err = s.normalizeChanges(cryptoAsset, true, &stored); err != nil {
+		logger.WithField(errKey, err.Error()).Error(normalizeError)
+		respondWithError(ctx, err)
+		return
+	}
+	if cryptoAsset.Version, err = parseIfMatch(ctx); err != nil {
+		logger.WithField(errKey, err.Error()).Error(ifMatchError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	// Revert the crypto asset in the database.
+	if err = s.DB.Revert(id, cryptoAsset, actor); err != nil {
+		logger.WithField(errKey, err.Error()).Error(revertError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	// Read the crypto asset back from the database so the user sees the result of the revert.
+	revertedCryptoAsset, err := s.DB.Get(id)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(getError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	// Format the crypto asset and return it back to the user along with its new version.
+	setETag(ctx, revertedCryptoAsset)
+	revertedCryptoAsset.Format()
+	ctx.JSON(http.StatusOK, revertedCryptoAsset)
+}
+
+// search performs a search for crypto assets given the parameters passed in via the query string and returns them in
+// the format negotiated by negotiateFormat.
 func (s *Server) search(ctx *gin.Context) {
 	// Initialize the logger.
 	logger := log.WithField(endpoint, searchEndpoint)
 
+	// Negotiate the format to return the crypto assets in.
+	format, err := negotiateFormat(ctx)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(negotiateError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	// Parse the query passed in via the query string.
+	query, err := parseQueryString(ctx)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(queryError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	// Search for the values that the funding statuses and coin types passed in are aliases of.
+	if err = s.canonicalize(query); err != nil {
+		logger.WithField(errKey, err.Error()).Error(vocabulariesError)
+		respondWithError(ctx, err)
+		return
+	}
+
 	// Get the crypto assets from the database.
-	cryptoAssets, err := s.DB.Select(parseQueryString(ctx))
+	cryptoAssets, nextCursor, err := s.DB.Select(query)
 	if err != nil {
 		logger.WithField(errKey, err.Error()).Error(selectError)
-		ctx.JSON(http.StatusInternalServerError, map[string]string{errKey: internalServerError})
+		respondWithError(ctx, err)
 		return
 	}
 
-	// Format each crypto asset and return them all back to the user.
-	for _, cryptoAsset := range cryptoAssets {
-		cryptoAsset.Format()
+	// Return the crypto assets back to the user in the format they asked for. A paginated search wraps them in an
+	// envelope holding the cursor to the next page, which is also returned in the X-Next-Cursor header because CSV has
+	// nowhere else to put it, while an unpaginated search returns a plain array as it always has.
+	var wrap func(results interface{}) interface{}
+	if isPaginated(ctx) {
+		if nextCursor != "" {
+			ctx.Header(nextCursorHeader, nextCursor)
+		}
+		wrap = func(results interface{}) interface{} {
+			return newSearchPage(results, nextCursor)
+		}
 	}
-	ctx.JSON(http.StatusOK, cryptoAssets)
+	respondWithCryptoAssets(ctx, format, cryptoAssets, query.Fields, query.IncludeDeleted, wrap)
 }
 
-// update performs an update on a crypto asset given its id and the fields to update.
+// update performs an update on a crypto asset given its id and the fields to update and returns the updated crypto
+// asset. Passing "dryRun=true" in the query string validates the update without making it.
 func (s *Server) update(ctx *gin.Context) {
 	// Initialize the logger.
 	logger := log.WithField(endpoint, updateEndpoint)
@@ -148,51 +661,281 @@ func (s *Server) update(ctx *gin.Context) {
 	cryptoAsset, err := models.NewCryptoAsset(ctx.Request.Body)
 	if err != nil {
 		logger.WithField(errKey, err.Error()).Error(parseError)
-		ctx.JSON(http.StatusBadRequest, false)
+		respondWithInvalidBody(ctx, err)
 		return
 	}
 
 	// Ensure that the passed crypto asset has an id.
 	if cryptoAsset.ID == nil {
-		logger.Error("no id in POST request")
-		ctx.JSON(http.StatusBadRequest, false)
+		err = database.NewNullConstraintError(idKey)
+		logger.Error(err.Error())
+		respondWithError(ctx, err)
 		return
 	}
 
-	// Normalize all of the fields in the newly created crypto asset struct. This will only fail if the date is not in
-	// ISO-8601 format or the id cannot be converted to an int.
-	id, err := cryptoAsset.Normalize()
+	s.updateAsset(ctx, logger, cryptoAsset, false)
+}
+
+// updateAsset normalizes and validates the fields of the given crypto asset, applies them to the crypto asset with the
+// same id and returns the updated crypto asset along with its new version. If complete is true, as it is for a full
+// replacement, the request body is rejected unless it contains every field of a crypto asset. If the If-Match header is
+// passed, the crypto asset is only updated if it is still at that version. Passing "dryRun=true" in the query string
+// validates the update without making it and returns the crypto asset as it would be after the update.
+func (s *Server) updateAsset(ctx *gin.Context, logger *log.Entry, cryptoAsset *models.CryptoAsset, complete bool) {
+	// Parse whether this is a dry run from the query string.
+	dryRun, err := parseDryRun(ctx)
 	if err != nil {
-		logger.WithField(errKey, err.Error()).Error(normalizeError)
-		ctx.JSON(http.StatusBadRequest, false)
+		logger.WithField(errKey, err.Error()).Error(queryError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	// Get the crypto asset as it is stored, so that the values the update leaves as they are are not held to format
+	// rules added since they were stored.
+	stored, err := s.storedCryptoAsset(cryptoAsset)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(getError)
+		respondWithError(ctx, err)
 		return
 	}
-	logger.WithField("id", id)
 
-	// Update the crypto asset with the given id in the database. If an empty update or a
-	err = s.DB.Update(id, cryptoAsset)
+	// Normalize and validate all of the fields in the crypto asset struct.
+	id, err := s.normalizeChanges(cryptoAsset, complete, stored)
 	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(normalizeError)
+		respondWithError(ctx, err)
+		return
+	}
+	logger = logger.WithField(idKey, id)
+	setAuditCryptoAssetID(ctx, id)
+	setAuditPayload(ctx, cryptoAsset)
+
+	// Parse the version the crypto asset is expected to be at from the If-Match header.
+	if cryptoAsset.Version, err = parseIfMatch(ctx); err != nil {
+		logger.WithField(errKey, err.Error()).Error(ifMatchError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	if dryRun {
+		s.respondWithDryRunUpdate(ctx, logger, id, cryptoAsset)
+		return
+	}
+
+	// Update the crypto asset with the given id in the database.
+	if err = s.DB.Update(id, cryptoAsset, getActor(ctx)); err != nil {
 		logger.WithField(errKey, err.Error()).Error(updateError)
-		switch err.(type) {
-		case *database.EmptyUpdateError, *database.UnknownIDError:
-			ctx.JSON(http.StatusBadRequest, false)
-		default:
-			ctx.JSON(http.StatusInternalServerError, false)
-		}
+		respondWithError(ctx, err)
+		return
+	}
+
+	// Read the crypto asset back from the database so the user sees the result of the update.
+	updatedCryptoAsset, err := s.DB.Get(id)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(getError)
+		respondWithError(ctx, err)
 		return
 	}
 
-	// Return a true boolean to the user.
-	ctx.JSON(http.StatusOK, true)
+	// Format the crypto asset and return it back to the user along with its new version.
+	setETag(ctx, updatedCryptoAsset)
+	updatedCryptoAsset.Format()
+	ctx.JSON(http.StatusOK, updatedCryptoAsset)
+}
+
+// storedCryptoAsset returns the stored crypto asset with the id of the given crypto asset. It returns nil if the id is
+// not a number or no crypto asset has it, leaving those to be reported by the validation and the update.
+func (s *Server) storedCryptoAsset(cryptoAsset *models.CryptoAsset) (*models.CryptoAsset, error) {
+	id, err := strconv.Atoi(*cryptoAsset.ID)
+	if err != nil {
+		return nil, nil
+	}
+	stored, err := s.DB.Get(id)
+	if _, ok := err.(*database.UnknownIDError); ok {
+		return nil, nil
+	}
+	return stored, err
+}
+
+// getActor returns whoever is making the request. That is the name of the API key the request was made with or, if the
+// request was let through without one, the X-Actor header.
+func getActor(ctx *gin.Context) string {
+	if apiKey, ok := ctx.Get(apiKeyContextKey); ok {
+		return apiKey.(*models.APIKey).Name
+	}
+	if actor := strings.TrimSpace(ctx.GetHeader(actorHeader)); actor != "" {
+		return actor
+	}
+	return defaultActor
+}
+
+// parseID extracts the crypto asset id from the path.
+func parseID(ctx *gin.Context) (int, error) {
+	idString := ctx.Param(idKey)
+	id, err := strconv.Atoi(idString)
+	if err != nil {
+		return -1, newInvalidParameterError(idKey, idString)
+	}
+	return id, nil
+}
+
+// parseRevision extracts the number of the revision to revert to from the query string. Revisions are numbered from 1.
+func parseRevision(ctx *gin.Context) (int, error) {
+	revisionString := ctx.Query(revisionParam)
+	revision, err := strconv.Atoi(revisionString)
+	if err != nil || revision < 1 {
+		return -1, newInvalidParameterError(revisionParam, revisionString)
+	}
+	return revision, nil
+}
+
+// isPaginated determines whether the user asked for a page of search results rather than every result.
+func isPaginated(ctx *gin.Context) bool {
+	_, hasLimit := ctx.GetQuery(limitParam)
+	_, hasCursor := ctx.GetQuery(cursorParam)
+	return hasLimit || hasCursor
+}
+
+// parseAutocompleteQuery extracts the normalized prefix and the maximum number of suggestions from the query string.
+// The limit defaults to 10 and cannot be more than 50. An error is returned if the prefix is empty or the limit is out
+// of range.
+func parseAutocompleteQuery(ctx *gin.Context) (string, int, error) {
+	prefix := *util.Normalize(ctx.Query(prefixParam))
+	if prefix == "" {
+		return "", 0, newParameterError(prefixParam, "prefix cannot be empty")
+	}
+
+	limit := defaultSuggestions
+	if limitString, ok := ctx.GetQuery(limitParam); ok {
+		var err error
+		limit, err = strconv.Atoi(limitString)
+		if err != nil || limit < 1 || limit > maxSuggestions {
+			return "", 0, newParameterError(limitParam, fmt.Sprintf("limit must be between 1 and %d: %s", maxSuggestions,
+				limitString))
+		}
+	}
+
+	return prefix, limit, nil
+}
+
+// parseQueryString extracts a query from the query string. The "name", "symbol", "fundingStatus", "coinType" and
+// "teamMember" filters, as well as the "sort" keys and the projected "fields", can have multiple comma separated
+// values. The "startDate" and "endDate" filters and the "minIcoAmount", "maxIcoAmount", "minBlockReward" and
+// "maxBlockReward" range filters will always take the first comma separated value, as do "hasBlockReward" and
+// "includeDeleted", which includes deleted crypto assets in the results when true. The "q" parameter is the text of a
+// full-text search. The "limit" and "cursor" select a page of results, and a missing limit means every result is
+// returned. "asOf" searches the crypto assets as they were at a point in time. An error is returned if the limit or a
+// range filter is not a number, if hasBlockReward or includeDeleted is not a boolean, or if asOf is neither a timestamp
+// nor a date. The rest of the query is validated by the database.
+func parseQueryString(ctx *gin.Context) (*database.Query, error) {
+	query := &database.Query{
+		Names:           splitQueryArray(ctx.QueryArray("name")),
+		Symbols:         splitQueryArray(ctx.QueryArray("symbol")),
+		FundingStatuses: splitQueryArray(ctx.QueryArray("fundingStatus")),
+		CoinTypes:       splitQueryArray(ctx.QueryArray("coinType")),
+		TeamMembers:     splitFieldArray(ctx.QueryArray("teamMember")),
+		StartDate:       parseDate(ctx.Query("startDate")),
+		EndDate:         parseDate(ctx.Query("endDate")),
+		Text:            strings.TrimSpace(ctx.Query("q")),
+		Cursor:          ctx.Query(cursorParam),
+		Fields:          splitFieldArray(ctx.QueryArray(fieldsParam)),
+	}
+
+	for _, sortKey := range splitFieldArray(ctx.QueryArray(sortParam)) {
+		query.Sort = append(query.Sort, database.ParseSortKey(sortKey))
+	}
+
+	if limitString, ok := ctx.GetQuery(limitParam); ok {
+		limit, err := strconv.Atoi(limitString)
+		if err != nil {
+			return nil, newInvalidParameterError(limitParam, limitString)
+		}
+		query.Limit = limit
+	}
+
+	// Parse the numeric range filters.
+	var err error
+	if query.MinICOAmount, err = parseNumber(ctx, "minIcoAmount"); err != nil {
+		return nil, err
+	}
+	if query.MaxICOAmount, err = parseNumber(ctx, "maxIcoAmount"); err != nil {
+		return nil, err
+	}
+	if query.MinBlockReward, err = parseNumber(ctx, "minBlockReward"); err != nil {
+		return nil, err
+	}
+	if query.MaxBlockReward, err = parseNumber(ctx, "maxBlockReward"); err != nil {
+		return nil, err
+	}
+
+	// Parse the boolean filters.
+	if query.HasBlockReward, err = parseBoolean(ctx, "hasBlockReward"); err != nil {
+		return nil, err
+	}
+	includeDeleted, err := parseBoolean(ctx, includeDeletedParam)
+	if err != nil {
+		return nil, err
+	}
+	query.IncludeDeleted = includeDeleted != nil && *includeDeleted
+
+	if asOfString, ok := ctx.GetQuery(asOfParam); ok {
+		if query.AsOf, err = parseTimestamp(asOfParam, asOfString, true); err != nil {
+			return nil, err
+		}
+	}
+
+	return query, nil
+}
+
+// parseNumber parses the first comma separated value of a numeric query string parameter. Nil is returned if the
+// parameter is not in the query string and an error is returned if it is not a number.
+func parseNumber(ctx *gin.Context, param string) (*float64, error) {
+	numberString, ok := ctx.GetQuery(param)
+	if !ok {
+		return nil, nil
+	}
+
+	numberString = strings.TrimSpace(strings.Split(numberString, comma)[0])
+	number, err := strconv.ParseFloat(numberString, 64)
+	if err != nil {
+		return nil, newInvalidParameterError(param, numberString)
+	}
+	return &number, nil
 }
 
-// parseQueryString extracts the "name", "symbol", "fundingStatus", "coinType", "startDate", and "endDate" from the
-// query string. Note that "startDate" and "endDate" will always take the first comma separated value while the rest
-// will be an array and can have multiple values.
-func parseQueryString(ctx *gin.Context) ([]string, []string, []string, []string, string, string) {
-	return splitQueryArray(ctx.QueryArray("name")), splitQueryArray(ctx.QueryArray("symbol")),
-		splitQueryArray(ctx.QueryArray("fundingStatus")), splitQueryArray(ctx.QueryArray("coinType")),
-		parseDate(ctx.Query("startDate")), parseDate(ctx.Query("endDate"))
+// parseTimestamp parses a point in time passed in via the given query string parameter into an RFC 3339 UTC
+// timestamp. Either an RFC 3339 timestamp or an ISO-8601 date is accepted. A date means the start of that day in UTC,
+// or the end of it if endOfDay is true.
+func parseTimestamp(param, timestampString string, endOfDay bool) (string, error) {
+	timestampString = strings.TrimSpace(timestampString)
+	timestamp, err := time.Parse(time.RFC3339, timestampString)
+	if err != nil {
+		date, dateErr := time.Parse("2006-01-02", timestampString)
+		if dateErr != nil {
+			return "", newInvalidParameterError(param, timestampString)
+		}
+		timestamp = date
+		if endOfDay {
+			timestamp = date.Add(24*time.Hour - time.Second)
+		}
+	}
+	return timestamp.UTC().Format(time.RFC3339), nil
+}
+
+// parseBoolean parses the first comma separated value of a boolean query string parameter. It returns nil if the
+// parameter is missing and an error if it is not a boolean.
+func parseBoolean(ctx *gin.Context, param string) (*bool, error) {
+	booleanString, ok := ctx.GetQuery(param)
+	if !ok {
+		return nil, nil
+	}
+
+	booleanString = strings.TrimSpace(strings.Split(booleanString, comma)[0])
+	boolean, err := strconv.ParseBool(booleanString)
+	if err != nil {
+		return nil, newInvalidParameterError(param, booleanString)
+	}
+	return &boolean, nil
 }
 
 // parseDate takes the first date value and ensures it is in ISO-8601 format. Otherwise, an empty string is returned.
@@ -205,6 +948,21 @@ func parseDate(query string) string {
 	return date
 }
 
+// splitFieldArray splits a query string parameter holding crypto asset field names into its comma separated values.
+// Unlike splitQueryArray, the values are only trimmed because field names are case sensitive. Team member names are
+// split the same way because they are only trimmed when a crypto asset is normalized.
+func splitFieldArray(queryArray []string) []string {
+	var fields []string
+	for _, queryValue := range queryArray {
+		for _, field := range strings.Split(queryValue, comma) {
+			if field = strings.TrimSpace(field); field != "" {
+				fields = append(fields, field)
+			}
+		}
+	}
+	return fields
+}
+
 // splitQueryArray splits a query string parameter into its comma separated values.
 func splitQueryArray(queryArray []string) []string {
 	var commaSeparatedArray []string
@@ -4,15 +4,22 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"reflect"
 	"strings"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/paddyquinn/messari/config"
 	"github.com/paddyquinn/messari/database"
 	"github.com/paddyquinn/messari/database/models"
 	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestRegisterEndpoint(t *testing.T) {
@@ -23,36 +30,126 @@ func TestRegisterEndpoint(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockDatabase := &database.Mock{}
 	mockRouter := setUpMockRouter(mockDatabase)
+	expectVocabularies(mockDatabase)
+	expectAudit(mockDatabase)
 
 	// Run the tests.
-	testInvalidJSON(t, mockRouter, registerEndpoint, "{\"error\":\"unexpected EOF\"}")
-	testNullTeam(t, mockRouter, registerEndpoint, "{\"error\":\"team cannot be null\"}")
-	testNormalizationError(t, mockRouter, registerEndpoint, "{\"error\":\"date must be an ISO-8601 date in the past\"}")
+	testInvalidJSON(t, mockRouter, registerEndpoint)
+	testRegisterValidationError(t, mockRouter)
+	testNormalizationError(t, mockRouter, registerEndpoint)
 	testRegisterUserError(t, mockRouter, mockDatabase)
 	testRegisterDatabaseError(t, mockRouter, mockDatabase)
+	testRegisterDryRun(t, mockRouter, mockDatabase)
+	testRegisterDryRunSymbolTaken(t, mockRouter, mockDatabase)
 	testRegisterSuccess(t, mockRouter, mockDatabase)
 }
 
-func testNullTeam(t *testing.T, mockRouter *gin.Engine, endpoint, expectedResponse string) {
-	testNull(t, mockRouter, endpoint, expectedResponse)
+func testRegisterValidationError(t *testing.T, mockRouter *gin.Engine) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request. Every field that is missing or invalid is reported, not just the first.
+	req := httptest.NewRequest("POST", registerEndpoint, strings.NewReader("{\"name\": \" \", \"symbol\": \"b!tc\", "+
+		"\"team\": [\"Satoshi Nakomoto\", \"\"], \"icoAmount\": -1, \"fundingStatus\": \"crowdsale\", "+
+		"\"website\": \"bitcoin.org\"}"))
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusBadRequest, recorder.Code)
+	assertProblem(t, invalidFieldCode, "", "description cannot be null; blockReward cannot be null; "+
+		"foundedDate cannot be null; coinType cannot be null; name cannot be empty; "+
+		"symbol must be 1 to 10 letters or digits; team member names cannot be empty; ICO amount cannot be negative; "+
+		"fundingStatus must be one of ico, no-ico, post-ico, pre-ico; website must be an http or https URL", recorder)
+	assertViolations(t, []*models.Violation{
+		{Field: "description", Code: models.RequiredViolation, Detail: "description cannot be null"},
+		{Field: "blockReward", Code: models.RequiredViolation, Detail: "blockReward cannot be null"},
+		{Field: "foundedDate", Code: models.RequiredViolation, Detail: "foundedDate cannot be null"},
+		{Field: "coinType", Code: models.RequiredViolation, Detail: "coinType cannot be null"},
+		{Field: "name", Code: models.RequiredViolation, Detail: "name cannot be empty"},
+		{Field: "symbol", Code: models.FormatViolation, Detail: "symbol must be 1 to 10 letters or digits"},
+		{Field: "team[1]", Code: models.RequiredViolation, Detail: "team member names cannot be empty"},
+		{Field: "icoAmount", Code: models.RangeViolation, Detail: "ICO amount cannot be negative"},
+		{Field: "fundingStatus", Code: models.EnumViolation,
+			Detail: "fundingStatus must be one of ico, no-ico, post-ico, pre-ico"},
+		{Field: "website", Code: models.FormatViolation, Detail: "website must be an http or https URL"},
+	}, recorder)
 }
 
 func testRegisterUserError(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
 	// Create the response recorder.
 	recorder := httptest.NewRecorder()
 
-	// Create a crypto asset with null data and marshal it into bytes to be sent to our mock router.
-	date := "2017-12-25"
-	invalidCryptoAsset := &models.CryptoAsset{Team: []string{}, FoundedDate: &date}
-	buffer, err := json.Marshal(invalidCryptoAsset)
+	// Create a valid crypto asset with a symbol that is already taken and marshal it into bytes to be sent to our mock
+	// router.
+	duplicateCryptoAsset := newBitcoin()
+	buffer, err := json.Marshal(duplicateCryptoAsset)
 
-	// Fail the test if there is an error marshalling the valid crypto asset.
+	// Fail the test if there is an error marshalling the crypto asset.
 	if err != nil {
 		t.Fatal("unexpected error marshaling to JSON")
 	}
 	// Prepare the HTTP request and mock database call.
 	req := httptest.NewRequest("POST", registerEndpoint, bytes.NewReader(buffer))
-	mockDatabase.On("Insert", invalidCryptoAsset).Return("", database.NewNullConstraintError("name"))
+	mockDatabase.On("Insert", duplicateCryptoAsset, "anonymous").Return("", database.NewUniqueConstraintError("btc"))
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusBadRequest, recorder.Code)
+	assertProblem(t, duplicateSymbolCode, "symbol", "symbol btc already exists", recorder)
+}
+
+func testRegisterDryRun(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call. A dry run is validated, normalized and inserted, but the insert
+	// is rolled back, so the crypto asset is returned as it would be stored but without an id.
+	req := httptest.NewRequest("POST", registerEndpoint+"?dryRun=true", strings.NewReader("{\"name\": \" Litecoin \", "+
+		"\"symbol\": \"LTC\", \"description\": \"Silver to bitcoin's gold\", \"team\": [\"Charlie Lee\"], "+
+		"\"icoAmount\": 0, \"blockReward\": 12.5, \"fundingStatus\": \"no-ico\", \"foundedDate\": \"2011-10-07\", "+
+		"\"coinType\": \"currency\", \"website\": \"https://litecoin.org\"}"))
+	litecoin := newLitecoin()
+	mockDatabase.On("DryRunInsert", mock.MatchedBy(func(cryptoAsset *models.CryptoAsset) bool {
+		return *cryptoAsset.Symbol == "ltc"
+	}), "anonymous").Return(litecoin, nil).Once()
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+	mockDatabase.AssertNotCalled(t, "Insert", mock.MatchedBy(func(cryptoAsset *models.CryptoAsset) bool {
+		return *cryptoAsset.Symbol == "ltc"
+	}), mock.Anything)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+	assertResponseBody(t, "{\"id\":null,\"name\":\"Litecoin\",\"symbol\":\"LTC\","+
+		"\"description\":\"Silver to bitcoin's gold\",\"team\":[\"Charlie Lee\"],\"icoAmount\":0,\"blockReward\":12.5,"+
+		"\"fundingStatus\":\"NO-ICO\",\"foundedDate\":\"2011-10-07\",\"coinType\":\"Currency\","+
+		"\"website\":\"https://litecoin.org\"}", recorder.Body.String())
+}
+
+func testRegisterDryRunSymbolTaken(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call. A dry run fails the same way as the real registration when the
+	// symbol is taken.
+	req := httptest.NewRequest("POST", registerEndpoint+"?dryRun=true", strings.NewReader("{\"name\": \"Bitcoin\", "+
+		"\"symbol\": \"BTC\", \"description\": \"The original cryptocurrency\", \"team\": [], \"icoAmount\": 0, "+
+		"\"blockReward\": 12.5, \"fundingStatus\": \"no-ico\", \"foundedDate\": \"2009-01-03\", "+
+		"\"coinType\": \"currency\", \"website\": \"https://bitcoin.org\"}"))
+	mockDatabase.On("DryRunInsert", mock.MatchedBy(func(cryptoAsset *models.CryptoAsset) bool {
+		return *cryptoAsset.Symbol == "btc"
+	}), "anonymous").Return(nil, database.NewUniqueConstraintError("btc")).Once()
 
 	// Make the request.
 	mockRouter.ServeHTTP(recorder, req)
@@ -62,7 +159,7 @@ func testRegisterUserError(t *testing.T, mockRouter *gin.Engine, mockDatabase *d
 
 	// Assert the expected HTTP response code and body.
 	assertResponseCode(t, http.StatusBadRequest, recorder.Code)
-	assertResponseBody(t, "{\"error\":\"name cannot be null\"}", recorder.Body.String())
+	assertProblem(t, duplicateSymbolCode, "symbol", "symbol btc already exists", recorder)
 }
 
 func testRegisterDatabaseError(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
@@ -99,7 +196,7 @@ func testRegisterDatabaseError(t *testing.T, mockRouter *gin.Engine, mockDatabas
 	}
 	// Prepare the HTTP request and mock database call.
 	req := httptest.NewRequest("POST", registerEndpoint, bytes.NewReader(buffer))
-	mockDatabase.On("Insert", validCryptoAsset).Return("", errors.New("mock database error"))
+	mockDatabase.On("Insert", validCryptoAsset, "anonymous").Return("", errors.New("mock database error"))
 
 	// Make the request.
 	mockRouter.ServeHTTP(recorder, req)
@@ -109,7 +206,7 @@ func testRegisterDatabaseError(t *testing.T, mockRouter *gin.Engine, mockDatabas
 
 	// Assert the expected HTTP response code and body.
 	assertResponseCode(t, http.StatusInternalServerError, recorder.Code)
-	assertResponseBody(t, "{\"error\":\"internal server error\"}", recorder.Body.String())
+	assertProblem(t, internalErrorCode, "", "internal server error", recorder)
 }
 
 func testRegisterSuccess(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
@@ -148,7 +245,7 @@ func testRegisterSuccess(t *testing.T, mockRouter *gin.Engine, mockDatabase *dat
 	// Prepare the HTTP request and mock database call.
 	reader := bytes.NewReader(buffer)
 	req := httptest.NewRequest("POST", registerEndpoint, reader)
-	mockDatabase.On("Insert", validCryptoAsset).Return("1", nil)
+	mockDatabase.On("Insert", validCryptoAsset, "anonymous").Return("1", nil)
 
 	// Make the request.
 	mockRouter.ServeHTTP(recorder, req)
@@ -173,6 +270,251 @@ func TestSearchEndpoint(t *testing.T) {
 	// Run tests.
 	testSearchDatabaseError(t, mockRouter, mockDatabase)
 	testSearchSuccess(t, mockRouter, mockDatabase)
+	testSearchInvalidLimit(t, mockRouter)
+	testSearchInvalidRange(t, mockRouter)
+	testSearchRanges(t, mockRouter, mockDatabase)
+	testSearchTeamMembers(t, mockRouter, mockDatabase)
+	testSearchUserError(t, mockRouter, mockDatabase)
+	testSearchPaginated(t, mockRouter, mockDatabase)
+	testSearchProjected(t, mockRouter, mockDatabase)
+	testSearchFullText(t, mockRouter, mockDatabase)
+	testSearchIncludeDeleted(t, mockRouter, mockDatabase)
+	testSearchInvalidAsOf(t, mockRouter)
+	testSearchAsOf(t, mockRouter, mockDatabase)
+}
+
+func testSearchInvalidAsOf(t *testing.T, mockRouter *gin.Engine) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request.
+	req := httptest.NewRequest("GET", "/search?asOf=yesterday", nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusBadRequest, recorder.Code)
+	assertProblem(t, invalidParameterCode, "asOf", "invalid asOf: yesterday", recorder)
+}
+
+func testSearchAsOf(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// A timestamp is converted to UTC and a date means the end of that day.
+	asOfTests := []struct {
+		asOf     string
+		expected string
+	}{
+		{"2018-06-01T14:00:00%2B02:00", "2018-06-01T12:00:00Z"},
+		{"2018-06-02", "2018-06-02T23:59:59Z"},
+	}
+	for _, asOfTest := range asOfTests {
+		// Create the response recorder.
+		recorder := httptest.NewRecorder()
+
+		// Prepare the HTTP request and mock database call.
+		req := httptest.NewRequest("GET", "/search?symbol=btc&asOf="+asOfTest.asOf, nil)
+		mockDatabase.On("Select", &database.Query{Symbols: []string{"btc"}, AsOf: asOfTest.expected}).Return(
+			[]*models.CryptoAsset{newBitcoin()}, "", nil)
+
+		// Make the request.
+		mockRouter.ServeHTTP(recorder, req)
+
+		// Assert the correct mock calls were made.
+		mockDatabase.AssertExpectations(t)
+
+		// Assert the expected HTTP response code and body.
+		assertResponseCode(t, http.StatusOK, recorder.Code)
+		assertResponseBody(t, "["+formattedBitcoin+"]", recorder.Body.String())
+	}
+}
+
+func testSearchIncludeDeleted(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call.
+	req := httptest.NewRequest("GET", "/search?symbol=xmr&includeDeleted=true", nil)
+	mockDatabase.On("Select", &database.Query{Symbols: []string{"xmr"}, IncludeDeleted: true}).Return(
+		[]*models.CryptoAsset{}, "", nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+	assertResponseBody(t, "[]", recorder.Body.String())
+}
+
+func testSearchFullText(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Create a full-text search result.
+	relevance := 1.5
+	snippet := "The original <b>cryptocurrency</b>"
+	bitcoin := newBitcoin()
+	bitcoin.Relevance = &relevance
+	bitcoin.Snippet = &snippet
+
+	// Prepare the HTTP request and mock database call.
+	req := httptest.NewRequest("GET", "/search?q=%20cryptocurrency%20&fields=snippet", nil)
+	mockDatabase.On("Select", &database.Query{Text: "cryptocurrency", Fields: []string{"snippet"}}).Return(
+		[]*models.CryptoAsset{bitcoin}, "", nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+	assertResponseBody(t, "[{\"id\":\"1\",\"snippet\":\"The original \\u003cb\\u003ecryptocurrency\\u003c/b\\u003e\"}]",
+		recorder.Body.String())
+}
+
+func testSearchInvalidLimit(t *testing.T, mockRouter *gin.Engine) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request.
+	req := httptest.NewRequest("GET", "/search?limit=a", nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusBadRequest, recorder.Code)
+	assertProblem(t, invalidParameterCode, "limit", "invalid limit: a", recorder)
+}
+
+func testSearchInvalidRange(t *testing.T, mockRouter *gin.Engine) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request.
+	req := httptest.NewRequest("GET", "/search?minIcoAmount=lots", nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusBadRequest, recorder.Code)
+	assertProblem(t, invalidParameterCode, "minIcoAmount", "invalid minIcoAmount: lots", recorder)
+}
+
+func testSearchRanges(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call. Note that range filters take only the first value.
+	minICOAmount := 1000000.0
+	maxBlockReward := 12.5
+	hasBlockReward := true
+	req := httptest.NewRequest("GET", "/search?minIcoAmount=1e6,2e6&maxBlockReward=12.5&hasBlockReward=true", nil)
+	mockDatabase.On("Select", &database.Query{
+		MinICOAmount:   &minICOAmount,
+		MaxBlockReward: &maxBlockReward,
+		HasBlockReward: &hasBlockReward,
+	}).Return([]*models.CryptoAsset{newBitcoin()}, "", nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+	assertResponseBody(t, "["+formattedBitcoin+"]", recorder.Body.String())
+}
+
+func testSearchTeamMembers(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call. Note that team member names are only trimmed, since they are
+	// compared ignoring case by the database.
+	req := httptest.NewRequest("GET", "/search?teamMember=Satoshi%20Nakomoto,%20Hal%20Finney&teamMember=Nick", nil)
+	mockDatabase.On("Select", &database.Query{
+		TeamMembers: []string{"Satoshi Nakomoto", "Hal Finney", "Nick"},
+	}).Return([]*models.CryptoAsset{newBitcoin()}, "", nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+	assertResponseBody(t, "["+formattedBitcoin+"]", recorder.Body.String())
+}
+
+func testSearchUserError(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call.
+	req := httptest.NewRequest("GET", "/search?sort=team", nil)
+	mockDatabase.On("Select", &database.Query{Sort: []database.SortKey{{Field: "team"}}}).Return(nil, "",
+		database.NewUnknownSortFieldError("team"))
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusBadRequest, recorder.Code)
+	assertProblem(t, invalidParameterCode, "sort", "cannot sort by team", recorder)
+}
+
+func testSearchProjected(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call.
+	req := httptest.NewRequest("GET", "/search?fields=symbol,team&name=bitcoin", nil)
+	mockDatabase.On("Select", &database.Query{Names: []string{"bitcoin"}, Fields: []string{"symbol", "team"}}).Return(
+		[]*models.CryptoAsset{newBitcoin()}, "", nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+	assertResponseBody(t, "[{\"id\":\"1\",\"symbol\":\"BTC\",\"team\":[\"Satoshi Nakomoto\"]}]", recorder.Body.String())
+}
+
+func testSearchPaginated(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call.
+	req := httptest.NewRequest("GET", "/assets?symbol=btc&limit=1&sort=-icoAmount,name", nil)
+	mockDatabase.On("Select", &database.Query{
+		Symbols: []string{"btc"},
+		Sort:    []database.SortKey{{Field: "icoAmount", Descending: true}, {Field: "name"}},
+		Limit:   1,
+	}).Return([]*models.CryptoAsset{newBitcoin()}, "next", nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+	assertResponseBody(t, "{\"results\":["+formattedBitcoin+"],\"nextCursor\":\"next\"}", recorder.Body.String())
 }
 
 func testSearchDatabaseError(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
@@ -181,8 +523,7 @@ func testSearchDatabaseError(t *testing.T, mockRouter *gin.Engine, mockDatabase
 
 	// Prepare the HTTP request and mock database call.
 	req := httptest.NewRequest("GET", searchEndpoint, nil)
-	mockDatabase.On("Select", []string(nil), []string(nil), []string(nil), []string(nil), "", "").Return(nil,
-		errors.New("mock database error"))
+	mockDatabase.On("Select", &database.Query{}).Return(nil, "", errors.New("mock database error"))
 
 	// Make the request.
 	mockRouter.ServeHTTP(recorder, req)
@@ -192,7 +533,7 @@ func testSearchDatabaseError(t *testing.T, mockRouter *gin.Engine, mockDatabase
 
 	// Assert the expected HTTP response code and body.
 	assertResponseCode(t, http.StatusInternalServerError, recorder.Code)
-	assertResponseBody(t, "{\"error\":\"internal server error\"}", recorder.Body.String())
+	assertProblem(t, internalErrorCode, "", "internal server error", recorder)
 }
 
 func testSearchSuccess(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
@@ -251,11 +592,16 @@ func testSearchSuccess(t *testing.T, mockRouter *gin.Engine, mockDatabase *datab
 
 	// Prepare the HTTP request and mock database call. Note that the mock call expects the query string to be parsed such
 	// that query parameters can be split by commas or ampersands but single value query parameters (startDate, endDate)
-	// take only the first value.
+	// take only the first value. Aliases are searched for as the values they stand for.
 	req := httptest.NewRequest("GET", "/search?fundingStatus=post-ico&fundingStatus=active-ico"+
-		"&coinType=governance,storage&startDate=2017-05-17,2017-05-18&endDate=2017-05-19&endDate=2017-05-18", nil)
-	mockDatabase.On("Select", []string(nil), []string(nil), []string{"post-ico", "active-ico"},
-		[]string{"governance", "storage"}, "2017-05-17", "2017-05-19").Return([]*models.CryptoAsset{aragon, storj}, nil)
+		"&coinType=governance,storage,Coin&startDate=2017-05-17,2017-05-18&endDate=2017-05-19&endDate=2017-05-18", nil)
+	expectVocabularies(mockDatabase)
+	mockDatabase.On("Select", &database.Query{
+		FundingStatuses: []string{"post-ico", "active-ico"},
+		CoinTypes:       []string{"governance", "storage", "currency"},
+		StartDate:       "2017-05-17",
+		EndDate:         "2017-05-19",
+	}).Return([]*models.CryptoAsset{aragon, storj}, "", nil)
 
 	// Make the request.
 	mockRouter.ServeHTTP(recorder, req)
@@ -283,18 +629,47 @@ func TestUpdateEndpoint(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockDatabase := &database.Mock{}
 	mockRouter := setUpMockRouter(mockDatabase)
+	expectVocabularies(mockDatabase)
+	expectAudit(mockDatabase)
+	mockDatabase.On("Get", 1).Return(newBitcoin(), nil)
 
 	// Run tests.
-	testInvalidJSON(t, mockRouter, updateEndpoint, "false")
-	testNullID(t, mockRouter, updateEndpoint, "false")
-	testNormalizationError(t, mockRouter, updateEndpoint, "false")
+	testInvalidJSON(t, mockRouter, updateEndpoint)
+	testNullID(t, mockRouter, updateEndpoint)
+	testNormalizationError(t, mockRouter, updateEndpoint)
 	testUpdateUserUpdate(t, mockRouter, mockDatabase)
 	testUpdateDatabaseError(t, mockRouter, mockDatabase)
 	testUpdateSuccess(t, mockRouter, mockDatabase)
+	testUpdateVersionMismatch(t, mockRouter, mockDatabase)
+}
+
+func testUpdateVersionMismatch(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call. The crypto asset has changed since the user read it.
+	req := httptest.NewRequest("POST", updateEndpoint, strings.NewReader("{\"id\": \"6\", \"coinType\": \"Token\"}"))
+	req.Header.Set("If-Match", "\"1\"")
+	id := "6"
+	coinType := "token"
+	version := 1
+	mockDatabase.On("Get", 6).Return(newBitcoin(), nil).Once()
+	mockDatabase.On("Update", 6, &models.CryptoAsset{ID: &id, CoinType: &coinType, Version: &version},
+		"anonymous").Return(database.NewVersionMismatchError(6, 1, 2))
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusPreconditionFailed, recorder.Code)
+	assertProblem(t, versionMismatchCode, "", "crypto asset with id 6 is at version 2, not 1", recorder)
 }
 
-func testNullID(t *testing.T, mockRouter *gin.Engine, endpoint, expectedResponse string) {
-	testNull(t, mockRouter, endpoint, expectedResponse)
+func testNullID(t *testing.T, mockRouter *gin.Engine, endpoint string) {
+	testNull(t, mockRouter, endpoint, "id")
 }
 
 func testUpdateUserUpdate(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
@@ -315,7 +690,7 @@ func testUpdateUserUpdate(t *testing.T, mockRouter *gin.Engine, mockDatabase *da
 	// Prepare the HTTP request and mock database call.
 	reader := bytes.NewReader(buffer)
 	req := httptest.NewRequest("POST", "/update", reader)
-	mockDatabase.On("Update", 1, emptyCryptoAssetUpdate).Return(database.NewEmptyUpdateError())
+	mockDatabase.On("Update", 1, emptyCryptoAssetUpdate, "anonymous").Return(database.NewEmptyUpdateError())
 
 	// Make the request.
 	mockRouter.ServeHTTP(recorder, req)
@@ -325,7 +700,7 @@ func testUpdateUserUpdate(t *testing.T, mockRouter *gin.Engine, mockDatabase *da
 
 	// Assert the expected HTTP response code and body.
 	assertResponseCode(t, http.StatusBadRequest, recorder.Code)
-	assertResponseBody(t, "false", recorder.Body.String())
+	assertProblem(t, emptyUpdateCode, "", "nothing to update", recorder)
 }
 
 func testUpdateDatabaseError(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
@@ -347,7 +722,8 @@ func testUpdateDatabaseError(t *testing.T, mockRouter *gin.Engine, mockDatabase
 	}
 	// Prepare the HTTP request and mock database call.
 	req := httptest.NewRequest("POST", updateEndpoint, bytes.NewReader(buffer))
-	mockDatabase.On("Update", 3, validCryptoAssetUpdate).Return(errors.New("mock database error"))
+	mockDatabase.On("Get", 3).Return(newBitcoin(), nil).Once()
+	mockDatabase.On("Update", 3, validCryptoAssetUpdate, "anonymous").Return(errors.New("mock database error"))
 
 	// Make the request.
 	mockRouter.ServeHTTP(recorder, req)
@@ -357,7 +733,7 @@ func testUpdateDatabaseError(t *testing.T, mockRouter *gin.Engine, mockDatabase
 
 	// Assert the expected HTTP response code and body.
 	assertResponseCode(t, http.StatusInternalServerError, recorder.Code)
-	assertResponseBody(t, "false", recorder.Body.String())
+	assertProblem(t, internalErrorCode, "", "internal server error", recorder)
 }
 
 func testUpdateSuccess(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
@@ -380,7 +756,8 @@ func testUpdateSuccess(t *testing.T, mockRouter *gin.Engine, mockDatabase *datab
 	// Prepare the HTTP request and mock database call.
 	reader := bytes.NewReader(buffer)
 	req := httptest.NewRequest("POST", "/update", reader)
-	mockDatabase.On("Update", 1, validCryptoAssetUpdate).Return(nil)
+	mockDatabase.On("Update", 1, validCryptoAssetUpdate, "anonymous").Return(nil)
+	mockDatabase.On("Get", 1).Return(newBitcoin(), nil)
 
 	// Make the request.
 	mockRouter.ServeHTTP(recorder, req)
@@ -388,51 +765,1063 @@ func testUpdateSuccess(t *testing.T, mockRouter *gin.Engine, mockDatabase *datab
 	// Assert the correct mock calls were made.
 	mockDatabase.AssertExpectations(t)
 
-	// Assert the expected HTTP response code and body.
+	// Assert the expected HTTP response code and body. The updated crypto asset is returned.
 	assertResponseCode(t, http.StatusOK, recorder.Code)
-	assertResponseBody(t, "true", recorder.Body.String())
+	assertResponseBody(t, formattedBitcoin, recorder.Body.String())
 }
 
-func assertResponseBody(t *testing.T, expected, actual string) {
-	if expected != actual {
-		t.Fatalf("unexpected response body\n\nexpected: %s\nactual: %s", expected, actual)
-	}
+func TestGetAssetEndpoint(t *testing.T) {
+	// Hide logs.
+	log.SetLevel(log.FatalLevel)
+
+	// Set up router for testing.
+	gin.SetMode(gin.TestMode)
+	mockDatabase := &database.Mock{}
+	mockRouter := setUpMockRouter(mockDatabase)
+
+	// Run tests.
+	testInvalidPathID(t, mockRouter, "GET")
+	testGetAssetUnknownID(t, mockRouter, mockDatabase)
+	testGetAssetSuccess(t, mockRouter, mockDatabase)
+	testGetAssetDeleted(t, mockRouter, mockDatabase)
+	testGetAssetETag(t, mockRouter, mockDatabase)
 }
 
-func assertResponseCode(t *testing.T, expected, actual int) {
-	if expected != actual {
-		t.Fatalf("unexpected response code\n\nexpected: %d\nactual: %d", expected, actual)
+func testGetAssetETag(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create a crypto asset at version 4.
+	version := 4
+	bitcoin := newBitcoin()
+	bitcoin.Version = &version
+	mockDatabase.On("Get", 3).Return(bitcoin, nil)
+
+	// The version is returned as the ETag.
+	recorder := httptest.NewRecorder()
+	mockRouter.ServeHTTP(recorder, httptest.NewRequest("GET", "/assets/3", nil))
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+	assertResponseBody(t, formattedBitcoin, recorder.Body.String())
+	if etag := recorder.Header().Get("ETag"); etag != "\"4\"" {
+		t.Fatalf("unexpected ETag header\n\nexpected: \"4\"\nactual: %s", etag)
 	}
+
+	// Nothing is returned if the user already has the current version.
+	recorder = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/assets/3", nil)
+	req.Header.Set("If-None-Match", "\"3\", W/\"4\"")
+	mockRouter.ServeHTTP(recorder, req)
+	assertResponseCode(t, http.StatusNotModified, recorder.Code)
+	assertResponseBody(t, "", recorder.Body.String())
+
+	// The crypto asset is returned if the user has an older version.
+	recorder = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/assets/3", nil)
+	req.Header.Set("If-None-Match", "\"3\"")
+	mockRouter.ServeHTTP(recorder, req)
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+	assertResponseBody(t, formattedBitcoin, recorder.Body.String())
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
 }
 
-func setUpMockRouter(mock *database.Mock) *gin.Engine {
-	server := NewServer(mock)
-	return server.initializeRouter()
+func testGetAssetDeleted(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create a deleted crypto asset.
+	deletedAt := "2018-06-01T12:00:00Z"
+	deletedBy := "alice"
+	bitcoin := newBitcoin()
+	bitcoin.DeletedAt = &deletedAt
+	bitcoin.DeletedBy = &deletedBy
+	mockDatabase.On("Get", 2).Return(bitcoin, nil)
+
+	// A deleted crypto asset is not found by default.
+	recorder := httptest.NewRecorder()
+	mockRouter.ServeHTTP(recorder, httptest.NewRequest("GET", "/assets/2", nil))
+	assertResponseCode(t, http.StatusNotFound, recorder.Code)
+	assertProblem(t, cryptoAssetNotFoundCode, "", "crypto asset with id 2 not found", recorder)
+
+	// A deleted crypto asset is returned with its tombstone when asked for.
+	recorder = httptest.NewRecorder()
+	mockRouter.ServeHTTP(recorder, httptest.NewRequest("GET", "/assets/2?includeDeleted=true", nil))
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+	assertResponseBody(t, strings.TrimSuffix(formattedBitcoin, "}")+
+		",\"deletedAt\":\"2018-06-01T12:00:00Z\",\"deletedBy\":\"alice\"}", recorder.Body.String())
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+}
+
+func testGetAssetUnknownID(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call.
+	req := httptest.NewRequest("GET", "/assets/7", nil)
+	mockDatabase.On("Get", 7).Return(nil, database.NewUnknownIDError(7))
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusNotFound, recorder.Code)
+	assertProblem(t, cryptoAssetNotFoundCode, "", "crypto asset with id 7 not found", recorder)
+}
+
+func testGetAssetSuccess(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call.
+	req := httptest.NewRequest("GET", "/assets/1", nil)
+	mockDatabase.On("Get", 1).Return(newBitcoin(), nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+	assertResponseBody(t, formattedBitcoin, recorder.Body.String())
+}
+
+func TestDeleteAssetEndpoint(t *testing.T) {
+	// Hide logs.
+	log.SetLevel(log.FatalLevel)
+
+	// Set up router for testing.
+	gin.SetMode(gin.TestMode)
+	mockDatabase := &database.Mock{}
+	mockRouter := setUpMockRouter(mockDatabase)
+	expectAudit(mockDatabase)
+
+	// Run tests.
+	testInvalidPathID(t, mockRouter, "DELETE")
+	testDeleteAssetUnknownID(t, mockRouter, mockDatabase)
+	testDeleteAssetSuccess(t, mockRouter, mockDatabase)
+	testDeleteAssetVersionMismatch(t, mockRouter, mockDatabase)
+}
+
+func testDeleteAssetVersionMismatch(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call. The crypto asset has changed since the user read it.
+	req := httptest.NewRequest("DELETE", "/assets/2", nil)
+	req.Header.Set("If-Match", "\"2\"")
+	version := 2
+	mockDatabase.On("Delete", 2, &version, "anonymous").Return(database.NewVersionMismatchError(2, 2, 3))
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusPreconditionFailed, recorder.Code)
+	assertProblem(t, versionMismatchCode, "", "crypto asset with id 2 is at version 3, not 2", recorder)
+}
+
+func testDeleteAssetUnknownID(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call.
+	req := httptest.NewRequest("DELETE", "/assets/7", nil)
+	mockDatabase.On("Delete", 7, (*int)(nil), "anonymous").Return(database.NewUnknownIDError(7))
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusNotFound, recorder.Code)
+	assertProblem(t, cryptoAssetNotFoundCode, "", "crypto asset with id 7 not found", recorder)
+}
+
+func testDeleteAssetSuccess(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call. The actor is recorded as having deleted the crypto asset.
+	req := httptest.NewRequest("DELETE", "/assets/1", nil)
+	req.Header.Set("X-Actor", "alice")
+	mockDatabase.On("Delete", 1, (*int)(nil), "alice").Return(nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusNoContent, recorder.Code)
+	assertResponseBody(t, "", recorder.Body.String())
+}
+
+func TestRestoreAssetEndpoint(t *testing.T) {
+	// Hide logs.
+	log.SetLevel(log.FatalLevel)
+
+	// Set up router for testing.
+	gin.SetMode(gin.TestMode)
+	mockDatabase := &database.Mock{}
+	mockRouter := setUpMockRouter(mockDatabase)
+	expectAudit(mockDatabase)
+
+	// Run tests.
+	testInvalidPathIDEndpoint(t, mockRouter, "POST", "/assets/a/restore")
+	testRestoreAssetUniqueConstraint(t, mockRouter, mockDatabase)
+	testRestoreAssetSuccess(t, mockRouter, mockDatabase)
+}
+
+func testRestoreAssetUniqueConstraint(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call. The symbol has been registered again since the crypto asset was
+	// deleted.
+	req := httptest.NewRequest("POST", "/assets/7/restore", nil)
+	mockDatabase.On("Restore", 7, (*int)(nil), "anonymous").Return(database.NewUniqueConstraintError("btc"))
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusBadRequest, recorder.Code)
+	assertProblem(t, duplicateSymbolCode, "symbol", "symbol btc already exists", recorder)
+}
+
+func testRestoreAssetSuccess(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database calls.
+	req := httptest.NewRequest("POST", "/assets/1/restore", nil)
+	mockDatabase.On("Restore", 1, (*int)(nil), "anonymous").Return(nil)
+	mockDatabase.On("Get", 1).Return(newBitcoin(), nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+	assertResponseBody(t, formattedBitcoin, recorder.Body.String())
+}
+
+func TestHistoryEndpoint(t *testing.T) {
+	// Hide logs.
+	log.SetLevel(log.FatalLevel)
+
+	// Set up router for testing.
+	gin.SetMode(gin.TestMode)
+	mockDatabase := &database.Mock{}
+	mockRouter := setUpMockRouter(mockDatabase)
+
+	// Run tests.
+	testInvalidPathIDEndpoint(t, mockRouter, "GET", "/assets/a/history")
+	testHistoryUnknownID(t, mockRouter, mockDatabase)
+	testHistorySuccess(t, mockRouter, mockDatabase)
+}
+
+func testHistoryUnknownID(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call.
+	req := httptest.NewRequest("GET", "/assets/7/history", nil)
+	mockDatabase.On("History", 7).Return(nil, database.NewUnknownIDError(7))
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusNotFound, recorder.Code)
+	assertProblem(t, cryptoAssetNotFoundCode, "", "crypto asset with id 7 not found", recorder)
+}
+
+func testHistorySuccess(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call.
+	req := httptest.NewRequest("GET", "/assets/1/history", nil)
+	mockDatabase.On("History", 1).Return([]*models.Revision{{
+		Revision:  1,
+		CreatedAt: "2018-06-01T12:00:00Z",
+		Actor:     "alice",
+		Action:    models.CreateAction,
+		Snapshot:  newBitcoin(),
+		Diff:      map[string]*models.Change{"blockReward": {From: nil, To: 12.5}},
+	}}, nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body. The snapshot is formatted but the diff is not.
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+	assertResponseBody(t, "[{\"revision\":1,\"createdAt\":\"2018-06-01T12:00:00Z\",\"actor\":\"alice\","+
+		"\"action\":\"create\",\"snapshot\":"+formattedBitcoin+",\"diff\":{\"blockReward\":{\"from\":null,"+
+		"\"to\":12.5}}}]", recorder.Body.String())
+}
+
+func TestRevertAssetEndpoint(t *testing.T) {
+	// Hide logs.
+	log.SetLevel(log.FatalLevel)
+
+	// Set up router for testing.
+	gin.SetMode(gin.TestMode)
+	mockDatabase := &database.Mock{}
+	mockRouter := setUpMockRouter(mockDatabase)
+	expectAudit(mockDatabase)
+
+	// Run tests.
+	testInvalidPathIDEndpoint(t, mockRouter, "POST", "/assets/a/revert?revision=1")
+	testRevertAssetInvalidRevision(t, mockRouter)
+	testRevertAssetUnknownRevision(t, mockRouter, mockDatabase)
+	testRevertAssetUniqueConstraint(t, mockRouter, mockDatabase)
+	testRevertAssetSuccess(t, mockRouter, mockDatabase)
+	testRevertAssetLegacyValues(t, mockRouter, mockDatabase)
+}
+
+func testRevertAssetInvalidRevision(t *testing.T, mockRouter *gin.Engine) {
+	invalidRevisionTests := []struct {
+		target   string
+		expected string
+	}{
+		{"/assets/1/revert", "invalid revision: "},
+		{"/assets/1/revert?revision=0", "invalid revision: 0"},
+	}
+	for _, invalidRevisionTest := range invalidRevisionTests {
+		// Create the response recorder.
+		recorder := httptest.NewRecorder()
+
+		// Make the request.
+		mockRouter.ServeHTTP(recorder, httptest.NewRequest("POST", invalidRevisionTest.target, nil))
+
+		// Assert the expected HTTP response code and body.
+		assertResponseCode(t, http.StatusBadRequest, recorder.Code)
+		assertProblem(t, invalidParameterCode, "revision", invalidRevisionTest.expected, recorder)
+	}
+}
+
+func testRevertAssetUnknownRevision(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call.
+	req := httptest.NewRequest("POST", "/assets/1/revert?revision=9", nil)
+	mockDatabase.On("Revision", 1, 9).Return(nil, database.NewUnknownRevisionError(1, 9))
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusNotFound, recorder.Code)
+	assertProblem(t, revisionNotFoundCode, "revision", "revision 9 of crypto asset with id 1 not found", recorder)
+}
+
+func testRevertAssetUniqueConstraint(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database calls. The symbol of the revision has since been taken by another
+	// crypto asset.
+	bitcoin := newBitcoin()
+	id := "2"
+	bitcoin.ID = &id
+	req := httptest.NewRequest("POST", "/assets/2/revert?revision=1", nil)
+	expectVocabularies(mockDatabase)
+	mockDatabase.On("Revision", 2, 1).Return(&models.Revision{Revision: 1, Snapshot: bitcoin}, nil)
+	mockDatabase.On("Revert", 2, bitcoin, "anonymous").Return(database.NewUniqueConstraintError("btc"))
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusBadRequest, recorder.Code)
+	assertProblem(t, duplicateSymbolCode, "symbol", "symbol btc already exists", recorder)
+}
+
+func testRevertAssetSuccess(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database calls.
+	bitcoin := newBitcoin()
+	req := httptest.NewRequest("POST", "/assets/1/revert?revision=3", nil)
+	req.Header.Set("X-Actor", "alice")
+	mockDatabase.On("Revision", 1, 3).Return(&models.Revision{Revision: 3, Snapshot: bitcoin}, nil)
+	mockDatabase.On("Revert", 1, bitcoin, "alice").Return(nil)
+	mockDatabase.On("Get", 1).Return(newBitcoin(), nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+	assertResponseBody(t, formattedBitcoin, recorder.Body.String())
+}
+
+func testRevertAssetLegacyValues(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database calls. The revision was stored before symbols, founded dates and
+	// websites had to follow their current format, which does not stop it being reverted to.
+	legacyBitcoin := newLegacyBitcoin("4")
+	req := httptest.NewRequest("POST", "/assets/4/revert?revision=1", nil)
+	mockDatabase.On("Revision", 4, 1).Return(&models.Revision{Revision: 1, Snapshot: legacyBitcoin}, nil)
+	mockDatabase.On("Revert", 4, legacyBitcoin, "anonymous").Return(nil)
+	mockDatabase.On("Get", 4).Return(newLegacyBitcoin("4"), nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code.
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+}
+
+func TestPatchAssetEndpoint(t *testing.T) {
+	// Hide logs.
+	log.SetLevel(log.FatalLevel)
+
+	// Set up router for testing.
+	gin.SetMode(gin.TestMode)
+	mockDatabase := &database.Mock{}
+	mockRouter := setUpMockRouter(mockDatabase)
+	expectVocabularies(mockDatabase)
+	expectAudit(mockDatabase)
+	mockDatabase.On("Get", 1).Return(newBitcoin(), nil)
+
+	// Run tests.
+	testInvalidJSONMethod(t, mockRouter, "PATCH", "/assets/1")
+	testIDMismatch(t, mockRouter, "PATCH")
+	testPatchAssetInvalidIfMatch(t, mockRouter)
+	testPatchAssetSuccess(t, mockRouter, mockDatabase)
+	testPatchAssetIfMatch(t, mockRouter, mockDatabase)
+	testPatchAssetDryRun(t, mockRouter, mockDatabase)
+	testPatchAssetLegacyValues(t, mockRouter, mockDatabase)
+}
+
+func testPatchAssetLegacyValues(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database calls. The crypto asset was stored before symbols, founded dates and
+	// websites had to follow their current format, so its values are let through as long as they are left as they are.
+	req := httptest.NewRequest("PATCH", "/assets/9", strings.NewReader(
+		"{\"symbol\": \"B.TC\", \"foundedDate\": \"2008-10-31\", \"website\": \"bitcoin.org\", \"blockReward\": 6.25}"))
+	mockDatabase.On("Get", 9).Return(newLegacyBitcoin("9"), nil)
+	id, symbol, foundedDate, website, blockReward := "9", "b.tc", "2008-10-31", "bitcoin.org", 6.25
+	cryptoAssetUpdate := &models.CryptoAsset{ID: &id, Symbol: &symbol, FoundedDate: &foundedDate, Website: &website,
+		BlockReward: &blockReward}
+	mockDatabase.On("Update", 9, cryptoAssetUpdate, "anonymous").Return(nil).Once()
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code.
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+
+	// A website that is changed must follow the current format.
+	recorder = httptest.NewRecorder()
+	req = httptest.NewRequest("PATCH", "/assets/9", strings.NewReader("{\"website\": \"bitcoin.com\"}"))
+	mockRouter.ServeHTTP(recorder, req)
+	assertResponseCode(t, http.StatusBadRequest, recorder.Code)
+	assertProblem(t, invalidFieldCode, "website", "website must be an http or https URL", recorder)
+}
+
+func testPatchAssetDryRun(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call. A dry run makes the update and rolls it back, returning the
+	// crypto asset as it would be after the update along with the version the update would give it.
+	req := httptest.NewRequest("PATCH", "/assets/8?dryRun=true", strings.NewReader("{\"blockReward\": 6.25}"))
+	id, blockReward := "8", 6.25
+	bitcoin := newBitcoin()
+	version := 5
+	bitcoin.BlockReward, bitcoin.Version = &blockReward, &version
+	mockDatabase.On("Get", 8).Return(newBitcoin(), nil)
+	mockDatabase.On("DryRunUpdate", 8, &models.CryptoAsset{ID: &id, BlockReward: &blockReward}, "anonymous").
+		Return(bitcoin, nil).Once()
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+	mockDatabase.AssertNotCalled(t, "Update", 8, mock.Anything, mock.Anything)
+
+	// Assert the expected HTTP response code, ETag and body.
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+	if etag := recorder.Header().Get("ETag"); etag != "\"5\"" {
+		t.Fatalf("unexpected ETag header\n\nexpected: \"5\"\nactual: %s", etag)
+	}
+	assertResponseBody(t, strings.Replace(formattedBitcoin, "12.5", "6.25", 1), recorder.Body.String())
+
+	// A dry run fails the same way as the real update would: a deleted crypto asset is not found, there must be
+	// something to update and the symbol must not be taken.
+	testPatchAssetDryRunError(t, mockRouter, mockDatabase, "{\"blockReward\": 6.25}", database.NewUnknownIDError(8),
+		http.StatusNotFound, cryptoAssetNotFoundCode, "")
+	testPatchAssetDryRunError(t, mockRouter, mockDatabase, "{}", database.NewEmptyUpdateError(),
+		http.StatusBadRequest, emptyUpdateCode, "")
+	testPatchAssetDryRunError(t, mockRouter, mockDatabase, "{\"symbol\": \"eth\"}",
+		database.NewUniqueConstraintError("eth"), http.StatusBadRequest, duplicateSymbolCode, "symbol")
+}
+
+// testPatchAssetDryRunError makes a dry run of a patch that fails with the given database error and asserts that it is
+// responded to with the expected problem.
+func testPatchAssetDryRunError(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock, body string,
+	err error, expectedStatus int, expectedCode, expectedField string) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call.
+	req := httptest.NewRequest("PATCH", "/assets/8?dryRun=true", strings.NewReader(body))
+	mockDatabase.On("DryRunUpdate", 8, mock.Anything, "anonymous").Return(nil, err).Once()
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, expectedStatus, recorder.Code)
+	assertProblem(t, expectedCode, expectedField, err.Error(), recorder)
+}
+
+func testPatchAssetInvalidIfMatch(t *testing.T, mockRouter *gin.Engine) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request with an If-Match header that is not the ETag of a crypto asset.
+	req := httptest.NewRequest("PATCH", "/assets/1", strings.NewReader("{\"blockReward\": 12.5}"))
+	req.Header.Set("If-Match", "W/\"2\"")
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusBadRequest, recorder.Code)
+	assertProblem(t, invalidParameterCode, "If-Match", "invalid If-Match: W/\"2\"", recorder)
+}
+
+func testPatchAssetIfMatch(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create a partial crypto asset update that expects the crypto asset to be at version 2.
+	id := "5"
+	website := "https://bitcoin.org"
+	expectedVersion := 2
+	cryptoAssetUpdate := &models.CryptoAsset{ID: &id, Website: &website, Version: &expectedVersion}
+
+	// The update is made and the new version is returned as the ETag if the crypto asset is at the expected version.
+	newVersion := 3
+	bitcoin := newBitcoin()
+	bitcoin.Version = &newVersion
+	mockDatabase.On("Update", 5, cryptoAssetUpdate, "anonymous").Return(nil).Once()
+	mockDatabase.On("Get", 5).Return(bitcoin, nil)
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest("PATCH", "/assets/5", strings.NewReader("{\"website\": \"https://bitcoin.org\"}"))
+	req.Header.Set("If-Match", "\"2\"")
+	mockRouter.ServeHTTP(recorder, req)
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+	if etag := recorder.Header().Get("ETag"); etag != "\"3\"" {
+		t.Fatalf("unexpected ETag header\n\nexpected: \"3\"\nactual: %s", etag)
+	}
+
+	// Making the same update again fails because the crypto asset has moved on to version 3.
+	mockDatabase.On("Update", 5, cryptoAssetUpdate, "anonymous").Return(database.NewVersionMismatchError(5, 2, 3))
+	recorder = httptest.NewRecorder()
+	req = httptest.NewRequest("PATCH", "/assets/5", strings.NewReader("{\"website\": \"https://bitcoin.org\"}"))
+	req.Header.Set("If-Match", "\"2\"")
+	mockRouter.ServeHTTP(recorder, req)
+	assertResponseCode(t, http.StatusPreconditionFailed, recorder.Code)
+	assertProblem(t, versionMismatchCode, "", "crypto asset with id 5 is at version 3, not 2", recorder)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+}
+
+func testPatchAssetSuccess(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Create a partial crypto asset update. The id is filled in from the path by the server.
+	id := "1"
+	blockReward := 12.5
+	cryptoAssetUpdate := &models.CryptoAsset{ID: &id, BlockReward: &blockReward}
+
+	// Prepare the HTTP request and mock database calls.
+	req := httptest.NewRequest("PATCH", "/assets/1", strings.NewReader("{\"blockReward\": 12.5}"))
+	mockDatabase.On("Update", 1, cryptoAssetUpdate, "anonymous").Return(nil)
+	mockDatabase.On("Get", 1).Return(newBitcoin(), nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+	assertResponseBody(t, formattedBitcoin, recorder.Body.String())
+}
+
+func TestReplaceAssetEndpoint(t *testing.T) {
+	// Hide logs.
+	log.SetLevel(log.FatalLevel)
+
+	// Set up router for testing.
+	gin.SetMode(gin.TestMode)
+	mockDatabase := &database.Mock{}
+	mockRouter := setUpMockRouter(mockDatabase)
+	expectVocabularies(mockDatabase)
+	expectAudit(mockDatabase)
+	mockDatabase.On("Get", 1).Return(newBitcoin(), nil)
+
+	// Run tests.
+	testIDMismatch(t, mockRouter, "PUT")
+	testReplaceAssetNullField(t, mockRouter)
+	testReplaceAssetUniqueConstraint(t, mockRouter, mockDatabase)
+}
+
+func testReplaceAssetNullField(t *testing.T, mockRouter *gin.Engine) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request. The crypto asset is missing every field but its name.
+	req := httptest.NewRequest("PUT", "/assets/1", strings.NewReader("{\"name\": \"bitcoin\"}"))
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusBadRequest, recorder.Code)
+	assertProblem(t, invalidFieldCode, "", "symbol cannot be null; description cannot be null; team cannot be null; "+
+		"icoAmount cannot be null; blockReward cannot be null; fundingStatus cannot be null; "+
+		"foundedDate cannot be null; coinType cannot be null; website cannot be null", recorder)
+}
+
+func testReplaceAssetUniqueConstraint(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Create a complete crypto asset and marshal it into bytes to be sent to our mock router.
+	cryptoAsset := newBitcoin()
+	symbol := "eth"
+	cryptoAsset.Symbol = &symbol
+	buffer, err := json.Marshal(cryptoAsset)
+
+	// Fail the test if there is an error marshalling the crypto asset.
+	if err != nil {
+		t.Fatal("unexpected error marshaling to JSON")
+	}
+
+	// Prepare the HTTP request and mock database call.
+	req := httptest.NewRequest("PUT", "/assets/1", bytes.NewReader(buffer))
+	mockDatabase.On("Update", 1, cryptoAsset, "anonymous").Return(database.NewUniqueConstraintError(symbol))
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusBadRequest, recorder.Code)
+	assertProblem(t, duplicateSymbolCode, "symbol", "symbol eth already exists", recorder)
+}
+
+func TestAutocompleteEndpoint(t *testing.T) {
+	// Hide logs.
+	log.SetLevel(log.FatalLevel)
+
+	// Set up router for testing.
+	gin.SetMode(gin.TestMode)
+	mockDatabase := &database.Mock{}
+	mockRouter := setUpMockRouter(mockDatabase)
+
+	// Run tests.
+	testAutocompleteEmptyPrefix(t, mockRouter)
+	testAutocompleteInvalidLimit(t, mockRouter)
+	testAutocompleteDatabaseError(t, mockRouter, mockDatabase)
+	testAutocompleteSuccess(t, mockRouter, mockDatabase)
+}
+
+func testAutocompleteEmptyPrefix(t *testing.T, mockRouter *gin.Engine) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request.
+	req := httptest.NewRequest("GET", "/autocomplete?prefix=%20", nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusBadRequest, recorder.Code)
+	assertProblem(t, invalidParameterCode, "prefix", "prefix cannot be empty", recorder)
+}
+
+func testAutocompleteInvalidLimit(t *testing.T, mockRouter *gin.Engine) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request.
+	req := httptest.NewRequest("GET", "/autocomplete?prefix=eth&limit=51", nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusBadRequest, recorder.Code)
+	assertProblem(t, invalidParameterCode, "limit", "limit must be between 1 and 50: 51", recorder)
+}
+
+func testAutocompleteDatabaseError(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call.
+	req := httptest.NewRequest("GET", "/autocomplete?prefix=btc", nil)
+	mockDatabase.On("Autocomplete", "btc", 10).Return(nil, errors.New("database error"))
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusInternalServerError, recorder.Code)
+	assertProblem(t, internalErrorCode, "", "internal server error", recorder)
+}
+
+func testAutocompleteSuccess(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call. Note that the prefix is normalized.
+	req := httptest.NewRequest("GET", "/autocomplete?prefix=%20ETH&limit=2", nil)
+	mockDatabase.On("Autocomplete", "eth", 2).Return([]*models.Suggestion{
+		{ID: "2", Name: "ethereum", Symbol: "eth"},
+		{ID: "3", Name: "ethereum classic", Symbol: "etc"},
+	}, nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+	assertResponseBody(t, "[{\"id\":\"2\",\"name\":\"Ethereum\",\"symbol\":\"ETH\",\"distance\":0},"+
+		"{\"id\":\"3\",\"name\":\"Ethereum Classic\",\"symbol\":\"ETC\",\"distance\":0}]", recorder.Body.String())
+}
+
+func TestServe(t *testing.T) {
+	// Hide logs.
+	log.SetLevel(log.FatalLevel)
+
+	// Set up a server listening on any free port.
+	gin.SetMode(gin.TestMode)
+	cfg := config.Default()
+	cfg.Address = "127.0.0.1:0"
+	server := NewServer(&database.Mock{}, cfg)
+
+	// Run the server and tell it to stop.
+	stop := make(chan os.Signal, 1)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.serve(stop)
+	}()
+	stop <- syscall.SIGTERM
+
+	// Assert the server shuts down cleanly.
+	select {
+	case err := <-errs:
+		if err != nil {
+			t.Fatalf("unexpected error shutting down: %s", err.Error())
+		}
+	case <-time.After(cfg.ShutdownTimeout):
+		t.Fatal("server did not shut down")
+	}
+}
+
+func testIDMismatch(t *testing.T, mockRouter *gin.Engine, method string) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request with an id in the body that differs from the id in the path.
+	req := httptest.NewRequest(method, "/assets/1", strings.NewReader("{\"id\": \"2\"}"))
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusBadRequest, recorder.Code)
+	assertProblem(t, idMismatchCode, "id", "id in request body does not match the id in the path", recorder)
+}
+
+func testInvalidPathID(t *testing.T, mockRouter *gin.Engine, method string) {
+	testInvalidPathIDEndpoint(t, mockRouter, method, "/assets/a")
+}
+
+func testInvalidPathIDEndpoint(t *testing.T, mockRouter *gin.Engine, method, target string) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request with a non-numeric id.
+	req := httptest.NewRequest(method, target, nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusBadRequest, recorder.Code)
+	assertProblem(t, invalidParameterCode, "id", "invalid id: a", recorder)
+}
+
+// formattedBitcoin is the JSON a formatted newBitcoin() crypto asset is expected to render as.
+const formattedBitcoin = "{\"id\":\"1\",\"name\":\"Bitcoin\",\"symbol\":\"BTC\"," +
+	"\"description\":\"The original cryptocurrency\",\"team\":[\"Satoshi Nakomoto\"],\"icoAmount\":0," +
+	"\"blockReward\":12.5,\"fundingStatus\":\"NO-ICO\",\"foundedDate\":\"2009-01-03\",\"coinType\":\"Currency\"," +
+	"\"website\":\"https://bitcoin.org/en/\"}"
+
+// newBitcoin creates a normalized crypto asset that can be used in tests.
+func newBitcoin() *models.CryptoAsset {
+	id := "1"
+	name := "bitcoin"
+	symbol := "btc"
+	description := "The original cryptocurrency"
+	var icoAmount float64
+	blockReward := 12.5
+	fundingStatus := "no-ico"
+	foundedDate := "2009-01-03"
+	coinType := "currency"
+	website := "https://bitcoin.org/en/"
+	return &models.CryptoAsset{
+		ID:            &id,
+		Name:          &name,
+		Symbol:        &symbol,
+		Description:   &description,
+		Team:          []string{"Satoshi Nakomoto"},
+		ICOAmount:     &icoAmount,
+		BlockReward:   &blockReward,
+		FundingStatus: &fundingStatus,
+		FoundedDate:   &foundedDate,
+		CoinType:      &coinType,
+		Website:       &website,
+	}
+}
+
+// newLitecoin creates a normalized crypto asset that can be used in tests.
+func newLitecoin() *models.CryptoAsset {
+	id := "3"
+	name := "litecoin"
+	symbol := "ltc"
+	description := "Silver to bitcoin's gold"
+	var icoAmount float64
+	blockReward := 12.5
+	fundingStatus := "no-ico"
+	foundedDate := "2011-10-07"
+	coinType := "currency"
+	website := "https://litecoin.org"
+	return &models.CryptoAsset{
+		ID:            &id,
+		Name:          &name,
+		Symbol:        &symbol,
+		Description:   &description,
+		Team:          []string{"Charlie Lee"},
+		ICOAmount:     &icoAmount,
+		BlockReward:   &blockReward,
+		FundingStatus: &fundingStatus,
+		FoundedDate:   &foundedDate,
+		CoinType:      &coinType,
+		Website:       &website,
+	}
+}
+
+// newLegacyBitcoin returns bitcoin with the given id as it could have been stored before symbols, founded dates and
+// websites had to follow their current format.
+func newLegacyBitcoin(id string) *models.CryptoAsset {
+	bitcoin := newBitcoin()
+	symbol, foundedDate, website := "b.tc", "2008-10-31", "bitcoin.org"
+	bitcoin.ID, bitcoin.Symbol, bitcoin.FoundedDate, bitcoin.Website = &id, &symbol, &foundedDate, &website
+	return bitcoin
+}
+
+func assertResponseBody(t *testing.T, expected, actual string) {
+	if expected != actual {
+		t.Fatalf("unexpected response body\n\nexpected: %s\nactual: %s", expected, actual)
+	}
+}
+
+// assertProblem asserts that the response is a problem with the expected code, field and detail that carries the
+// response's status code and request id. The violations of a validation problem are checked by assertViolations.
+func assertProblem(t *testing.T, expectedCode, expectedField, expectedDetail string,
+	recorder *httptest.ResponseRecorder) {
+
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "application/problem+json" {
+		t.Fatalf("unexpected content type: %s", contentType)
+	}
+	actual := &problem{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), actual); err != nil {
+		t.Fatalf("unexpected response body: %s", recorder.Body.String())
+	}
+	expected := &problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(recorder.Code),
+		Status:    recorder.Code,
+		Detail:    expectedDetail,
+		Instance:  actual.Instance,
+		Code:      expectedCode,
+		Field:     expectedField,
+		RequestID: recorder.Header().Get("X-Request-ID"),
+		Errors:    actual.Errors,
+		Rows:      actual.Rows,
+	}
+	if !reflect.DeepEqual(expected, actual) || actual.Instance == "" || actual.RequestID == "" {
+		t.Fatalf("unexpected problem\n\nexpected: %+v\nactual: %+v", expected, actual)
+	}
+}
+
+// assertViolations asserts that the response is a problem listing the expected violations.
+func assertViolations(t *testing.T, expectedViolations []*models.Violation, recorder *httptest.ResponseRecorder) {
+	actual := &problem{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), actual); err != nil {
+		t.Fatalf("unexpected response body: %s", recorder.Body.String())
+	}
+	if !reflect.DeepEqual(expectedViolations, actual.Errors) {
+		t.Fatalf("unexpected violations\n\nexpected: %s\nactual: %s", formatViolations(expectedViolations),
+			formatViolations(actual.Errors))
+	}
+}
+
+// formatViolations formats violations for a failed assertion.
+func formatViolations(violations []*models.Violation) string {
+	formatted := make([]string, len(violations))
+	for idx, violation := range violations {
+		formatted[idx] = fmt.Sprintf("%+v", *violation)
+	}
+	return strings.Join(formatted, ", ")
+}
+
+func assertResponseCode(t *testing.T, expected, actual int) {
+	if expected != actual {
+		t.Fatalf("unexpected response code\n\nexpected: %d\nactual: %d", expected, actual)
+	}
+}
+
+// setUpMockRouter sets up a router with auth off. Auth is tested separately by setUpMockAuthRouter.
+func setUpMockRouter(mockDatabase *database.Mock) *gin.Engine {
+	cfg := config.Default()
+	cfg.Auth = false
+	server := NewServer(mockDatabase, cfg)
+	return server.initializeRouter()
+}
+
+// startTestServer serves a router with the mock database the way serve does, with the given read and write timeouts.
+func startTestServer(mockDatabase *database.Mock, readTimeout, writeTimeout time.Duration) *httptest.Server {
+	httpServer := httptest.NewUnstartedServer(withResponseController(setUpMockRouter(mockDatabase)))
+	httpServer.Config.ReadTimeout, httpServer.Config.WriteTimeout = readTimeout, writeTimeout
+	httpServer.Start()
+	return httpServer
+}
+
+// expectVocabularies lets the mock database return the controlled vocabularies any number of times.
+func expectVocabularies(mockDatabase *database.Mock) {
+	mockDatabase.On("Vocabularies", true).Return(newVocabularies(), nil)
+}
+
+// newVocabularies returns the controlled vocabularies as they are when the database is created.
+func newVocabularies() models.Vocabularies {
+	return models.Vocabularies{
+		models.CoinTypeVocabulary: {
+			{Value: "currency", Aliases: []string{"coin", "cryptocurrency"}},
+			{Value: "governance", Aliases: []string{}},
+			{Value: "platform", Aliases: []string{}},
+			{Value: "privacy", Aliases: []string{}},
+			{Value: "stablecoin", Aliases: []string{"stable coin"}},
+			{Value: "storage", Aliases: []string{}},
+			{Value: "token", Aliases: []string{}},
+		},
+		models.FundingStatusVocabulary: {
+			{Value: "ico", Aliases: []string{}},
+			{Value: "no-ico", Aliases: []string{"no ico", "none"}},
+			{Value: "post-ico", Aliases: []string{"post ico"}},
+			{Value: "pre-ico", Aliases: []string{"pre ico", "presale"}},
+		},
+	}
+}
+
+// expectAudit lets the mock database record any number of audit log entries.
+func expectAudit(mockDatabase *database.Mock) {
+	mockDatabase.On("Audit", mock.Anything).Return(nil)
+}
+
+func testInvalidJSON(t *testing.T, mockRouter *gin.Engine, endpoint string) {
+	testInvalidJSONMethod(t, mockRouter, "POST", endpoint)
 }
 
-func testInvalidJSON(t *testing.T, mockRouter *gin.Engine, endpoint, expectedResponse string) {
+func testInvalidJSONMethod(t *testing.T, mockRouter *gin.Engine, method, endpoint string) {
 	// Create the response recorder.
 	recorder := httptest.NewRecorder()
 
 	// Prepare the HTTP request.
-	req := httptest.NewRequest("POST", endpoint, strings.NewReader("{"))
+	req := httptest.NewRequest(method, endpoint, strings.NewReader("{"))
 
 	// Make the request.
 	mockRouter.ServeHTTP(recorder, req)
 
 	// Assert the expected HTTP response code and body.
 	assertResponseCode(t, http.StatusBadRequest, recorder.Code)
-	assertResponseBody(t, expectedResponse, recorder.Body.String())
+	assertProblem(t, invalidBodyCode, "", "unexpected EOF", recorder)
 }
 
-func testNormalizationError(t *testing.T, mockRouter *gin.Engine, endpoint, expectedResponse string) {
+func testNormalizationError(t *testing.T, mockRouter *gin.Engine, endpoint string) {
 	// Create the response recorder.
 	recorder := httptest.NewRecorder()
 
 	// Create a crypto asset with an invalid date and marshal it into bytes to be sent to our mock router.
-	id := "4"
 	nonISO8601Date := "12/25/2017"
-	invalidCryptoAsset := &models.CryptoAsset{ID: &id, Team: []string{}, FoundedDate: &nonISO8601Date}
+	invalidCryptoAsset := newBitcoin()
+	invalidCryptoAsset.FoundedDate = &nonISO8601Date
 	buffer, err := json.Marshal(invalidCryptoAsset)
 
 	// Fail the test if there is an error marshalling the valid crypto asset.
@@ -447,10 +1836,10 @@ func testNormalizationError(t *testing.T, mockRouter *gin.Engine, endpoint, expe
 
 	// Assert the expected HTTP response code and body.
 	assertResponseCode(t, http.StatusBadRequest, recorder.Code)
-	assertResponseBody(t, expectedResponse, recorder.Body.String())
+	assertProblem(t, invalidFieldCode, "foundedDate", "date must be an ISO-8601 date in the past", recorder)
 }
 
-func testNull(t *testing.T, mockRouter *gin.Engine, endpoint, expectedResponse string) {
+func testNull(t *testing.T, mockRouter *gin.Engine, endpoint, expectedField string) {
 	// Create the response recorder.
 	recorder := httptest.NewRecorder()
 
@@ -462,5 +1851,5 @@ func testNull(t *testing.T, mockRouter *gin.Engine, endpoint, expectedResponse s
 
 	// Assert the expected HTTP response code and body.
 	assertResponseCode(t, http.StatusBadRequest, recorder.Code)
-	assertResponseBody(t, expectedResponse, recorder.Body.String())
+	assertProblem(t, nullFieldCode, expectedField, expectedField+" cannot be null", recorder)
 }
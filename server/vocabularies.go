@@ -0,0 +1,162 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/paddyquinn/messari/database"
+	"github.com/paddyquinn/messari/database/models"
+	"github.com/paddyquinn/messari/util"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Vocabulary path parameter constants.
+	valueParam      = "value"
+	vocabularyParam = "vocabulary"
+
+	// includeRetiredParam includes retired values when listing the vocabularies.
+	includeRetiredParam = "includeRetired"
+
+	// Vocabulary error string constants.
+	addValueError     = "could not add the value to the vocabulary"
+	renameValueError  = "could not rename the value of the vocabulary"
+	retireValueError  = "could not retire the value of the vocabulary"
+	vocabulariesError = "could not get the vocabularies"
+)
+
+// normalize normalizes and validates the crypto asset against the current vocabularies, returning its id. See
+// models.CryptoAsset.Normalize.
+func (s *Server) normalize(cryptoAsset *models.CryptoAsset, complete bool) (int, error) {
+	return s.normalizeChanges(cryptoAsset, complete, nil)
+}
+
+// normalizeChanges normalizes and validates the crypto asset like normalize, but as a write over the given stored
+// crypto asset, so that values that are already stored are not held to the format rules added since. See
+// models.CryptoAsset.NormalizeChanges.
+func (s *Server) normalizeChanges(cryptoAsset *models.CryptoAsset, complete bool, stored *models.CryptoAsset) (int,
+	error) {
+	vocabularies, err := s.DB.Vocabularies(true)
+	if err != nil {
+		return -1, err
+	}
+	return cryptoAsset.NormalizeChanges(vocabularies, complete, stored)
+}
+
+// canonicalize replaces the funding statuses and coin types the query filters by with the values of the current
+// vocabularies that they are aliases of, if any, so that searching for an alias finds the crypto assets with its value.
+func (s *Server) canonicalize(query *database.Query) error {
+	if len(query.FundingStatuses) == 0 && len(query.CoinTypes) == 0 {
+		return nil
+	}
+
+	vocabularies, err := s.DB.Vocabularies(true)
+	if err != nil {
+		return err
+	}
+	for idx, fundingStatus := range query.FundingStatuses {
+		query.FundingStatuses[idx] = vocabularies.Canonicalize(models.FundingStatusVocabulary, fundingStatus)
+	}
+	for idx, coinType := range query.CoinTypes {
+		query.CoinTypes[idx] = vocabularies.Canonicalize(models.CoinTypeVocabulary, coinType)
+	}
+	return nil
+}
+
+// listVocabularies returns the values of every controlled vocabulary along with their aliases. Passing
+// "includeRetired=true" in the query string includes the values that have been retired.
+func (s *Server) listVocabularies(ctx *gin.Context) {
+	// Initialize the logger.
+	logger := log.WithField(endpoint, vocabulariesEndpoint)
+
+	// Parse whether to include retired values from the query string.
+	includeRetired, err := parseBoolean(ctx, includeRetiredParam)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(queryError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	vocabularies, err := s.DB.Vocabularies(includeRetired != nil && *includeRetired)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(vocabulariesError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, vocabularies)
+}
+
+// addVocabularyValue adds the value passed in via the request body, along with its aliases, to the vocabulary given in
+// the path and returns it.
+func (s *Server) addVocabularyValue(ctx *gin.Context) {
+	// Initialize the logger.
+	vocabulary := ctx.Param(vocabularyParam)
+	logger := log.WithFields(log.Fields{endpoint: vocabularyEndpoint, vocabularyParam: vocabulary})
+
+	// Parse the value passed in via the request body.
+	value, err := models.NewVocabularyValue(ctx.Request.Body)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(parseError)
+		respondWithInvalidBody(ctx, err)
+		return
+	}
+	setAuditPayload(ctx, map[string]interface{}{vocabularyParam: vocabulary, valueParam: value})
+
+	// Add the value to the vocabulary in the database.
+	if err = s.DB.AddVocabularyValue(vocabulary, value); err != nil {
+		logger.WithField(errKey, err.Error()).Error(addValueError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	// Return the value back to the user.
+	ctx.JSON(http.StatusOK, value)
+}
+
+// renameVocabularyValue renames the value of the vocabulary given in the path to the value passed in via the request
+// body. Every crypto asset with the value is changed to the new one, and the old value becomes an alias of it.
+func (s *Server) renameVocabularyValue(ctx *gin.Context) {
+	// Initialize the logger.
+	vocabulary, value := ctx.Param(vocabularyParam), *util.Normalize(ctx.Param(valueParam))
+	logger := log.WithFields(log.Fields{endpoint: renameValueEndpoint, vocabularyParam: vocabulary, valueParam: value})
+
+	// Parse the new value passed in via the request body.
+	newValue, err := models.NewVocabularyValue(ctx.Request.Body)
+	if err != nil {
+		logger.WithField(errKey, err.Error()).Error(parseError)
+		respondWithInvalidBody(ctx, err)
+		return
+	}
+	setAuditPayload(ctx, map[string]string{vocabularyParam: vocabulary, "from": value, "to": newValue.Value})
+
+	// Rename the value in the database.
+	if err = s.DB.RenameVocabularyValue(vocabulary, value, newValue.Value, getActor(ctx)); err != nil {
+		logger.WithField(errKey, err.Error()).Error(renameValueError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	// There is nothing left to return to the user.
+	ctx.Status(http.StatusNoContent)
+}
+
+// retireVocabularyValue retires the value of the vocabulary given in the path so that it can no longer be given to a
+// crypto asset. Crypto assets that already have the value keep it.
+func (s *Server) retireVocabularyValue(ctx *gin.Context) {
+	// Initialize the logger.
+	vocabulary, value := ctx.Param(vocabularyParam), *util.Normalize(ctx.Param(valueParam))
+	logger := log.WithFields(log.Fields{endpoint: vocabularyValueEndpoint, vocabularyParam: vocabulary,
+		valueParam: value})
+	setAuditPayload(ctx, map[string]string{vocabularyParam: vocabulary, valueParam: value})
+
+	// Retire the value in the database.
+	if err := s.DB.RetireVocabularyValue(vocabulary, value); err != nil {
+		logger.WithField(errKey, err.Error()).Error(retireValueError)
+		respondWithError(ctx, err)
+		return
+	}
+
+	// There is nothing left to return to the user.
+	ctx.Status(http.StatusNoContent)
+}
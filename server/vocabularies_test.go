@@ -0,0 +1,178 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/paddyquinn/messari/database"
+	"github.com/paddyquinn/messari/database/models"
+	log "github.com/sirupsen/logrus"
+)
+
+func TestVocabularyEndpoints(t *testing.T) {
+	// Hide logs.
+	log.SetLevel(log.FatalLevel)
+
+	// Set up router for testing.
+	gin.SetMode(gin.TestMode)
+	mockDatabase := &database.Mock{}
+	mockRouter := setUpMockRouter(mockDatabase)
+
+	// Run tests. Every test but the first makes a write, which is recorded in the audit log.
+	testListVocabularies(t, mockRouter, mockDatabase)
+	expectAudit(mockDatabase)
+	testAddVocabularyValueUnknownVocabulary(t, mockRouter, mockDatabase)
+	testAddVocabularyValueSuccess(t, mockRouter, mockDatabase)
+	testRenameVocabularyValueDuplicate(t, mockRouter, mockDatabase)
+	testRenameVocabularyValueSuccess(t, mockRouter, mockDatabase)
+	testRetireVocabularyValueNotFound(t, mockRouter, mockDatabase)
+	testRetireVocabularyValueSuccess(t, mockRouter, mockDatabase)
+}
+
+func testListVocabularies(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call.
+	retiredAt := "2018-06-01T12:00:00Z"
+	req := httptest.NewRequest("GET", "/vocabularies?includeRetired=true", nil)
+	mockDatabase.On("Vocabularies", true).Return(models.Vocabularies{
+		models.CoinTypeVocabulary: {{Value: "currency", Aliases: []string{"coin"}}},
+		models.FundingStatusVocabulary: {{Value: "no-ico", Aliases: []string{}},
+			{Value: "pre-ico", Aliases: []string{"presale"}, RetiredAt: &retiredAt}},
+	}, nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+	assertResponseBody(t, "{\"coinType\":[{\"value\":\"currency\",\"aliases\":[\"coin\"]}],\"fundingStatus\":"+
+		"[{\"value\":\"no-ico\",\"aliases\":[]},{\"value\":\"pre-ico\",\"aliases\":[\"presale\"],"+
+		"\"retiredAt\":\"2018-06-01T12:00:00Z\"}]}", recorder.Body.String())
+}
+
+func testAddVocabularyValueUnknownVocabulary(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call.
+	req := httptest.NewRequest("POST", "/vocabularies/color", strings.NewReader("{\"value\":\"red\"}"))
+	mockDatabase.On("AddVocabularyValue", "color", &models.VocabularyValue{Value: "red", Aliases: []string{}}).
+		Return(database.NewUnknownVocabularyError("color"))
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusNotFound, recorder.Code)
+	assertProblem(t, vocabularyNotFoundCode, "", "unknown vocabulary color", recorder)
+}
+
+func testAddVocabularyValueSuccess(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call. The value and its aliases are normalized.
+	req := httptest.NewRequest("POST", "/vocabularies/coinType",
+		strings.NewReader("{\"value\":\" Meme \",\"aliases\":[\"Meme Coin\"]}"))
+	mockDatabase.On("AddVocabularyValue", "coinType",
+		&models.VocabularyValue{Value: "meme", Aliases: []string{"meme coin"}}).Return(nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusOK, recorder.Code)
+	assertResponseBody(t, "{\"value\":\"meme\",\"aliases\":[\"meme coin\"]}", recorder.Body.String())
+}
+
+func testRenameVocabularyValueDuplicate(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call.
+	req := httptest.NewRequest("POST", "/vocabularies/coinType/currency/rename",
+		strings.NewReader("{\"value\":\"token\"}"))
+	mockDatabase.On("RenameVocabularyValue", "coinType", "currency", "token", "anonymous").
+		Return(database.NewDuplicateVocabularyValueError("coinType", "token"))
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusBadRequest, recorder.Code)
+	assertProblem(t, duplicateValueCode, "value", "coinType token already exists", recorder)
+}
+
+func testRenameVocabularyValueSuccess(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call. Both the old and the new value are normalized.
+	req := httptest.NewRequest("POST", "/vocabularies/fundingStatus/No-ICO/rename",
+		strings.NewReader("{\"value\":\"No ICO \"}"))
+	mockDatabase.On("RenameVocabularyValue", "fundingStatus", "no-ico", "no ico", "anonymous").Return(nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code.
+	assertResponseCode(t, http.StatusNoContent, recorder.Code)
+}
+
+func testRetireVocabularyValueNotFound(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call.
+	req := httptest.NewRequest("DELETE", "/vocabularies/fundingStatus/airdrop", nil)
+	mockDatabase.On("RetireVocabularyValue", "fundingStatus", "airdrop").
+		Return(database.NewUnknownVocabularyValueError("fundingStatus", "airdrop"))
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code and body.
+	assertResponseCode(t, http.StatusNotFound, recorder.Code)
+	assertProblem(t, valueNotFoundCode, "", "fundingStatus airdrop not found", recorder)
+}
+
+func testRetireVocabularyValueSuccess(t *testing.T, mockRouter *gin.Engine, mockDatabase *database.Mock) {
+	// Create the response recorder.
+	recorder := httptest.NewRecorder()
+
+	// Prepare the HTTP request and mock database call.
+	req := httptest.NewRequest("DELETE", "/vocabularies/fundingStatus/pre-ico", nil)
+	mockDatabase.On("RetireVocabularyValue", "fundingStatus", "pre-ico").Return(nil)
+
+	// Make the request.
+	mockRouter.ServeHTTP(recorder, req)
+
+	// Assert the correct mock calls were made.
+	mockDatabase.AssertExpectations(t)
+
+	// Assert the expected HTTP response code.
+	assertResponseCode(t, http.StatusNoContent, recorder.Code)
+}
@@ -0,0 +1,52 @@
+package util
+
+// PrefixDistance returns the smallest Levenshtein edit distance between query and any prefix of candidate, comparing
+// runes. It is 0 if candidate starts with query. The calculation stops early and returns maxDistance+1 once every
+// prefix is known to be more than maxDistance edits away.
+func PrefixDistance(query, candidate string, maxDistance int) int {
+	queryRunes := []rune(query)
+	candidateRunes := []rune(candidate)
+
+	// previous[j] is the edit distance between the query runes seen so far and the first j runes of the candidate.
+	previous := make([]int, len(candidateRunes)+1)
+	current := make([]int, len(candidateRunes)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(queryRunes); i++ {
+		current[0] = i
+		rowMinimum := current[0]
+		for j := 1; j <= len(candidateRunes); j++ {
+			substitution := previous[j-1]
+			if queryRunes[i-1] != candidateRunes[j-1] {
+				substitution++
+			}
+			current[j] = minimum(substitution, previous[j]+1, current[j-1]+1)
+			rowMinimum = minimum(rowMinimum, current[j])
+		}
+
+		// Distances never decrease from one row to the next, so give up once the whole row is too far away.
+		if rowMinimum > maxDistance {
+			return maxDistance + 1
+		}
+		previous, current = current, previous
+	}
+
+	// The query has been consumed, so the best prefix of the candidate is the cheapest entry in the final row.
+	distance := previous[0]
+	for _, prefixDistance := range previous {
+		distance = minimum(distance, prefixDistance)
+	}
+	return distance
+}
+
+// minimum returns the smallest of the passed integers.
+func minimum(first int, rest ...int) int {
+	for _, value := range rest {
+		if value < first {
+			first = value
+		}
+	}
+	return first
+}
@@ -0,0 +1,19 @@
+package util
+
+import "testing"
+
+func TestPrefixDistance(t *testing.T) {
+	assertDistance(t, "eth", "ethereum", 2, 0)
+	assertDistance(t, "etherum", "ethereum", 2, 1)
+	assertDistance(t, "bitcion", "bitcoin", 2, 2)
+	assertDistance(t, "", "bitcoin", 2, 0)
+	assertDistance(t, "monero", "mon", 2, 3)
+	assertDistance(t, "zzzzzz", "bitcoin", 2, 3)
+}
+
+func assertDistance(t *testing.T, query, candidate string, maxDistance, expected int) {
+	if actual := PrefixDistance(query, candidate, maxDistance); actual != expected {
+		t.Fatalf("unexpected distance between %s and %s\n\nexpected: %d\nactual: %d", query, candidate, expected,
+			actual)
+	}
+}